@@ -1,6 +1,7 @@
 "use client";
 
 import { Button } from "@/components/ui/button";
+import { authorizedFetch } from "@/hooks/use-auth";
 import { useAuth } from "@/providers/auth-provider";
 import { LogOut } from "lucide-react";
 import { useRouter } from "next/navigation";
@@ -10,7 +11,17 @@ export function LogoutButton() {
 
   const router = useRouter();
 
-  const handleLogout = () => {
+  const handleLogout = async () => {
+    // Revoke the session on the server so its refresh token stops working too
+    try {
+      await authorizedFetch(
+        `${process.env.NEXT_PUBLIC_AUTH_URL}/logout`,
+        { method: "POST" },
+        token
+      );
+    } catch {
+      // Signing out locally still ends the session in this browser
+    }
     setToken(undefined);
     router.push("/login");
   };
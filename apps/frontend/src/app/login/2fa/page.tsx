@@ -0,0 +1,229 @@
+"use client";
+
+import { useEffect, useState } from "react";
+import { useRouter } from "next/navigation";
+import { Card, CardContent, CardHeader, CardTitle } from "@/components/ui/card";
+import { Input } from "@/components/ui/input";
+import { Button } from "@/components/ui/button";
+import { useForm } from "react-hook-form";
+import { zodResolver } from "@hookform/resolvers/zod";
+import {
+  Form,
+  FormControl,
+  FormField,
+  FormItem,
+  FormMessage,
+} from "@/components/ui/form";
+import { z } from "zod";
+import { useAuth } from "@/providers/auth-provider";
+import { getPasskeyAssertion } from "@/lib/webauthn";
+
+const twoFactorFormSchema = z.object({
+  code: z.string().trim().min(1, "Please enter a code"),
+});
+
+type TwoFactorFormValues = z.infer<typeof twoFactorFormSchema>;
+
+// failureMessage explains why the auth service rejected the second factor
+const failureMessage = (response: Response) => {
+  if (response.status === 429) {
+    const retryAfter = response.headers.get("Retry-After");
+    return retryAfter
+      ? `Too many attempts. Please try again in ${retryAfter} seconds.`
+      : "Too many attempts. Please try again later.";
+  }
+  return "Failed to verify. Please try again.";
+};
+
+export default function TwoFactorLogin() {
+  const router = useRouter();
+  const { setToken } = useAuth();
+  const [challenge, setChallenge] = useState<string | null>(null);
+  const [methods, setMethods] = useState<string[]>([]);
+  const [enterRecoveryCode, setEnterRecoveryCode] = useState(false);
+  const [isLoading, setIsLoading] = useState(false);
+  const [message, setMessage] = useState("");
+
+  const form = useForm<TwoFactorFormValues>({
+    resolver: zodResolver(twoFactorFormSchema),
+    defaultValues: {
+      code: "",
+    },
+  });
+
+  // The login callbacks leave the challenge for this tab only; it expires after a few minutes
+  useEffect(() => {
+    const token = sessionStorage.getItem("login_challenge");
+    if (!token) {
+      router.push("/login");
+      return;
+    }
+    setChallenge(token);
+    setMethods(JSON.parse(sessionStorage.getItem("login_methods") ?? "[]") ?? []);
+  }, [router]);
+
+  const finishLogin = async (response: Response) => {
+    if (response.status === 401) {
+      const { message } = await response.json();
+      if (message === "invalid or expired challenge") {
+        sessionStorage.removeItem("login_challenge");
+        sessionStorage.removeItem("login_methods");
+        router.push("/login");
+        return;
+      }
+      setMessage("Failed to verify. The code is invalid.");
+      return;
+    }
+    if (!response.ok) {
+      setMessage(failureMessage(response));
+      return;
+    }
+
+    const data = await response.json();
+    sessionStorage.removeItem("login_challenge");
+    sessionStorage.removeItem("login_methods");
+    setToken(data.token, data.refresh_token);
+    router.push("/");
+  };
+
+  async function onSubmit(data: TwoFactorFormValues) {
+    setIsLoading(true);
+    setMessage("");
+
+    try {
+      const response = await fetch(
+        `${process.env.NEXT_PUBLIC_AUTH_URL}/login/2fa`,
+        {
+          method: "POST",
+          headers: {
+            "Content-Type": "application/json",
+          },
+          body: JSON.stringify({
+            challenge_token: challenge,
+            code: data.code.trim(),
+          }),
+        }
+      );
+      await finishLogin(response);
+    } catch (error) {
+      setMessage("Failed to verify. Please try again.");
+    } finally {
+      setIsLoading(false);
+    }
+  }
+
+  async function signInWithPasskey() {
+    setIsLoading(true);
+    setMessage("");
+
+    try {
+      const optionsResponse = await fetch(
+        `${process.env.NEXT_PUBLIC_AUTH_URL}/login/2fa/passkey/options`,
+        {
+          method: "POST",
+          headers: {
+            "Content-Type": "application/json",
+          },
+          body: JSON.stringify({ challenge_token: challenge }),
+        }
+      );
+      if (!optionsResponse.ok) {
+        await finishLogin(optionsResponse);
+        return;
+      }
+
+      const ceremony = await optionsResponse.json();
+      const credential = await getPasskeyAssertion(ceremony.options);
+      const response = await fetch(
+        `${process.env.NEXT_PUBLIC_AUTH_URL}/login/2fa/passkey`,
+        {
+          method: "POST",
+          headers: {
+            "Content-Type": "application/json",
+          },
+          body: JSON.stringify({
+            challenge_token: challenge,
+            session: ceremony.session,
+            credential,
+          }),
+        }
+      );
+      await finishLogin(response);
+    } catch (error) {
+      setMessage("Failed to verify the passkey. Please try again.");
+    } finally {
+      setIsLoading(false);
+    }
+  }
+
+  if (!challenge) {
+    return null;
+  }
+
+  return (
+    <Card className="w-full max-w-[400px] mx-auto mt-8 border rounded-xl bg-background backdrop-blur supports-[backdrop-filter]:bg-background/50">
+      <CardHeader>
+        <CardTitle className="text-foreground">Two-factor authentication</CardTitle>
+      </CardHeader>
+      <CardContent>
+        <Form {...form}>
+          <form onSubmit={form.handleSubmit(onSubmit)} className="space-y-4">
+            {methods.includes("totp") && (
+              <>
+                <FormField
+                  control={form.control}
+                  name="code"
+                  render={({ field }) => (
+                    <FormItem>
+                      <FormControl>
+                        <Input
+                          placeholder={
+                            enterRecoveryCode
+                              ? "Enter a recovery code"
+                              : "Enter the code from your authenticator app"
+                          }
+                          autoComplete="one-time-code"
+                          inputMode={enterRecoveryCode ? "text" : "numeric"}
+                          disabled={isLoading}
+                          className="w-full border rounded-md focus:ring-2 focus:ring-offset-2"
+                          {...field}
+                        />
+                      </FormControl>
+                      <FormMessage />
+                    </FormItem>
+                  )}
+                />
+                <Button type="submit" className="w-full" disabled={isLoading}>
+                  {isLoading ? "Verifying..." : "Verify"}
+                </Button>
+                <Button
+                  type="button"
+                  variant="link"
+                  className="w-full"
+                  disabled={isLoading}
+                  onClick={() => setEnterRecoveryCode(!enterRecoveryCode)}
+                >
+                  {enterRecoveryCode
+                    ? "Use your authenticator app instead"
+                    : "Use a recovery code instead"}
+                </Button>
+              </>
+            )}
+            {methods.includes("passkey") && (
+              <Button
+                type="button"
+                variant="outline"
+                className="w-full"
+                disabled={isLoading}
+                onClick={signInWithPasskey}
+              >
+                Use a passkey
+              </Button>
+            )}
+            {message && <p className="text-sm text-destructive">{message}</p>}
+          </form>
+        </Form>
+      </CardContent>
+    </Card>
+  );
+}
@@ -0,0 +1,67 @@
+"use client";
+
+import { useEffect } from "react";
+import { useRouter, useSearchParams } from "next/navigation";
+import { Card, CardContent, CardHeader, CardTitle } from "@/components/ui/card";
+import { useAuth } from "@/providers/auth-provider";
+
+export default function LoginCallback() {
+  const router = useRouter();
+  const { setToken } = useAuth();
+  const searchParams = useSearchParams();
+
+  useEffect(() => {
+    const code = searchParams.get("code");
+    if (!code) {
+      router.push("/login");
+      return;
+    }
+
+    // The login code works once and only for a minute; the tokens are never in the URL
+    const exchangeCode = async () => {
+      try {
+        const response = await fetch(
+          `${process.env.NEXT_PUBLIC_AUTH_URL}/login/exchange`,
+          {
+            method: "POST",
+            headers: {
+              "Content-Type": "application/json",
+            },
+            body: JSON.stringify({ code }),
+          }
+        );
+
+        if (!response.ok) {
+          throw new Error("Login code exchange failed");
+        }
+
+        const data = await response.json();
+        if (data.two_factor_required) {
+          sessionStorage.setItem("login_challenge", data.challenge_token);
+          sessionStorage.setItem("login_methods", JSON.stringify(data.two_factor_methods));
+          router.push("/login/2fa");
+          return;
+        }
+        setToken(data.token, data.refresh_token);
+        router.push("/");
+      } catch (error) {
+        router.push("/login");
+      }
+    };
+
+    exchangeCode();
+  }, [router, searchParams]);
+
+  return (
+    <Card className="w-full max-w-[400px] mx-auto mt-8 border rounded-xl bg-background backdrop-blur supports-[backdrop-filter]:bg-background/50">
+      <CardHeader>
+        <CardTitle className="text-foreground">Verifying login...</CardTitle>
+      </CardHeader>
+      <CardContent>
+        <p className="text-muted-foreground">
+          Please wait while we verify your login.
+        </p>
+      </CardContent>
+    </Card>
+  );
+}
@@ -26,7 +26,10 @@ export default function LoginCallback() {
             headers: {
               "Content-Type": "application/json",
             },
-            body: JSON.stringify({ token }),
+            body: JSON.stringify({
+              token,
+              binding: localStorage.getItem("login_binding"),
+            }),
           }
         );
 
@@ -35,7 +38,14 @@ export default function LoginCallback() {
         }
 
         const data = await response.json();
-        setToken(data.token);
+        localStorage.removeItem("login_binding");
+        if (data.two_factor_required) {
+          sessionStorage.setItem("login_challenge", data.challenge_token);
+          sessionStorage.setItem("login_methods", JSON.stringify(data.two_factor_methods));
+          router.push("/login/2fa");
+          return;
+        }
+        setToken(data.token, data.refresh_token);
         router.push("/");
       } catch (error) {
         router.push("/");
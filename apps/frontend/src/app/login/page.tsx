@@ -53,6 +53,10 @@ export default function Login() {
         throw new Error("Login request failed");
       }
 
+      // The login link only works together with this browser's binding
+      const { binding } = await response.json();
+      localStorage.setItem("login_binding", binding);
+
       setMessage("Check your email for the login link!");
     } catch (error) {
       setMessage("Failed to send login link. Please try again.");
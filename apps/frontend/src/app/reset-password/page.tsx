@@ -0,0 +1,142 @@
+"use client";
+
+import { useState } from "react";
+import { useSearchParams } from "next/navigation";
+import { Card, CardContent, CardHeader, CardTitle } from "@/components/ui/card";
+import { Input } from "@/components/ui/input";
+import { Button } from "@/components/ui/button";
+import { useForm } from "react-hook-form";
+import { zodResolver } from "@hookform/resolvers/zod";
+import {
+  Form,
+  FormControl,
+  FormField,
+  FormItem,
+  FormMessage,
+} from "@/components/ui/form";
+import { z } from "zod";
+
+const resetPasswordFormSchema = z
+  .object({
+    password: z.string().min(6, "The password must be at least 6 characters"),
+    confirmPassword: z.string(),
+  })
+  .refine((data) => data.password === data.confirmPassword, {
+    message: "The passwords do not match",
+    path: ["confirmPassword"],
+  });
+
+type ResetPasswordFormValues = z.infer<typeof resetPasswordFormSchema>;
+
+export default function ResetPassword() {
+  const searchParams = useSearchParams();
+  const token = searchParams.get("token");
+  const [isLoading, setIsLoading] = useState(false);
+  const [isReset, setIsReset] = useState(false);
+  const [message, setMessage] = useState(
+    token ? "" : "The reset link is incomplete."
+  );
+
+  const form = useForm<ResetPasswordFormValues>({
+    resolver: zodResolver(resetPasswordFormSchema),
+    defaultValues: {
+      password: "",
+      confirmPassword: "",
+    },
+  });
+
+  async function onSubmit(data: ResetPasswordFormValues) {
+    setIsLoading(true);
+    setMessage("");
+
+    try {
+      const response = await fetch(
+        `${process.env.NEXT_PUBLIC_AUTH_URL}/password/reset`,
+        {
+          method: "POST",
+          headers: {
+            "Content-Type": "application/json",
+          },
+          body: JSON.stringify({ token, password: data.password }),
+        }
+      );
+
+      if (!response.ok) {
+        throw new Error("Password reset failed");
+      }
+
+      setIsReset(true);
+      setMessage("Your password is reset. You have been signed out everywhere, log in with your new password.");
+    } catch (error) {
+      setMessage("Failed to reset the password. The reset link is invalid or has expired.");
+    } finally {
+      setIsLoading(false);
+    }
+  }
+
+  return (
+    <Card className="w-full max-w-[400px] mx-auto mt-8 border rounded-xl bg-background backdrop-blur supports-[backdrop-filter]:bg-background/50">
+      <CardHeader>
+        <CardTitle className="text-foreground">Reset password</CardTitle>
+      </CardHeader>
+      <CardContent>
+        {token && !isReset && (
+          <Form {...form}>
+            <form onSubmit={form.handleSubmit(onSubmit)} className="space-y-4">
+              <FormField
+                control={form.control}
+                name="password"
+                render={({ field }) => (
+                  <FormItem>
+                    <FormControl>
+                      <Input
+                        placeholder="New password"
+                        type="password"
+                        autoComplete="new-password"
+                        disabled={isLoading}
+                        className="w-full border rounded-md focus:ring-2 focus:ring-offset-2"
+                        {...field}
+                      />
+                    </FormControl>
+                    <FormMessage />
+                  </FormItem>
+                )}
+              />
+              <FormField
+                control={form.control}
+                name="confirmPassword"
+                render={({ field }) => (
+                  <FormItem>
+                    <FormControl>
+                      <Input
+                        placeholder="Repeat the new password"
+                        type="password"
+                        autoComplete="new-password"
+                        disabled={isLoading}
+                        className="w-full border rounded-md focus:ring-2 focus:ring-offset-2"
+                        {...field}
+                      />
+                    </FormControl>
+                    <FormMessage />
+                  </FormItem>
+                )}
+              />
+              <Button type="submit" className="w-full" disabled={isLoading}>
+                {isLoading ? "Resetting..." : "Reset password"}
+              </Button>
+            </form>
+          </Form>
+        )}
+        {message && (
+          <p
+            className={`text-sm mt-4 ${
+              isReset ? "text-primary" : "text-destructive"
+            }`}
+          >
+            {message}
+          </p>
+        )}
+      </CardContent>
+    </Card>
+  );
+}
@@ -0,0 +1,54 @@
+"use client";
+
+import { useEffect, useState } from "react";
+import { useSearchParams } from "next/navigation";
+import { Card, CardContent, CardHeader, CardTitle } from "@/components/ui/card";
+
+export default function RestoreAccount() {
+  const searchParams = useSearchParams();
+  const [message, setMessage] = useState("Please wait while we restore your account.");
+
+  useEffect(() => {
+    const token = searchParams.get("token");
+    if (!token) {
+      setMessage("The restore link is incomplete.");
+      return;
+    }
+
+    const restoreAccount = async () => {
+      try {
+        const response = await fetch(
+          `${process.env.NEXT_PUBLIC_AUTH_URL}/account/restore`,
+          {
+            method: "POST",
+            headers: {
+              "Content-Type": "application/json",
+            },
+            body: JSON.stringify({ token }),
+          }
+        );
+
+        if (!response.ok) {
+          throw new Error("Account restore failed");
+        }
+
+        setMessage("Your account is restored. You can log in again.");
+      } catch (error) {
+        setMessage("The restore link is invalid or has expired.");
+      }
+    };
+
+    restoreAccount();
+  }, [searchParams]);
+
+  return (
+    <Card className="w-full max-w-[400px] mx-auto mt-8 border rounded-xl bg-background backdrop-blur supports-[backdrop-filter]:bg-background/50">
+      <CardHeader>
+        <CardTitle className="text-foreground">Restoring account...</CardTitle>
+      </CardHeader>
+      <CardContent>
+        <p className="text-muted-foreground">{message}</p>
+      </CardContent>
+    </Card>
+  );
+}
@@ -0,0 +1,54 @@
+"use client";
+
+import { useEffect, useState } from "react";
+import { useSearchParams } from "next/navigation";
+import { Card, CardContent, CardHeader, CardTitle } from "@/components/ui/card";
+
+export default function VerifyEmail() {
+  const searchParams = useSearchParams();
+  const [message, setMessage] = useState("Please wait while we verify your email.");
+
+  useEffect(() => {
+    const token = searchParams.get("token");
+    if (!token) {
+      setMessage("The verification link is incomplete.");
+      return;
+    }
+
+    const verifyEmail = async () => {
+      try {
+        const response = await fetch(
+          `${process.env.NEXT_PUBLIC_AUTH_URL}/email/verify`,
+          {
+            method: "POST",
+            headers: {
+              "Content-Type": "application/json",
+            },
+            body: JSON.stringify({ token }),
+          }
+        );
+
+        if (!response.ok) {
+          throw new Error("Email verification failed");
+        }
+
+        setMessage("Your email address is verified.");
+      } catch (error) {
+        setMessage("The verification link is invalid or has expired.");
+      }
+    };
+
+    verifyEmail();
+  }, [searchParams]);
+
+  return (
+    <Card className="w-full max-w-[400px] mx-auto mt-8 border rounded-xl bg-background backdrop-blur supports-[backdrop-filter]:bg-background/50">
+      <CardHeader>
+        <CardTitle className="text-foreground">Verifying email...</CardTitle>
+      </CardHeader>
+      <CardContent>
+        <p className="text-muted-foreground">{message}</p>
+      </CardContent>
+    </Card>
+  );
+}
@@ -3,6 +3,84 @@ import { useRouter } from "next/navigation";
 import { HTTP_METHOD } from "next/dist/server/web/http";
 import { useEffect, useState } from "react";
 
+// refreshing is shared so that requests failing together rotate the refresh token once;
+// presenting a refresh token a second time revokes the whole session
+let refreshing: Promise<string | undefined> | undefined;
+
+const notifySession = () => window.dispatchEvent(new Event("auth-session"));
+
+export const storeSession = (token: string, refreshToken?: string) => {
+  localStorage.setItem("token", token);
+  if (refreshToken) {
+    localStorage.setItem("refresh_token", refreshToken);
+  }
+  notifySession();
+};
+
+export const clearSession = () => {
+  localStorage.removeItem("token");
+  localStorage.removeItem("refresh_token");
+  notifySession();
+};
+
+// refreshSession trades the stored refresh token for new tokens, returning the new access
+// token. The session is cleared when the refresh token is no longer accepted.
+export const refreshSession = () => {
+  if (!refreshing) {
+    refreshing = (async () => {
+      const refreshToken = localStorage.getItem("refresh_token");
+      if (!refreshToken) {
+        clearSession();
+        return undefined;
+      }
+      try {
+        const res = await fetch(
+          `${process.env.NEXT_PUBLIC_AUTH_URL}/token/refresh`,
+          {
+            method: "POST",
+            headers: { "Content-Type": "application/json" },
+            body: JSON.stringify({ refresh_token: refreshToken }),
+          }
+        );
+        if (!res.ok) {
+          clearSession();
+          return undefined;
+        }
+        const data = await res.json();
+        storeSession(data.token, data.refresh_token);
+        return data.token as string;
+      } catch {
+        return undefined;
+      } finally {
+        refreshing = undefined;
+      }
+    })();
+  }
+  return refreshing;
+};
+
+// authorizedFetch sends the request with the access token and, when it has expired,
+// refreshes the session and retries once with the new token
+export const authorizedFetch = async (
+  url: string,
+  opts: RequestInit,
+  token?: string
+) => {
+  const withToken = (token?: string): RequestInit =>
+    token
+      ? {
+          ...opts,
+          headers: { ...opts.headers, Authorization: `Bearer ${token}` },
+        }
+      : opts;
+  const res = await fetch(url, withToken(token));
+  if (res.status !== 401 || !token) {
+    return res;
+  }
+  const refreshed = await refreshSession();
+  return refreshed ? fetch(url, withToken(refreshed)) : res;
+};
+
 export const fetcher = async <T = unknown>(
   path: string,
   {
@@ -20,15 +98,13 @@ export const fetcher = async <T = unknown>(
   if (data) {
     opts["body"] = JSON.stringify(data);
   }
-  if (token) {
-    opts["headers"] = { Authorization: `Bearer ${token}` };
-  }
-  const res = await fetch(
+  const res = await authorizedFetch(
     `${process.env.NEXT_PUBLIC_AUTH_URL}/${path
       .split("/")
       .filter((x) => !!x)
       .join("/")}`,
-    opts
+    opts,
+    token
   );
   return res.json() as T;
 };
@@ -46,7 +122,16 @@ export function useAuth() {
   useEffect(() => {
     const storedToken = localStorage.getItem("token");
     if (!storedToken) router.push("/login");
-    setToken(storedToken);
+    setStateToken(storedToken);
+
+    // Follow refreshes and sign-outs made by requests or other components
+    const onSession = () => {
+      const current = localStorage.getItem("token");
+      setStateToken(current ?? undefined);
+      if (!current) router.push("/login");
+    };
+    window.addEventListener("auth-session", onSession);
+    return () => window.removeEventListener("auth-session", onSession);
   }, []);
 
   const { data: user, error } = useSWR(
@@ -54,14 +139,11 @@ export function useAuth() {
     ([url, token]) => !!token && fetcher<User>(url, { token })
   );
 
-  const setToken = (newToken?: string) => {
+  const setToken = (newToken?: string, refreshToken?: string) => {
     if (newToken) {
-      localStorage.setItem("token", newToken);
-      setStateToken(newToken);
+      storeSession(newToken, refreshToken);
     } else {
-      localStorage.removeItem("token");
-      setStateToken(undefined);
-      router.push("/login");
+      clearSession();
     }
   };
 
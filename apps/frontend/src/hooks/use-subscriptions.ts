@@ -2,7 +2,7 @@ import useSWR from "swr";
 import useSWRMutation from "swr/mutation";
 import { Subscription } from "../types/subscription";
 import { HTTP_METHOD } from "next/dist/server/web/http";
-import { useAuth } from "./use-auth";
+import { authorizedFetch, useAuth } from "./use-auth";
 
 export const fetcher = async <T = unknown>(
   path: string,
@@ -21,15 +21,13 @@ export const fetcher = async <T = unknown>(
   if (data) {
     opts["body"] = JSON.stringify(data);
   }
-  if (token) {
-    opts["headers"] = { Authorization: `Bearer ${token}` };
-  }
-  const res = await fetch(
+  const res = await authorizedFetch(
     `${process.env.NEXT_PUBLIC_API_URL}/${path
       .split("/")
       .filter((x) => !!x)
       .join("/")}`,
-    opts
+    opts,
+    token
   );
   return res.json() as T;
 };
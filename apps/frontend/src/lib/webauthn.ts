@@ -0,0 +1,53 @@
+// WebAuthn sends binary fields as base64url strings in JSON, as PublicKeyCredential.toJSON() does
+
+const toBuffer = (value: string) => {
+  const base64 = value.replace(/-/g, "+").replace(/_/g, "/");
+  const binary = atob(base64.padEnd(Math.ceil(base64.length / 4) * 4, "="));
+  return Uint8Array.from(binary, (c) => c.charCodeAt(0)).buffer;
+};
+
+const fromBuffer = (buffer: ArrayBuffer | null) => {
+  if (!buffer) {
+    return undefined;
+  }
+  const binary = String.fromCharCode(...new Uint8Array(buffer));
+  return btoa(binary).replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "");
+};
+
+export interface RequestOptionsJSON {
+  challenge: string;
+  rpId: string;
+  allowCredentials: { type: "public-key"; id: string }[];
+  userVerification: UserVerificationRequirement;
+  timeout: number;
+}
+
+// getPasskeyAssertion asks the browser to sign the challenge with a passkey and returns the
+// credential in the form the auth service expects
+export const getPasskeyAssertion = async (options: RequestOptionsJSON) => {
+  const credential = (await navigator.credentials.get({
+    publicKey: {
+      ...options,
+      challenge: toBuffer(options.challenge),
+      allowCredentials: options.allowCredentials.map((descriptor) => ({
+        ...descriptor,
+        id: toBuffer(descriptor.id),
+      })),
+    },
+  })) as PublicKeyCredential | null;
+  if (!credential) {
+    throw new Error("No passkey was selected");
+  }
+
+  const response = credential.response as AuthenticatorAssertionResponse;
+  return {
+    id: credential.id,
+    type: credential.type,
+    response: {
+      clientDataJSON: fromBuffer(response.clientDataJSON),
+      authenticatorData: fromBuffer(response.authenticatorData),
+      signature: fromBuffer(response.signature),
+      userHandle: fromBuffer(response.userHandle),
+    },
+  };
+};
@@ -10,7 +10,7 @@ interface User {
 
 interface AuthContextType {
   token?: string;
-  setToken: (token: string | undefined) => void;
+  setToken: (token: string | undefined, refreshToken?: string) => void;
   isAuthenticated: boolean;
   user?: User;
   error?: Error;
@@ -34,7 +34,10 @@ func main() {
 	// }
 
 	// Create and configure application
-	application := app.NewApplication(db)
+	application, err := app.NewApplication(db)
+	if err != nil {
+		log.Fatalf("Failed to create application: %v", err)
+	}
 
 	// Start server
 	port := os.Getenv("PORT")
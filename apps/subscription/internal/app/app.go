@@ -1,8 +1,12 @@
 package app
 
 import (
+	"os"
+
 	"github.com/gin-gonic/gin"
 	"github.com/subscription-tracker/subscription/internal/core/application"
+	"github.com/subscription-tracker/subscription/internal/infrastructure/blob"
+	"github.com/subscription-tracker/subscription/internal/infrastructure/jwks"
 	"github.com/subscription-tracker/subscription/internal/infrastructure/postgres"
 	"github.com/subscription-tracker/subscription/internal/interface/http/handlers"
 	"github.com/subscription-tracker/subscription/internal/middleware"
@@ -16,7 +20,7 @@ type Application struct {
 }
 
 // NewApplication creates and configures a new application instance
-func NewApplication(db *gorm.DB) *Application {
+func NewApplication(db *gorm.DB) (*Application, error) {
 	app := &Application{
 		Router: gin.Default(),
 		DB:     db,
@@ -35,24 +39,130 @@ func NewApplication(db *gorm.DB) *Application {
 	})
 
 	subscriptionRepo := postgres.NewSubscriptionRepository(db)
-	subscriptionService := application.NewSubscriptionService(subscriptionRepo)
+	paymentMethodRepo := postgres.NewPaymentMethodRepository(db)
+	usageEventRepo := postgres.NewUsageEventRepository(db)
+	subscriptionService := application.NewSubscriptionService(subscriptionRepo, paymentMethodRepo, usageEventRepo)
 	subscriptionHandler := handlers.NewSubscriptionHandler(subscriptionService)
 
+	calendarService := application.NewCalendarService(subscriptionRepo)
+	calendarHandler := handlers.NewCalendarHandler(calendarService)
+
+	paymentMethodService := application.NewPaymentMethodService(paymentMethodRepo, subscriptionRepo)
+	paymentMethodHandler := handlers.NewPaymentMethodHandler(paymentMethodService)
+
+	attachmentsDir := os.Getenv("ATTACHMENTS_DIR")
+	if attachmentsDir == "" {
+		attachmentsDir = "data/attachments"
+	}
+	blobStore, err := blob.NewLocalStore(attachmentsDir)
+	if err != nil {
+		return nil, err
+	}
+	attachmentRepo := postgres.NewAttachmentRepository(db)
+	attachmentService := application.NewAttachmentService(attachmentRepo, subscriptionRepo, blobStore)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService)
+
+	accountService := application.NewAccountService(postgres.NewAccountRepository(db), blobStore)
+	userEventHandler := handlers.NewUserEventHandler(accountService)
+
+	recommendationDismissalRepo := postgres.NewRecommendationDismissalRepository(db)
+	exportService := application.NewExportService(subscriptionRepo, usageEventRepo, attachmentRepo, paymentMethodRepo, recommendationDismissalRepo, blobStore)
+	exportHandler := handlers.NewExportHandler(exportService)
+
+	usageService := application.NewUsageService(usageEventRepo, subscriptionRepo)
+	usageHandler := handlers.NewUsageHandler(usageService)
+
+	forecastService := application.NewForecastService(subscriptionRepo)
+	forecastHandler := handlers.NewForecastHandler(forecastService)
+
+	reportService := application.NewReportService(subscriptionRepo, paymentMethodRepo)
+	reportHandler := handlers.NewReportHandler(reportService)
+
 	subscriptionConfigRepo := postgres.NewSubscriptionConfigRepository(db)
 	subscriptionConfigService := application.NewSubscriptionConfigService(subscriptionConfigRepo)
 	subscriptionConfigHandler := handlers.NewSubscriptionConfigHandler(subscriptionConfigService)
 
+	providerSyncService := application.NewProviderSyncService(subscriptionRepo, subscriptionConfigRepo)
+	providerSyncHandler := handlers.NewProviderSyncHandler(providerSyncService)
+
+	recommendationService := application.NewRecommendationService(subscriptionRepo, subscriptionConfigRepo, recommendationDismissalRepo)
+	recommendationHandler := handlers.NewRecommendationHandler(recommendationService)
+
+	jwksURL := os.Getenv("JWKS_URL")
+	if jwksURL == "" {
+		jwksURL = "http://localhost:8080/.well-known/jwks.json"
+	}
+	issuer := os.Getenv("JWT_ISSUER")
+	if issuer == "" {
+		issuer = "subscription-tracker-user"
+	}
+	audience := os.Getenv("JWT_AUDIENCE")
+	if audience == "" {
+		audience = "subscription-tracker"
+	}
+	internalAudience := os.Getenv("INTERNAL_AUDIENCE")
+	if internalAudience == "" {
+		internalAudience = "subscription-tracker-internal"
+	}
+	keys := jwks.NewClient(jwksURL, nil)
+	auth := middleware.AuthMiddleware(keys, issuer, audience)
+	// Internal routes are called by the user service with service tokens, which user tokens cannot pass for
+	internalAuth := middleware.AuthMiddleware(keys, issuer, internalAudience)
+
 	// Register routes
 	api := app.Router.Group("/api")
 	{
 		// Protected subscription routes
-		subscriptions := api.Group("/subscriptions", middleware.AuthMiddleware())
+		subscriptions := api.Group("/subscriptions", auth)
 		{
 			subscriptions.GET("/:uuid", subscriptionHandler.GetSubscription)
 			subscriptions.GET("", subscriptionHandler.GetSubscriptions)
 			subscriptions.POST("", subscriptionHandler.CreateSubscription)
 			subscriptions.PUT("/:uuid", subscriptionHandler.UpdateSubscription)
 			subscriptions.DELETE("/:uuid", subscriptionHandler.DeleteSubscription)
+			subscriptions.POST("/:uuid/cancel", subscriptionHandler.CancelSubscription)
+			subscriptions.POST("/:uuid/price_changes", subscriptionHandler.SchedulePriceChange)
+			subscriptions.POST("/:uuid/usage", usageHandler.RecordUsage)
+			subscriptions.GET("/:uuid/attachments", attachmentHandler.GetAttachments)
+			subscriptions.POST("/:uuid/attachments", attachmentHandler.UploadAttachment)
+			subscriptions.GET("/:uuid/attachments/:attachmentUuid", attachmentHandler.DownloadAttachment)
+			subscriptions.DELETE("/:uuid/attachments/:attachmentUuid", attachmentHandler.DeleteAttachment)
+		}
+		calendar := api.Group("/calendar", auth)
+		{
+			calendar.GET("/week", calendarHandler.GetWeek)
+			calendar.GET("/agenda", calendarHandler.GetAgenda)
+			calendar.GET("/:year/:month", calendarHandler.GetMonth)
+		}
+		paymentMethods := api.Group("/payment_methods", auth)
+		{
+			paymentMethods.GET("", paymentMethodHandler.GetPaymentMethods)
+			paymentMethods.GET("/alerts", paymentMethodHandler.GetExpiryAlerts)
+			paymentMethods.GET("/:id", paymentMethodHandler.GetPaymentMethod)
+			paymentMethods.POST("", paymentMethodHandler.CreatePaymentMethod)
+			paymentMethods.PUT("/:id", paymentMethodHandler.UpdatePaymentMethod)
+			paymentMethods.DELETE("/:id", paymentMethodHandler.DeletePaymentMethod)
+		}
+		api.GET("/forecast", auth, forecastHandler.GetForecast)
+		api.GET("/digest", auth, usageHandler.GetDigest)
+		reports := api.Group("/reports", auth)
+		{
+			reports.GET("/spend", reportHandler.GetSpendReport)
+		}
+		recommendations := api.Group("/recommendations", auth)
+		{
+			recommendations.GET("", recommendationHandler.GetRecommendations)
+			recommendations.POST("/:id/dismiss", recommendationHandler.DismissRecommendation)
+		}
+		integrations := api.Group("/integrations", auth)
+		{
+			integrations.POST("/spotify/sync", providerSyncHandler.SyncSpotify)
+		}
+		internal := api.Group("/internal", internalAuth)
+		{
+			internal.POST("/events", middleware.RequireScope(middleware.ScopeUserDeletion), userEventHandler.HandleUserEvent)
+			internal.GET("/export", middleware.RequireScope(middleware.ScopeUserExport), exportHandler.GetExport)
+			internal.GET("/export/attachments/:attachmentUuid", middleware.RequireScope(middleware.ScopeUserExport), exportHandler.DownloadAttachment)
 		}
 		subscriptionConfigs := api.Group("/subscriptions_configs")
 		{
@@ -62,5 +172,5 @@ func NewApplication(db *gorm.DB) *Application {
 		// Add more domain routes here as needed
 	}
 
-	return app
+	return app, nil
 }
@@ -0,0 +1,54 @@
+package application
+
+import (
+	"log"
+	"time"
+
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+)
+
+// UserDeletedEvent is published by the user service when an account's deletion grace period ended
+const UserDeletedEvent = "user.deleted"
+
+// UserEvent is an event about an account published by the user service
+type UserEvent struct {
+	ID         string
+	Type       string
+	UserID     string
+	OccurredAt time.Time
+}
+
+// AccountService reacts to changes of user accounts
+type AccountService struct {
+	accounts domain.AccountRepository
+	store    domain.BlobStore
+}
+
+func NewAccountService(accounts domain.AccountRepository, store domain.BlobStore) *AccountService {
+	return &AccountService{accounts: accounts, store: store}
+}
+
+// HandleUserEvent processes an event of the user service. Events of other types are
+// acknowledged and ignored. Processing is idempotent, so redelivered events are harmless.
+func (s *AccountService) HandleUserEvent(event UserEvent) error {
+	if event.Type != UserDeletedEvent {
+		log.Printf("Ignoring user event %s of type %s", event.ID, event.Type)
+		return nil
+	}
+	return s.PurgeUser(event.UserID)
+}
+
+// PurgeUser deletes all data of the user, including the attachment files
+func (s *AccountService) PurgeUser(userId string) error {
+	storageKeys, err := s.accounts.PurgeUser(userId)
+	if err != nil {
+		return err
+	}
+	// The rows are gone, so a file that fails to delete is only an orphaned blob
+	for _, key := range storageKeys {
+		if err := s.store.Delete(key); err != nil {
+			log.Printf("Failed to delete attachment blob %s: %v", key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package application
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+)
+
+var (
+	ErrSubscriptionNotFound      = errors.New("subscription not found")
+	ErrAttachmentNotFound        = errors.New("attachment not found")
+	ErrAttachmentTooLarge        = errors.New("attachment too large")
+	ErrUnsupportedAttachmentType = errors.New("unsupported attachment type")
+)
+
+// MaxAttachmentSize is the largest file accepted as a subscription attachment
+const MaxAttachmentSize = 10 << 20
+
+// allowedAttachmentTypes lists the content types accepted for attachments, as sniffed from the file contents
+var allowedAttachmentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/webp":      true,
+	"image/gif":       true,
+	"text/plain":      true,
+}
+
+type AttachmentService struct {
+	repo          domain.AttachmentRepository
+	subscriptions domain.SubscriptionRepository
+	store         domain.BlobStore
+}
+
+func NewAttachmentService(repo domain.AttachmentRepository, subscriptions domain.SubscriptionRepository, store domain.BlobStore) *AttachmentService {
+	return &AttachmentService{repo: repo, subscriptions: subscriptions, store: store}
+}
+
+func (s *AttachmentService) GetAttachments(subscriptionUuid string, userId string) ([]domain.Attachment, error) {
+	if err := checkSubscriptionOwner(s.subscriptions, subscriptionUuid, userId); err != nil {
+		return nil, err
+	}
+	return s.repo.FindBySubscriptionUuid(subscriptionUuid)
+}
+
+// UploadAttachment stores the file and records its metadata. The content type is
+// detected from the file contents rather than trusted from the client.
+func (s *AttachmentService) UploadAttachment(subscriptionUuid string, userId string, fileName string, content io.Reader) (*domain.Attachment, error) {
+	if err := checkSubscriptionOwner(s.subscriptions, subscriptionUuid, userId); err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(content, MaxAttachmentSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxAttachmentSize {
+		return nil, ErrAttachmentTooLarge
+	}
+	contentType := strings.SplitN(http.DetectContentType(data), ";", 2)[0]
+	if !allowedAttachmentTypes[contentType] {
+		return nil, ErrUnsupportedAttachmentType
+	}
+
+	attachment := &domain.Attachment{
+		Uuid:             uuid.NewString(),
+		SubscriptionUuid: subscriptionUuid,
+		UserID:           userId,
+		FileName:         filepath.Base(fileName),
+		ContentType:      contentType,
+		Size:             int64(len(data)),
+	}
+	attachment.StorageKey = userId + "/" + subscriptionUuid + "/" + attachment.Uuid
+	if err := s.store.Put(attachment.StorageKey, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(attachment); err != nil {
+		s.store.Delete(attachment.StorageKey)
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// DownloadAttachment returns the attachment metadata and a reader over its contents; the caller must close it
+func (s *AttachmentService) DownloadAttachment(subscriptionUuid string, attachmentUuid string, userId string) (*domain.Attachment, io.ReadCloser, error) {
+	attachment, err := s.findAttachment(subscriptionUuid, attachmentUuid, userId)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := s.store.Get(attachment.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return attachment, content, nil
+}
+
+func (s *AttachmentService) DeleteAttachment(subscriptionUuid string, attachmentUuid string, userId string) error {
+	attachment, err := s.findAttachment(subscriptionUuid, attachmentUuid, userId)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(attachment.Uuid); err != nil {
+		return err
+	}
+	return s.store.Delete(attachment.StorageKey)
+}
+
+func (s *AttachmentService) findAttachment(subscriptionUuid string, attachmentUuid string, userId string) (*domain.Attachment, error) {
+	attachment, err := s.repo.FindByUuid(attachmentUuid)
+	if err != nil || attachment.UserID != userId || attachment.SubscriptionUuid != subscriptionUuid {
+		return nil, ErrAttachmentNotFound
+	}
+	return attachment, nil
+}
@@ -0,0 +1,164 @@
+package application
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+)
+
+var ErrInvalidCalendarRange = errors.New("invalid calendar range")
+
+// maxAgendaDays bounds the agenda view so a single request cannot expand years of renewals
+const maxAgendaDays = 366
+
+type CalendarService struct {
+	repo domain.SubscriptionRepository
+}
+
+func NewCalendarService(repo domain.SubscriptionRepository) *CalendarService {
+	return &CalendarService{repo: repo}
+}
+
+// GetMonth returns every day of the given month with its subscription events
+func (s *CalendarService) GetMonth(userId string, year int, month int, prefs domain.Preferences) (*domain.Calendar, error) {
+	if month < 1 || month > 12 {
+		return nil, ErrInvalidCalendarRange
+	}
+	from := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	return s.build(userId, from, from.AddDate(0, 1, 0), false, prefs)
+}
+
+// GetWeek returns the seven days of the week containing date, starting on the user's first day of the week
+func (s *CalendarService) GetWeek(userId string, date time.Time, prefs domain.Preferences) (*domain.Calendar, error) {
+	from := truncateDay(date)
+	from = from.AddDate(0, 0, -((int(from.Weekday()) - int(prefs.WeekStart) + 7) % 7))
+	return s.build(userId, from, from.AddDate(0, 0, 7), false, prefs)
+}
+
+// GetAgenda returns only the days with subscription events in the next `days` days starting at from
+func (s *CalendarService) GetAgenda(userId string, from time.Time, days int, prefs domain.Preferences) (*domain.Calendar, error) {
+	if days < 1 || days > maxAgendaDays {
+		return nil, ErrInvalidCalendarRange
+	}
+	from = truncateDay(from)
+	return s.build(userId, from, from.AddDate(0, 0, days), true, prefs)
+}
+
+func (s *CalendarService) build(userId string, from, to time.Time, skipEmpty bool, prefs domain.Preferences) (*domain.Calendar, error) {
+	subs, err := s.repo.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	subs = latestVersions(subs)
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].Name < subs[j].Name
+	})
+
+	entries := make(map[string][]domain.CalendarEntry)
+	for i := range subs {
+		sub := &subs[i]
+		for _, renewal := range sub.Renewals(from, to) {
+			key := renewal.Date.Format(domain.CalendarDateFormat)
+			entry := calendarEntry(sub, true)
+			entry.Price = renewal.Gross
+			entry.Net = renewal.Net
+			entry.Tax = renewal.Tax
+			entries[key] = append(entries[key], entry)
+		}
+		if sub.TrialEndDate != nil && inRange(*sub.TrialEndDate, from, to) {
+			key := sub.TrialEndDate.Format(domain.CalendarDateFormat)
+			entries[key] = mergeEntry(entries[key], calendarEntry(sub, false), func(e *domain.CalendarEntry) {
+				e.TrialEnding = true
+			})
+		}
+		if sub.CancelAt != nil && inRange(*sub.CancelAt, from, to) {
+			key := sub.CancelAt.Format(domain.CalendarDateFormat)
+			entries[key] = mergeEntry(entries[key], calendarEntry(sub, false), func(e *domain.CalendarEntry) {
+				e.CancellationDue = true
+			})
+		}
+		for _, date := range cancelByDates(sub, from, to) {
+			key := date.Format(domain.CalendarDateFormat)
+			entries[key] = mergeEntry(entries[key], calendarEntry(sub, false), func(e *domain.CalendarEntry) {
+				e.CancelBy = true
+			})
+		}
+	}
+
+	calendar := &domain.Calendar{From: from, To: to, Days: make([]domain.CalendarDay, 0), Currency: prefs.Currency}
+	for date := from; date.Before(to); date = date.AddDate(0, 0, 1) {
+		key := date.Format(domain.CalendarDateFormat)
+		day := domain.CalendarDay{Date: key, Entries: entries[key]}
+		if day.Entries == nil {
+			day.Entries = make([]domain.CalendarEntry, 0)
+		}
+		for _, entry := range day.Entries {
+			if entry.Renewal {
+				day.Total += entry.Price
+			}
+			day.TrialEnding = day.TrialEnding || entry.TrialEnding
+			day.CancellationDue = day.CancellationDue || entry.CancellationDue
+			day.CancelBy = day.CancelBy || entry.CancelBy
+		}
+		calendar.Total += day.Total
+		day.RunningTotal = calendar.Total
+		if skipEmpty && len(day.Entries) == 0 {
+			continue
+		}
+		calendar.Days = append(calendar.Days, day)
+	}
+	return calendar, nil
+}
+
+// cancelByDates returns the notice deadlines within [from, to) of the renewals that can
+// still be cancelled. Subscriptions without a notice period or minimum term, or that are
+// already being cancelled, have no deadlines worth surfacing.
+func cancelByDates(sub *domain.Subscription, from, to time.Time) []time.Time {
+	if sub.CancelAt != nil || (sub.NoticePeriodDays <= 0 && sub.MinimumTermMonths <= 0) {
+		return nil
+	}
+	termEnd := sub.MinimumTermEnd()
+	var dates []time.Time
+	for _, renewal := range sub.Occurrences(from, to.AddDate(0, 0, sub.NoticePeriodDays)) {
+		if termEnd != nil && renewal.Before(*termEnd) {
+			continue
+		}
+		if cancelBy := sub.CancelBy(renewal); inRange(cancelBy, from, to) {
+			dates = append(dates, cancelBy)
+		}
+	}
+	return dates
+}
+
+func calendarEntry(sub *domain.Subscription, renewal bool) domain.CalendarEntry {
+	return domain.CalendarEntry{
+		Uuid:         sub.Uuid,
+		Name:         sub.Name,
+		Logo:         sub.Logo,
+		Price:        sub.Price,
+		BillingCycle: sub.BillingCycle,
+		Renewal:      renewal,
+	}
+}
+
+// mergeEntry applies flag to the entry of the same subscription on that day, adding entry if there is none
+func mergeEntry(entries []domain.CalendarEntry, entry domain.CalendarEntry, flag func(e *domain.CalendarEntry)) []domain.CalendarEntry {
+	for i := range entries {
+		if entries[i].Uuid == entry.Uuid {
+			flag(&entries[i])
+			return entries
+		}
+	}
+	flag(&entry)
+	return append(entries, entry)
+}
+
+func inRange(date, from, to time.Time) bool {
+	return !date.Before(from) && date.Before(to)
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,170 @@
+package application
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// calendarDates returns the dates of the calendar days in order
+func calendarDates(calendar *domain.Calendar) []string {
+	dates := make([]string, 0, len(calendar.Days))
+	for _, day := range calendar.Days {
+		dates = append(dates, day.Date)
+	}
+	return dates
+}
+
+func findDay(t *testing.T, calendar *domain.Calendar, date string) domain.CalendarDay {
+	t.Helper()
+	for _, day := range calendar.Days {
+		if day.Date == date {
+			return day
+		}
+	}
+	t.Fatalf("calendar has no day %s", date)
+	return domain.CalendarDay{}
+}
+
+func TestCalendarGetMonth(t *testing.T) {
+	repo := &fakeSubscriptionRepository{subs: []domain.Subscription{
+		{ID: 1, Uuid: "netflix", UserID: "user-1", Name: "Netflix", Price: 15.49, BillingCycle: 1, StartDate: date(2024, time.January, 31), IsActive: true},
+		// An older version is superseded by the one below
+		{ID: 2, Uuid: "spotify", UserID: "user-1", Name: "Spotify", Price: 9.99, BillingCycle: 1, StartDate: date(2024, time.January, 10), IsActive: true},
+		{ID: 3, Uuid: "spotify", UserID: "user-1", Name: "Spotify", Price: 10.99, BillingCycle: 1, StartDate: date(2024, time.February, 10), IsActive: true},
+		{ID: 4, Uuid: "gym", UserID: "user-1", Name: "Gym", Price: 30, BillingCycle: 1, StartDate: date(2024, time.January, 5), IsActive: true,
+			TrialEndDate: timePtr(date(2024, time.February, 5).Add(time.Hour)), CancelAt: timePtr(date(2024, time.February, 20))},
+		{ID: 5, Uuid: "other", UserID: "user-2", Name: "Other", Price: 99, BillingCycle: 1, StartDate: date(2024, time.January, 1), IsActive: true},
+	}}
+	service := NewCalendarService(repo)
+
+	calendar, err := service.GetMonth("user-1", 2024, 2, domain.Preferences{Currency: "EUR"})
+	if err != nil {
+		t.Fatalf("GetMonth: %v", err)
+	}
+	if len(calendar.Days) != 29 {
+		t.Fatalf("leap February has %d days, want 29", len(calendar.Days))
+	}
+	if calendar.Days[0].Date != "2024-02-01" || calendar.Days[28].Date != "2024-02-29" {
+		t.Errorf("month runs from %s to %s", calendar.Days[0].Date, calendar.Days[28].Date)
+	}
+	if calendar.Currency != "EUR" {
+		t.Errorf("currency = %q, want EUR", calendar.Currency)
+	}
+
+	// The renewal on the 31st is clamped to the end of February
+	netflix := findDay(t, calendar, "2024-02-29")
+	if len(netflix.Entries) != 1 || netflix.Entries[0].Uuid != "netflix" || netflix.Total != 15.49 {
+		t.Errorf("29th = %+v, want the Netflix renewal", netflix)
+	}
+	spotify := findDay(t, calendar, "2024-02-10")
+	if len(spotify.Entries) != 1 || spotify.Entries[0].Price != 10.99 {
+		t.Errorf("10th = %+v, want only the latest Spotify version", spotify)
+	}
+	// The renewal on the trial end day is still inside the trial, so the day only flags it
+	trialEnd := findDay(t, calendar, "2024-02-05")
+	if !trialEnd.TrialEnding || trialEnd.Total != 0 || len(trialEnd.Entries) != 1 || trialEnd.Entries[0].Renewal {
+		t.Errorf("5th = %+v, want a trial ending without a charge", trialEnd)
+	}
+	cancellation := findDay(t, calendar, "2024-02-20")
+	if !cancellation.CancellationDue || len(cancellation.Entries) != 1 {
+		t.Errorf("20th = %+v, want the cancellation", cancellation)
+	}
+	if calendar.Total != 26.48 {
+		t.Errorf("total = %v, want 26.48", calendar.Total)
+	}
+	if last := calendar.Days[28]; last.RunningTotal != calendar.Total {
+		t.Errorf("running total on the last day = %v, want %v", last.RunningTotal, calendar.Total)
+	}
+	if day := findDay(t, calendar, "2024-02-15"); day.Entries == nil || day.RunningTotal != 10.99 {
+		t.Errorf("15th = %+v, want no entries and a running total of 10.99", day)
+	}
+
+	for _, month := range []int{0, 13} {
+		if _, err := service.GetMonth("user-1", 2024, month, domain.Preferences{}); !errors.Is(err, ErrInvalidCalendarRange) {
+			t.Errorf("month %d: error = %v, want ErrInvalidCalendarRange", month, err)
+		}
+	}
+}
+
+func TestCalendarGetWeek(t *testing.T) {
+	tests := []struct {
+		name      string
+		date      time.Time
+		weekStart time.Weekday
+		wantFrom  string
+	}{
+		// 2024-03-06 is a Wednesday
+		{name: "monday start", date: date(2024, time.March, 6), weekStart: time.Monday, wantFrom: "2024-03-04"},
+		{name: "sunday start", date: date(2024, time.March, 6), weekStart: time.Sunday, wantFrom: "2024-03-03"},
+		{name: "saturday start", date: date(2024, time.March, 6), weekStart: time.Saturday, wantFrom: "2024-03-02"},
+		{name: "date on the week start", date: date(2024, time.March, 4), weekStart: time.Monday, wantFrom: "2024-03-04"},
+		{name: "date just before the week start", date: date(2024, time.March, 3), weekStart: time.Monday, wantFrom: "2024-02-26"},
+		{name: "time of day is ignored", date: date(2024, time.March, 10).Add(23 * time.Hour), weekStart: time.Monday, wantFrom: "2024-03-04"},
+		{name: "across the year end", date: date(2025, time.January, 1), weekStart: time.Monday, wantFrom: "2024-12-30"},
+	}
+
+	service := NewCalendarService(&fakeSubscriptionRepository{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calendar, err := service.GetWeek("user-1", tt.date, domain.Preferences{WeekStart: tt.weekStart})
+			if err != nil {
+				t.Fatalf("GetWeek: %v", err)
+			}
+			dates := calendarDates(calendar)
+			if len(dates) != 7 || dates[0] != tt.wantFrom {
+				t.Fatalf("week = %v, want seven days from %s", dates, tt.wantFrom)
+			}
+			if weekday := calendar.From.Weekday(); weekday != tt.weekStart {
+				t.Errorf("week starts on %v, want %v", weekday, tt.weekStart)
+			}
+			if !calendar.To.Equal(calendar.From.AddDate(0, 0, 7)) {
+				t.Errorf("week ends %v, want seven days after %v", calendar.To, calendar.From)
+			}
+		})
+	}
+}
+
+func TestCalendarGetAgenda(t *testing.T) {
+	repo := &fakeSubscriptionRepository{subs: []domain.Subscription{
+		{ID: 1, Uuid: "netflix", UserID: "user-1", Name: "Netflix", Price: 15.49, BillingCycle: 1, StartDate: date(2024, time.January, 10), IsActive: true},
+		// Cancelling before 2024-03-20 requires 14 days notice, so the cancel-by date is 03-06
+		{ID: 2, Uuid: "phone", UserID: "user-1", Name: "Phone", Price: 40, BillingCycle: 1, StartDate: date(2023, time.March, 20), IsActive: true,
+			NoticePeriodDays: 14, MinimumTermMonths: 12, TaxRate: 20, PriceIncludesTax: true},
+	}}
+	service := NewCalendarService(repo)
+
+	calendar, err := service.GetAgenda("user-1", date(2024, time.March, 1).Add(15*time.Hour), 30, domain.Preferences{})
+	if err != nil {
+		t.Fatalf("GetAgenda: %v", err)
+	}
+	if got, want := strings.Join(calendarDates(calendar), " "), "2024-03-06 2024-03-10 2024-03-20"; got != want {
+		t.Fatalf("agenda = %s, want %s", got, want)
+	}
+	if !calendar.From.Equal(date(2024, time.March, 1)) || !calendar.To.Equal(date(2024, time.March, 31)) {
+		t.Errorf("agenda covers %v to %v", calendar.From, calendar.To)
+	}
+
+	if day := findDay(t, calendar, "2024-03-06"); !day.CancelBy || day.Total != 0 {
+		t.Errorf("6th = %+v, want the cancel-by deadline", day)
+	}
+	if day := findDay(t, calendar, "2024-03-20"); len(day.Entries) != 1 || day.Entries[0].Net != 33.33 || day.Entries[0].Tax != 6.67 {
+		t.Errorf("20th = %+v, want the phone renewal split into net and tax", day)
+	}
+	if calendar.Total != 55.49 {
+		t.Errorf("total = %v, want 55.49", calendar.Total)
+	}
+
+	for _, days := range []int{0, maxAgendaDays + 1} {
+		if _, err := service.GetAgenda("user-1", date(2024, time.March, 1), days, domain.Preferences{}); !errors.Is(err, ErrInvalidCalendarRange) {
+			t.Errorf("%d days: error = %v, want ErrInvalidCalendarRange", days, err)
+		}
+	}
+}
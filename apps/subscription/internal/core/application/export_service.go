@@ -0,0 +1,121 @@
+package application
+
+import (
+	"io"
+	"sort"
+	"time"
+
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+)
+
+// ExportService collects a user's data for the user service's personal data export
+type ExportService struct {
+	subscriptions  domain.SubscriptionRepository
+	usageEvents    domain.UsageEventRepository
+	attachments    domain.AttachmentRepository
+	paymentMethods domain.PaymentMethodRepository
+	dismissals     domain.RecommendationDismissalRepository
+	store          domain.BlobStore
+}
+
+func NewExportService(subscriptions domain.SubscriptionRepository, usageEvents domain.UsageEventRepository, attachments domain.AttachmentRepository, paymentMethods domain.PaymentMethodRepository, dismissals domain.RecommendationDismissalRepository, store domain.BlobStore) *ExportService {
+	return &ExportService{
+		subscriptions:  subscriptions,
+		usageEvents:    usageEvents,
+		attachments:    attachments,
+		paymentMethods: paymentMethods,
+		dismissals:     dismissals,
+		store:          store,
+	}
+}
+
+// Export returns every subscription of the user with all its versions, payments, usage
+// and attachment metadata, and the user's payment methods and dismissed recommendations
+func (s *ExportService) Export(userId string) (*domain.UserDataExport, error) {
+	subs, err := s.subscriptions.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	usageEvents, err := s.usageEvents.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := s.attachments.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	paymentMethods, err := s.paymentMethods.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	dismissals, err := s.dismissals.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	histories := make(map[string]*domain.SubscriptionHistory)
+	order := make([]string, 0)
+	for _, sub := range subs {
+		history, ok := histories[sub.Uuid]
+		if !ok {
+			history = &domain.SubscriptionHistory{
+				Uuid:        sub.Uuid,
+				Payments:    make([]domain.Payment, 0),
+				UsageEvents: make([]domain.UsageEvent, 0),
+				Attachments: make([]domain.Attachment, 0),
+			}
+			histories[sub.Uuid] = history
+			order = append(order, sub.Uuid)
+		}
+		history.Versions = append(history.Versions, sub)
+	}
+	for _, latest := range latestVersions(subs) {
+		for _, renewal := range latest.Renewals(latest.StartDate, now) {
+			histories[latest.Uuid].Payments = append(histories[latest.Uuid].Payments, domain.Payment{
+				Date:         renewal.Date,
+				Price:        renewal.Price,
+				TaxBreakdown: renewal.TaxBreakdown,
+			})
+		}
+	}
+	for _, event := range usageEvents {
+		if history, ok := histories[event.SubscriptionUuid]; ok {
+			history.UsageEvents = append(history.UsageEvents, event)
+		}
+	}
+	for _, attachment := range attachments {
+		if history, ok := histories[attachment.SubscriptionUuid]; ok {
+			history.Attachments = append(history.Attachments, attachment)
+		}
+	}
+
+	export := &domain.UserDataExport{
+		UserID:                   userId,
+		GeneratedAt:              now,
+		Subscriptions:            make([]domain.SubscriptionHistory, 0, len(order)),
+		PaymentMethods:           paymentMethods,
+		RecommendationDismissals: dismissals,
+	}
+	for _, uuid := range order {
+		history := histories[uuid]
+		sort.SliceStable(history.Versions, func(i, j int) bool {
+			return history.Versions[i].ID < history.Versions[j].ID
+		})
+		export.Subscriptions = append(export.Subscriptions, *history)
+	}
+	return export, nil
+}
+
+// OpenAttachment returns an attachment of the user and a reader over its contents; the caller must close it
+func (s *ExportService) OpenAttachment(userId string, attachmentUuid string) (*domain.Attachment, io.ReadCloser, error) {
+	attachment, err := s.attachments.FindByUuid(attachmentUuid)
+	if err != nil || attachment.UserID != userId {
+		return nil, nil, ErrAttachmentNotFound
+	}
+	content, err := s.store.Get(attachment.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return attachment, content, nil
+}
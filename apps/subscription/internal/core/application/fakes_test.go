@@ -0,0 +1,67 @@
+package application
+
+import (
+	"errors"
+	"time"
+
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+)
+
+// fakeSubscriptionRepository keeps subscription versions in memory; methods the tests do not need panic
+type fakeSubscriptionRepository struct {
+	domain.SubscriptionRepository
+	subs []domain.Subscription
+}
+
+func (r *fakeSubscriptionRepository) FindByUserId(userId string) ([]domain.Subscription, error) {
+	var subs []domain.Subscription
+	for _, sub := range r.subs {
+		if sub.UserID == userId {
+			subs = append(subs, sub)
+		}
+	}
+	return subs, nil
+}
+
+func (r *fakeSubscriptionRepository) Find(query *domain.SubscriptionRepoQuery, order *string) ([]domain.Subscription, error) {
+	var subs []domain.Subscription
+	for _, sub := range r.subs {
+		if (query.UserID == nil || sub.UserID == *query.UserID) && (query.Uuid == nil || sub.Uuid == *query.Uuid) {
+			subs = append(subs, sub)
+		}
+	}
+	return subs, nil
+}
+
+func (r *fakeSubscriptionRepository) Create(sub *domain.Subscription) error {
+	sub.ID = uint(len(r.subs) + 1)
+	r.subs = append(r.subs, *sub)
+	return nil
+}
+
+// fakeUsageEventRepository has no usage events
+type fakeUsageEventRepository struct {
+	domain.UsageEventRepository
+}
+
+func (r *fakeUsageEventRepository) Summaries(userId string, since time.Time) (map[string]domain.UsageSummary, error) {
+	return map[string]domain.UsageSummary{}, nil
+}
+
+type fakeSubscriptionConfigRepository struct {
+	domain.SubscriptionConfigRepository
+	configs []domain.SubscriptionConfig
+}
+
+func (r *fakeSubscriptionConfigRepository) FindByProvider(provider string) (*domain.SubscriptionConfig, error) {
+	for i := range r.configs {
+		if r.configs[i].Provider == provider {
+			return &r.configs[i], nil
+		}
+	}
+	return nil, errors.New("config not found")
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,126 @@
+package application
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+)
+
+var ErrInvalidForecast = errors.New("invalid forecast parameters")
+
+// maxForecastMonths bounds the forecast horizon
+const maxForecastMonths = 36
+
+type ForecastService struct {
+	subscriptions domain.SubscriptionRepository
+}
+
+func NewForecastService(subscriptions domain.SubscriptionRepository) *ForecastService {
+	return &ForecastService{subscriptions: subscriptions}
+}
+
+// ForecastOptions configures a forecast. When Balance or Paydays are set, the forecast
+// tracks a running balance starting at Balance and reports its low point before each payday.
+type ForecastOptions struct {
+	From     time.Time
+	Months   int
+	Balance  *float64
+	Paydays  []domain.Payday
+	Currency string
+}
+
+// GetForecast projects every renewal over the horizon, honouring trials, cancellations and
+// price schedules, and returns the outflows per day and per month
+func (s *ForecastService) GetForecast(userId string, options ForecastOptions) (*domain.Forecast, error) {
+	if options.Months < 1 || options.Months > maxForecastMonths {
+		return nil, ErrInvalidForecast
+	}
+	if options.Balance != nil && (math.IsNaN(*options.Balance) || math.IsInf(*options.Balance, 0)) {
+		return nil, ErrInvalidForecast
+	}
+	for _, payday := range options.Paydays {
+		if payday.DayOfMonth < 1 || payday.DayOfMonth > 31 || payday.Amount < 0 {
+			return nil, ErrInvalidForecast
+		}
+	}
+	subs, err := s.subscriptions.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	from := truncateDay(options.From)
+	to := domain.AddMonths(from, options.Months)
+	outflows := make(map[string]float64)
+	renewals := make(map[string][]string)
+	for _, sub := range latestVersions(subs) {
+		for _, renewal := range sub.Renewals(from, to) {
+			key := renewal.Date.Format(domain.CalendarDateFormat)
+			outflows[key] = domain.RoundCents(outflows[key] + renewal.Gross)
+			renewals[key] = append(renewals[key], sub.Uuid)
+		}
+	}
+	inflows := make(map[string]float64)
+	for month := from; month.Before(to); month = domain.AddMonths(month, 1) {
+		for _, payday := range options.Paydays {
+			if date := payday.DateIn(month); inRange(date, from, to) {
+				key := date.Format(domain.CalendarDateFormat)
+				inflows[key] = domain.RoundCents(inflows[key] + payday.Amount)
+			}
+		}
+	}
+
+	forecast := &domain.Forecast{
+		From:     from,
+		To:       to,
+		Days:     make([]domain.ForecastDay, 0),
+		Months:   make([]domain.ForecastMonth, 0),
+		Currency: options.Currency,
+	}
+	overlay := options.Balance != nil || len(options.Paydays) > 0
+	balance := 0.0
+	if options.Balance != nil {
+		balance = *options.Balance
+	}
+	var low *domain.ForecastLowPoint
+	for date := from; date.Before(to); date = date.AddDate(0, 0, 1) {
+		if n := len(forecast.Months); n == 0 || forecast.Months[n-1].Month != int(date.Month()) {
+			forecast.Months = append(forecast.Months, domain.ForecastMonth{Year: date.Year(), Month: int(date.Month())})
+		}
+		month := &forecast.Months[len(forecast.Months)-1]
+
+		key := date.Format(domain.CalendarDateFormat)
+		outflow, inflow := outflows[key], inflows[key]
+		month.Outflow = domain.RoundCents(month.Outflow + outflow)
+		month.Inflow = domain.RoundCents(month.Inflow + inflow)
+		month.Renewals += len(renewals[key])
+		forecast.Outflow = domain.RoundCents(forecast.Outflow + outflow)
+
+		// Charges are assumed to leave the account before the same day's payday arrives
+		balance = domain.RoundCents(balance - outflow)
+		if low == nil || balance < low.LowestBalance {
+			low = &domain.ForecastLowPoint{LowestBalance: balance, Date: key}
+		}
+		if inflow > 0 {
+			low.Payday = key
+			forecast.LowPoints = append(forecast.LowPoints, *low)
+			low = nil
+		}
+		balance = domain.RoundCents(balance + inflow)
+
+		if outflow == 0 && inflow == 0 {
+			continue
+		}
+		day := domain.ForecastDay{Date: key, Outflow: outflow, Inflow: inflow, Renewals: renewals[key]}
+		if day.Renewals == nil {
+			day.Renewals = make([]string, 0)
+		}
+		if overlay {
+			dayBalance := balance
+			day.Balance = &dayBalance
+		}
+		forecast.Days = append(forecast.Days, day)
+	}
+	return forecast, nil
+}
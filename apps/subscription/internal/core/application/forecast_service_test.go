@@ -0,0 +1,108 @@
+package application
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+)
+
+func TestForecastBalanceAndLowPoints(t *testing.T) {
+	repo := &fakeSubscriptionRepository{subs: []domain.Subscription{
+		{ID: 1, Uuid: "rent", UserID: "user-1", Name: "Rent", Price: 800, BillingCycle: 1, StartDate: date(2024, time.January, 3), IsActive: true},
+		{ID: 2, Uuid: "gym", UserID: "user-1", Name: "Gym", Price: 50, BillingCycle: 1, StartDate: date(2024, time.January, 20), IsActive: true},
+		// Charged on payday, before the payday arrives
+		{ID: 3, Uuid: "phone", UserID: "user-1", Name: "Phone", Price: 30, BillingCycle: 1, StartDate: date(2024, time.January, 25), IsActive: true},
+	}}
+	service := NewForecastService(repo)
+	balance := 1000.0
+
+	forecast, err := service.GetForecast("user-1", ForecastOptions{
+		From:    date(2024, time.March, 1).Add(9 * time.Hour),
+		Months:  2,
+		Balance: &balance,
+		// The 31st falls on April 30th
+		Paydays: []domain.Payday{{DayOfMonth: 25, Amount: 1000}, {DayOfMonth: 31, Amount: 200}},
+	})
+	if err != nil {
+		t.Fatalf("GetForecast: %v", err)
+	}
+
+	wantBalances := map[string]float64{
+		"2024-03-03": 200, "2024-03-20": 150, "2024-03-25": 1120, "2024-03-31": 1320,
+		"2024-04-03": 520, "2024-04-20": 470, "2024-04-25": 1440, "2024-04-30": 1640,
+	}
+	if len(forecast.Days) != len(wantBalances) {
+		t.Fatalf("forecast has %d days, want %d: %+v", len(forecast.Days), len(wantBalances), forecast.Days)
+	}
+	for _, day := range forecast.Days {
+		want, ok := wantBalances[day.Date]
+		if !ok || day.Balance == nil || *day.Balance != want {
+			t.Errorf("%s: balance %v, want %v", day.Date, day.Balance, want)
+		}
+	}
+
+	wantLowPoints := []domain.ForecastLowPoint{
+		{Payday: "2024-03-25", LowestBalance: 120, Date: "2024-03-25"},
+		{Payday: "2024-03-31", LowestBalance: 1120, Date: "2024-03-26"},
+		{Payday: "2024-04-25", LowestBalance: 440, Date: "2024-04-25"},
+		{Payday: "2024-04-30", LowestBalance: 1440, Date: "2024-04-26"},
+	}
+	if !reflect.DeepEqual(forecast.LowPoints, wantLowPoints) {
+		t.Errorf("low points = %+v, want %+v", forecast.LowPoints, wantLowPoints)
+	}
+
+	wantMonths := []domain.ForecastMonth{
+		{Year: 2024, Month: 3, Outflow: 880, Inflow: 1200, Renewals: 3},
+		{Year: 2024, Month: 4, Outflow: 880, Inflow: 1200, Renewals: 3},
+	}
+	if !reflect.DeepEqual(forecast.Months, wantMonths) {
+		t.Errorf("months = %+v, want %+v", forecast.Months, wantMonths)
+	}
+	if forecast.Outflow != 1760 {
+		t.Errorf("outflow = %v, want 1760", forecast.Outflow)
+	}
+}
+
+func TestForecastWithoutBalance(t *testing.T) {
+	repo := &fakeSubscriptionRepository{subs: []domain.Subscription{
+		{ID: 1, Uuid: "gym", UserID: "user-1", Name: "Gym", Price: 50, BillingCycle: 1, StartDate: date(2024, time.January, 20), IsActive: true},
+	}}
+	forecast, err := NewForecastService(repo).GetForecast("user-1", ForecastOptions{From: date(2024, time.March, 1), Months: 1})
+	if err != nil {
+		t.Fatalf("GetForecast: %v", err)
+	}
+	if len(forecast.Days) != 1 || forecast.Days[0].Balance != nil {
+		t.Errorf("days = %+v, want one day without a balance", forecast.Days)
+	}
+	if forecast.LowPoints != nil {
+		t.Errorf("low points = %+v, want none without paydays", forecast.LowPoints)
+	}
+}
+
+func TestForecastRejectsInvalidOptions(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name    string
+		options ForecastOptions
+	}{
+		{name: "no months", options: ForecastOptions{Months: 0}},
+		{name: "too many months", options: ForecastOptions{Months: maxForecastMonths + 1}},
+		{name: "payday before the first", options: ForecastOptions{Months: 1, Paydays: []domain.Payday{{DayOfMonth: 0, Amount: 100}}}},
+		{name: "payday after the 31st", options: ForecastOptions{Months: 1, Paydays: []domain.Payday{{DayOfMonth: 32, Amount: 100}}}},
+		{name: "negative payday", options: ForecastOptions{Months: 1, Paydays: []domain.Payday{{DayOfMonth: 1, Amount: -100}}}},
+		{name: "balance not a number", options: ForecastOptions{Months: 1, Balance: &nan}},
+	}
+
+	service := NewForecastService(&fakeSubscriptionRepository{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.GetForecast("user-1", tt.options); !errors.Is(err, ErrInvalidForecast) {
+				t.Errorf("error = %v, want ErrInvalidForecast", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package application
+
+import (
+	"errors"
+	"time"
+
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+	"github.com/subscription-tracker/subscription/internal/interface/http/dto"
+)
+
+var ErrPaymentMethodNotFound = errors.New("payment method not found")
+
+type PaymentMethodService struct {
+	repo          domain.PaymentMethodRepository
+	subscriptions domain.SubscriptionRepository
+}
+
+func NewPaymentMethodService(repo domain.PaymentMethodRepository, subscriptions domain.SubscriptionRepository) *PaymentMethodService {
+	return &PaymentMethodService{repo: repo, subscriptions: subscriptions}
+}
+
+func (s *PaymentMethodService) GetPaymentMethods(userId string) ([]domain.PaymentMethod, error) {
+	return s.repo.FindByUserId(userId)
+}
+
+func (s *PaymentMethodService) GetPaymentMethod(id uint, userId string) (*domain.PaymentMethod, error) {
+	return findOwnedPaymentMethod(s.repo, id, userId)
+}
+
+func (s *PaymentMethodService) CreatePaymentMethod(paymentMethod *domain.PaymentMethod) error {
+	return s.repo.Create(paymentMethod)
+}
+
+func (s *PaymentMethodService) UpdatePaymentMethod(id uint, data dto.PaymentMethodRequest, userId string) (*domain.PaymentMethod, error) {
+	paymentMethod, err := findOwnedPaymentMethod(s.repo, id, userId)
+	if err != nil {
+		return nil, err
+	}
+	data.Apply(paymentMethod)
+	if err := s.repo.Update(paymentMethod); err != nil {
+		return nil, err
+	}
+	return paymentMethod, nil
+}
+
+func (s *PaymentMethodService) DeletePaymentMethod(id uint, userId string) error {
+	if _, err := findOwnedPaymentMethod(s.repo, id, userId); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+// GetExpiryAlerts returns the payment methods that expire within the given number of days
+// from now (or have already expired) together with every active subscription that will
+// renew on or after the expiry and therefore fail to be charged
+func (s *PaymentMethodService) GetExpiryAlerts(userId string, days int, now time.Time) ([]domain.PaymentMethodAlert, error) {
+	paymentMethods, err := s.repo.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	subs, err := s.subscriptions.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	subs = latestVersions(subs)
+
+	horizon := now.AddDate(0, 0, days)
+	alerts := make([]domain.PaymentMethodAlert, 0)
+	for _, paymentMethod := range paymentMethods {
+		if !paymentMethod.HasExpiry() || paymentMethod.ExpiresAt().After(horizon) {
+			continue
+		}
+		expiresAt := paymentMethod.ExpiresAt()
+		alert := domain.PaymentMethodAlert{
+			PaymentMethod: paymentMethod,
+			ExpiresAt:     expiresAt,
+			Subscriptions: make([]domain.Subscription, 0),
+		}
+		for _, sub := range subs {
+			if sub.PaymentMethodID == nil || *sub.PaymentMethodID != paymentMethod.ID {
+				continue
+			}
+			if len(sub.Occurrences(expiresAt, domain.AddMonths(expiresAt, sub.BillingCycle+1))) > 0 {
+				alert.Subscriptions = append(alert.Subscriptions, sub)
+			}
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, nil
+}
+
+// findOwnedPaymentMethod loads a payment method and hides it from users other than its owner
+func findOwnedPaymentMethod(repo domain.PaymentMethodRepository, id uint, userId string) (*domain.PaymentMethod, error) {
+	paymentMethod, err := repo.FindByID(id)
+	if err != nil || paymentMethod.UserID != userId {
+		return nil, ErrPaymentMethodNotFound
+	}
+	return paymentMethod, nil
+}
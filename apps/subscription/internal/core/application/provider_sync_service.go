@@ -0,0 +1,125 @@
+package application
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+)
+
+var ErrProviderPlanNotFound = errors.New("provider plan not found")
+
+// spotifyProvider is the SubscriptionConfig provider name of Spotify
+const spotifyProvider = "Spotify"
+
+// spotifyProductPlans maps the `product` of a Spotify profile to the catalog plan it is billed as.
+// Spotify does not expose which premium plan an account is on, so premium maps to Individual.
+var spotifyProductPlans = map[string]string{
+	"premium": "Individual",
+}
+
+// ProviderSyncService creates and updates subscriptions detected from linked provider accounts
+type ProviderSyncService struct {
+	subscriptions domain.SubscriptionRepository
+	configs       domain.SubscriptionConfigRepository
+}
+
+func NewProviderSyncService(subscriptions domain.SubscriptionRepository, configs domain.SubscriptionConfigRepository) *ProviderSyncService {
+	return &ProviderSyncService{subscriptions: subscriptions, configs: configs}
+}
+
+// SyncSpotify brings the user's Spotify subscription in line with the product of their
+// Spotify profile. A paid product creates the subscription, or records a new version if the
+// plan changed; a free product cancels an existing subscription at its next renewal. It
+// returns the resulting subscription, or nil if the user has no paid Spotify subscription.
+func (s *ProviderSyncService) SyncSpotify(userId string, product string) (*domain.Subscription, error) {
+	config, err := s.configs.FindByProvider(spotifyProvider)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.findProviderSubscription(userId, config)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	planName, paid := spotifyProductPlans[strings.ToLower(product)]
+	if !paid {
+		if existing == nil || existing.CancelAt != nil {
+			return existing, nil
+		}
+		version := existing.NewVersion()
+		cancelAt := truncateDay(now)
+		if renewal := existing.NextRenewal(now); renewal != nil {
+			cancelAt = *renewal
+		}
+		version.CancelAt = &cancelAt
+		return s.create(version)
+	}
+
+	var plan *domain.SubscriptionConfigPlan
+	plans := config.GetActivePlans()
+	for i := range plans {
+		if strings.EqualFold(plans[i].Name, planName) {
+			plan = &plans[i]
+		}
+	}
+	if plan == nil {
+		return nil, ErrProviderPlanNotFound
+	}
+
+	name := config.Provider + " " + plan.Name
+	if existing != nil {
+		if existing.Name == name && existing.Price == plan.Price && existing.BillingCycle == int(plan.BillingCycle) && existing.CancelAt == nil {
+			return existing, nil
+		}
+		version := existing.NewVersion()
+		version.Name = name
+		version.Price = plan.Price
+		version.BillingCycle = int(plan.BillingCycle)
+		version.TaxRate = plan.TaxRate
+		version.PriceIncludesTax = plan.PriceIncludesTax
+		version.CancelAt = nil
+		version.SetPriceSchedule(nil)
+		return s.create(version)
+	}
+
+	sub := &domain.Subscription{
+		Uuid:             uuid.NewString(),
+		UserID:           userId,
+		Name:             name,
+		Price:            plan.Price,
+		BillingCycle:     int(plan.BillingCycle),
+		StartDate:        truncateDay(now),
+		Logo:             config.Logo,
+		IsActive:         true,
+		TaxRate:          plan.TaxRate,
+		PriceIncludesTax: plan.PriceIncludesTax,
+	}
+	return s.create(sub)
+}
+
+func (s *ProviderSyncService) create(sub *domain.Subscription) (*domain.Subscription, error) {
+	if err := s.subscriptions.Create(sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// findProviderSubscription returns the latest version of the user's subscription to the
+// provider, whether it was detected or entered by hand
+func (s *ProviderSyncService) findProviderSubscription(userId string, config *domain.SubscriptionConfig) (*domain.Subscription, error) {
+	subs, err := s.subscriptions.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	configs := []domain.SubscriptionConfig{*config}
+	for _, sub := range latestVersions(subs) {
+		if domain.MatchSubscriptionConfig(configs, &sub) != nil {
+			return &sub, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,131 @@
+package application
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+)
+
+func spotifyConfig() domain.SubscriptionConfig {
+	return domain.SubscriptionConfig{
+		Provider: "Spotify",
+		Logo:     "https://example.com/spotify.png",
+		Status:   "active",
+		Plans: []domain.SubscriptionConfigPlan{
+			{Name: "Individual", Price: 10.99, Currency: "USD", BillingCycle: 1, TaxRate: 20, PriceIncludesTax: true, Status: "active"},
+			{Name: "Duo", Price: 14.99, Currency: "USD", BillingCycle: 1, Status: "active"},
+		},
+	}
+}
+
+func TestSyncSpotify(t *testing.T) {
+	today := truncateDay(time.Now())
+	tests := []struct {
+		name     string
+		existing []domain.Subscription
+		product  string
+		plans    []domain.SubscriptionConfigPlan
+		wantErr  error
+		// wantVersions is the number of versions stored after the sync
+		wantVersions int
+		want         *domain.Subscription
+	}{
+		{
+			name:         "premium creates the individual plan",
+			product:      "premium",
+			wantVersions: 1,
+			want:         &domain.Subscription{Name: "Spotify Individual", Price: 10.99, BillingCycle: 1, TaxRate: 20, PriceIncludesTax: true, StartDate: today, IsActive: true},
+		},
+		{
+			name:    "product is matched case-insensitively",
+			product: "Premium",
+			existing: []domain.Subscription{
+				{ID: 1, Uuid: "sub-1", UserID: "user-1", Name: "Spotify Individual", Price: 10.99, BillingCycle: 1, StartDate: today.AddDate(0, -3, 0), IsActive: true},
+			},
+			wantVersions: 1,
+			want:         &domain.Subscription{Uuid: "sub-1", Name: "Spotify Individual", Price: 10.99, BillingCycle: 1, StartDate: today.AddDate(0, -3, 0), IsActive: true},
+		},
+		{
+			name:    "price change records a new version",
+			product: "premium",
+			existing: []domain.Subscription{
+				{ID: 1, Uuid: "sub-1", UserID: "user-1", Name: "Spotify Premium", Price: 9.99, BillingCycle: 1, StartDate: today.AddDate(0, -3, 0), IsActive: true},
+			},
+			wantVersions: 2,
+			want:         &domain.Subscription{Uuid: "sub-1", Name: "Spotify Individual", Price: 10.99, BillingCycle: 1, TaxRate: 20, PriceIncludesTax: true, StartDate: today.AddDate(0, -3, 0), IsActive: true},
+		},
+		{
+			name:    "free cancels at the next renewal",
+			product: "free",
+			existing: []domain.Subscription{
+				{ID: 1, Uuid: "sub-1", UserID: "user-1", Name: "Spotify Individual", Price: 10.99, BillingCycle: 1, StartDate: today.AddDate(0, -1, -10), IsActive: true},
+			},
+			wantVersions: 2,
+			want:         &domain.Subscription{Uuid: "sub-1", Name: "Spotify Individual", Price: 10.99, BillingCycle: 1, StartDate: today.AddDate(0, -1, -10), IsActive: true, CancelAt: timePtr(domain.AddMonths(today.AddDate(0, -1, -10), 2))},
+		},
+		{
+			name:         "free without a subscription does nothing",
+			product:      "free",
+			wantVersions: 0,
+		},
+		{
+			name:    "other providers are left alone",
+			product: "premium",
+			existing: []domain.Subscription{
+				{ID: 1, Uuid: "sub-1", UserID: "user-1", Name: "Netflix", Price: 15.49, BillingCycle: 1, StartDate: today, IsActive: true},
+			},
+			wantVersions: 2,
+			want:         &domain.Subscription{Name: "Spotify Individual", Price: 10.99, BillingCycle: 1, TaxRate: 20, PriceIncludesTax: true, StartDate: today, IsActive: true},
+		},
+		{
+			name:    "missing catalog plan",
+			product: "premium",
+			plans: []domain.SubscriptionConfigPlan{
+				{Name: "Duo", Price: 14.99, Currency: "USD", BillingCycle: 1, Status: "active"},
+			},
+			wantErr:      ErrProviderPlanNotFound,
+			wantVersions: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := spotifyConfig()
+			if tt.plans != nil {
+				config.Plans = tt.plans
+			}
+			subscriptions := &fakeSubscriptionRepository{subs: append([]domain.Subscription(nil), tt.existing...)}
+			service := NewProviderSyncService(subscriptions, &fakeSubscriptionConfigRepository{configs: []domain.SubscriptionConfig{config}})
+
+			got, err := service.SyncSpotify("user-1", tt.product)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if len(subscriptions.subs) != tt.wantVersions {
+				t.Errorf("stored %d versions, want %d", len(subscriptions.subs), tt.wantVersions)
+			}
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("got subscription %+v, want none", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("got no subscription")
+			}
+			if tt.want.Uuid != "" && got.Uuid != tt.want.Uuid {
+				t.Errorf("uuid = %q, want %q", got.Uuid, tt.want.Uuid)
+			}
+			if got.UserID != "user-1" || got.Name != tt.want.Name || got.Price != tt.want.Price || got.BillingCycle != tt.want.BillingCycle ||
+				got.TaxRate != tt.want.TaxRate || got.PriceIncludesTax != tt.want.PriceIncludesTax || got.IsActive != tt.want.IsActive ||
+				!got.StartDate.Equal(tt.want.StartDate) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if (got.CancelAt == nil) != (tt.want.CancelAt == nil) || (got.CancelAt != nil && !got.CancelAt.Equal(*tt.want.CancelAt)) {
+				t.Errorf("cancelAt = %v, want %v", got.CancelAt, tt.want.CancelAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+)
+
+var ErrRecommendationNotFound = errors.New("recommendation not found")
+
+type RecommendationService struct {
+	subscriptions domain.SubscriptionRepository
+	configs       domain.SubscriptionConfigRepository
+	dismissals    domain.RecommendationDismissalRepository
+}
+
+func NewRecommendationService(subscriptions domain.SubscriptionRepository, configs domain.SubscriptionConfigRepository, dismissals domain.RecommendationDismissalRepository) *RecommendationService {
+	return &RecommendationService{subscriptions: subscriptions, configs: configs, dismissals: dismissals}
+}
+
+// GetRecommendations analyses the user's subscriptions against the catalog and returns
+// the recommendations the user has not dismissed, largest savings first
+func (s *RecommendationService) GetRecommendations(userId string) ([]domain.Recommendation, error) {
+	recommendations, err := s.analyse(userId, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	dismissals, err := s.dismissals.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	dismissed := make(map[string]bool)
+	for _, dismissal := range dismissals {
+		dismissed[dismissal.RecommendationID] = true
+	}
+
+	result := make([]domain.Recommendation, 0)
+	for _, recommendation := range recommendations {
+		if !dismissed[recommendation.ID] {
+			result = append(result, recommendation)
+		}
+	}
+	return result, nil
+}
+
+// DismissRecommendation hides a current recommendation from the user
+func (s *RecommendationService) DismissRecommendation(id string, userId string) error {
+	recommendations, err := s.analyse(userId, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, recommendation := range recommendations {
+		if recommendation.ID == id {
+			return s.dismissals.Create(&domain.RecommendationDismissal{UserID: userId, RecommendationID: id})
+		}
+	}
+	return ErrRecommendationNotFound
+}
+
+// yearlyCost is the gross amount a subscription will charge over the next year
+func yearlyCost(sub *domain.Subscription, now time.Time) float64 {
+	total := 0.0
+	for _, renewal := range sub.Renewals(now, now.AddDate(1, 0, 0)) {
+		total += renewal.Gross
+	}
+	return total
+}
+
+func (s *RecommendationService) analyse(userId string, now time.Time) ([]domain.Recommendation, error) {
+	subs, err := s.subscriptions.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	configs, err := s.configs.Find()
+	if err != nil {
+		return nil, err
+	}
+
+	type matched struct {
+		sub    *domain.Subscription
+		config *domain.SubscriptionConfig
+		yearly float64
+	}
+	var active []matched
+	latest := latestVersions(subs)
+	for i := range latest {
+		sub := &latest[i]
+		yearly := yearlyCost(sub, now)
+		if yearly <= 0 {
+			continue
+		}
+		active = append(active, matched{sub: sub, config: domain.MatchSubscriptionConfig(*configs, sub), yearly: yearly})
+	}
+
+	recommendations := make([]domain.Recommendation, 0)
+	byProvider := make(map[uint][]matched)
+	byCategory := make(map[string]map[uint][]matched)
+	for _, m := range active {
+		if m.config == nil {
+			continue
+		}
+		byProvider[m.config.ID] = append(byProvider[m.config.ID], m)
+		if m.config.Category != "" {
+			if byCategory[m.config.Category] == nil {
+				byCategory[m.config.Category] = make(map[uint][]matched)
+			}
+			byCategory[m.config.Category][m.config.ID] = append(byCategory[m.config.Category][m.config.ID], m)
+		}
+		plans := m.config.GetActivePlans()
+
+		// A monthly subscription that has a cheaper annual plan from the same provider
+		if m.sub.BillingCycle == 1 {
+			var best *domain.SubscriptionConfigPlan
+			for i := range plans {
+				if plans[i].BillingCycle == 12 && (best == nil || plans[i].PriceBreakdown().Gross < best.PriceBreakdown().Gross) {
+					best = &plans[i]
+				}
+			}
+			if best != nil && best.PriceBreakdown().Gross < m.yearly {
+				recommendation := domain.NewRecommendation(domain.RecommendationSwitchToAnnual,
+					fmt.Sprintf("Switch %s to the annual %s plan", m.sub.Name, best.Name),
+					[]string{m.sub.Uuid}, &best.ID, m.yearly-best.PriceBreakdown().Gross)
+				recommendation.Provider = m.config.Provider
+				recommendations = append(recommendations, recommendation)
+			}
+		}
+
+		// A subscription priced above the catalog price of the plan it names
+		if m.sub.BillingCycle > 0 {
+			next := m.sub.Renewals(now, now.AddDate(1, 0, 0))
+			name := strings.ToLower(m.sub.Name)
+			for i := range plans {
+				plan := &plans[i]
+				if int(plan.BillingCycle) != m.sub.BillingCycle || !strings.Contains(name, strings.ToLower(plan.Name)) {
+					continue
+				}
+				catalog := plan.PriceBreakdown().Gross
+				if len(next) > 0 && next[0].Gross > catalog {
+					recommendation := domain.NewRecommendation(domain.RecommendationAboveCatalog,
+						fmt.Sprintf("%s costs more than the listed %s %s price", m.sub.Name, m.config.Provider, plan.Name),
+						[]string{m.sub.Uuid}, &plan.ID, (next[0].Gross-catalog)*12/float64(m.sub.BillingCycle))
+					recommendation.Provider = m.config.Provider
+					recommendations = append(recommendations, recommendation)
+				}
+				break
+			}
+		}
+	}
+
+	// Several subscriptions to the same provider: keep the most expensive one
+	for _, group := range byProvider {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool { return group[i].yearly > group[j].yearly })
+		uuids := make([]string, 0, len(group))
+		savings := 0.0
+		for i, m := range group {
+			uuids = append(uuids, m.sub.Uuid)
+			if i > 0 {
+				savings += m.yearly
+			}
+		}
+		recommendation := domain.NewRecommendation(domain.RecommendationDuplicate,
+			fmt.Sprintf("You have %d subscriptions to %s", len(group), group[0].config.Provider),
+			uuids, nil, savings)
+		recommendation.Provider = group[0].config.Provider
+		recommendations = append(recommendations, recommendation)
+	}
+
+	// Several providers in the same category: drop the cheapest one
+	for category, providers := range byCategory {
+		if len(providers) < 2 {
+			continue
+		}
+		var uuids, names []string
+		cheapest := -1.0
+		for _, group := range providers {
+			providerCost := 0.0
+			for _, m := range group {
+				uuids = append(uuids, m.sub.Uuid)
+				providerCost += m.yearly
+			}
+			names = append(names, group[0].config.Provider)
+			if cheapest < 0 || providerCost < cheapest {
+				cheapest = providerCost
+			}
+		}
+		sort.Strings(names)
+		recommendation := domain.NewRecommendation(domain.RecommendationOverlap,
+			fmt.Sprintf("%s overlap as %s services", strings.Join(names, ", "), category),
+			uuids, nil, cheapest)
+		recommendation.Category = category
+		recommendations = append(recommendations, recommendation)
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].EstimatedYearlySavings > recommendations[j].EstimatedYearlySavings
+	})
+	return recommendations, nil
+}
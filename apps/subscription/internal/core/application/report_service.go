@@ -0,0 +1,114 @@
+package application
+
+import (
+	"errors"
+	"time"
+
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+)
+
+var ErrInvalidReportPeriod = errors.New("invalid report period")
+
+type ReportService struct {
+	subscriptions  domain.SubscriptionRepository
+	paymentMethods domain.PaymentMethodRepository
+}
+
+func NewReportService(subscriptions domain.SubscriptionRepository, paymentMethods domain.PaymentMethodRepository) *ReportService {
+	return &ReportService{subscriptions: subscriptions, paymentMethods: paymentMethods}
+}
+
+// GetSpendReport totals every renewal in [from, to) and groups it by payment method. The
+// totals are labelled with the user's currency.
+func (s *ReportService) GetSpendReport(userId string, from, to time.Time, currency string) (*domain.SpendReport, error) {
+	if !from.Before(to) {
+		return nil, ErrInvalidReportPeriod
+	}
+	subs, err := s.subscriptions.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	paymentMethods, err := s.paymentMethods.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.SpendReport{
+		From:            from,
+		To:              to,
+		ByPaymentMethod: make([]domain.PaymentMethodSpend, 0),
+		ByQuarter:       quarters(from, to),
+		Currency:        currency,
+	}
+	groups := make(map[uint]int)
+	unassigned := -1
+	for _, paymentMethod := range paymentMethods {
+		id := paymentMethod.ID
+		groups[id] = len(report.ByPaymentMethod)
+		report.ByPaymentMethod = append(report.ByPaymentMethod, domain.PaymentMethodSpend{
+			PaymentMethodID: &id,
+			Label:           paymentMethod.Label,
+		})
+	}
+
+	for _, sub := range latestVersions(subs) {
+		renewals := sub.Renewals(from, to)
+		if len(renewals) == 0 {
+			continue
+		}
+		idx, ok := -1, false
+		if sub.PaymentMethodID != nil {
+			idx, ok = groups[*sub.PaymentMethodID]
+		}
+		if !ok {
+			if unassigned < 0 {
+				unassigned = len(report.ByPaymentMethod)
+				report.ByPaymentMethod = append(report.ByPaymentMethod, domain.PaymentMethodSpend{Label: "Unassigned"})
+			}
+			idx = unassigned
+		}
+		group := &report.ByPaymentMethod[idx]
+		group.Renewals += len(renewals)
+		for _, renewal := range renewals {
+			group.Total = domain.RoundCents(group.Total + renewal.Gross)
+			group.Net = domain.RoundCents(group.Net + renewal.Net)
+			group.Tax = domain.RoundCents(group.Tax + renewal.Tax)
+
+			quarter := quarterOf(report, renewal.Date)
+			quarter.Gross = domain.RoundCents(quarter.Gross + renewal.Gross)
+			quarter.Net = domain.RoundCents(quarter.Net + renewal.Net)
+			quarter.Tax = domain.RoundCents(quarter.Tax + renewal.Tax)
+			if sub.BusinessExpense {
+				quarter.Reclaimable = domain.RoundCents(quarter.Reclaimable + renewal.Tax)
+				report.ReclaimableTax = domain.RoundCents(report.ReclaimableTax + renewal.Tax)
+			}
+
+			report.Total = domain.RoundCents(report.Total + renewal.Gross)
+			report.NetTotal = domain.RoundCents(report.NetTotal + renewal.Net)
+			report.TaxTotal = domain.RoundCents(report.TaxTotal + renewal.Tax)
+		}
+	}
+	return report, nil
+}
+
+// quarters returns an empty entry for every calendar quarter overlapping [from, to)
+func quarters(from, to time.Time) []domain.QuarterTax {
+	result := make([]domain.QuarterTax, 0)
+	start := time.Date(from.Year(), from.Month()-(from.Month()-1)%3, 1, 0, 0, 0, 0, time.UTC)
+	for q := start; q.Before(to); q = q.AddDate(0, 3, 0) {
+		result = append(result, domain.QuarterTax{Year: q.Year(), Quarter: int(q.Month()-1)/3 + 1})
+	}
+	return result
+}
+
+// quarterOf returns the report's entry for the calendar quarter of date
+func quarterOf(report *domain.SpendReport, date time.Time) *domain.QuarterTax {
+	year, quarter := date.Year(), int(date.Month()-1)/3+1
+	for i := range report.ByQuarter {
+		if report.ByQuarter[i].Year == year && report.ByQuarter[i].Quarter == quarter {
+			return &report.ByQuarter[i]
+		}
+	}
+	report.ByQuarter = append(report.ByQuarter, domain.QuarterTax{Year: year, Quarter: quarter})
+	return &report.ByQuarter[len(report.ByQuarter)-1]
+}
@@ -1,21 +1,55 @@
 package application
 
 import (
-	"errors"
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/subscription-tracker/subscription/internal/core/domain"
 	"github.com/subscription-tracker/subscription/internal/interface/http/dto"
 )
 
+// MinimumTermError is returned when a cancellation would end a subscription inside its minimum term
+type MinimumTermError struct {
+	MinimumTermEnd time.Time
+	// Earliest is the first date the subscription can be cancelled on, if it renews again
+	Earliest *time.Time
+}
+
+func (e *MinimumTermError) Error() string {
+	msg := fmt.Sprintf("subscription has a minimum term until %s", e.MinimumTermEnd.Format("2006-01-02"))
+	if e.Earliest != nil {
+		msg += fmt.Sprintf(", the earliest possible cancellation date is %s", e.Earliest.Format("2006-01-02"))
+	}
+	return msg
+}
+
+// NoticePeriodError is returned when the notice deadline for a requested cancellation date has passed
+type NoticePeriodError struct {
+	CancelAt time.Time
+	// CancelBy is the notice deadline that was missed
+	CancelBy time.Time
+	// Earliest is the first date the subscription can still be cancelled on, if it renews again
+	Earliest *time.Time
+}
+
+func (e *NoticePeriodError) Error() string {
+	msg := fmt.Sprintf("cancelling on %s required notice by %s", e.CancelAt.Format("2006-01-02"), e.CancelBy.Format("2006-01-02"))
+	if e.Earliest != nil {
+		msg += fmt.Sprintf(", the earliest possible cancellation date is %s", e.Earliest.Format("2006-01-02"))
+	}
+	return msg
+}
+
 type SubscriptionService struct {
-	repo domain.SubscriptionRepository
+	repo           domain.SubscriptionRepository
+	paymentMethods domain.PaymentMethodRepository
+	usage          domain.UsageEventRepository
 }
 
-func NewSubscriptionService(repo domain.SubscriptionRepository) *SubscriptionService {
-	return &SubscriptionService{repo: repo}
+func NewSubscriptionService(repo domain.SubscriptionRepository, paymentMethods domain.PaymentMethodRepository, usage domain.UsageEventRepository) *SubscriptionService {
+	return &SubscriptionService{repo: repo, paymentMethods: paymentMethods, usage: usage}
 }
 
 func (s *SubscriptionService) GetSubscription(uuid string, userId string) (*domain.Subscription, error) {
@@ -27,31 +61,166 @@ func (s *SubscriptionService) GetSubscription(uuid string, userId string) (*doma
 	if err != nil {
 		return nil, err
 	}
-	return &subs[0], nil
+	latest := latestVersions(subs)
+	if len(latest) == 0 {
+		return nil, ErrSubscriptionNotFound
+	}
+	return &latest[0], nil
 }
 
-func (s *SubscriptionService) UpdateSubscription(uuid string, data dto.UpdateSubscriptionRequest, userId string) error {
-	subs, err := s.repo.FindByUuid(uuid)
+// UpdateSubscription records a new version of the subscription. Optional fields missing
+// from the request are carried forward from the current version. Notice deadlines are
+// checked against the current day in the user's time zone.
+func (s *SubscriptionService) UpdateSubscription(uuid string, data dto.UpdateSubscriptionRequest, userId string, prefs domain.Preferences) error {
+	current, err := s.GetSubscription(uuid, userId)
 	if err != nil {
 		return err
 	}
-	if subs.UserID != userId {
-		return errors.New("Unauthorized")
-	}
 	// Create a new version of the subscription with a new ID but preserving the UUID
-	newSubs := &domain.Subscription{
-		ID:           0, // Let the database assign a new ID
-		UserID:       userId,
-		Uuid:         uuid,
-		Name:         data.Name,
-		Price:        data.Price,
-		StartDate:    data.StartDate,
-		BillingCycle: subs.BillingCycle,
-		Logo:         data.Logo,
+	newSubs := current.NewVersion()
+	newSubs.Name = data.Name
+	newSubs.Price = data.Price
+	newSubs.StartDate = data.StartDate
+	newSubs.Logo = data.Logo
+	if data.TrialEndDate != nil {
+		newSubs.TrialEndDate = data.TrialEndDate
+	} else if data.ClearTrialEndDate {
+		newSubs.TrialEndDate = nil
+	}
+	cancelAtChanged := false
+	if data.CancelAt != nil {
+		cancelAtChanged = current.CancelAt == nil || !current.CancelAt.Equal(*data.CancelAt)
+		newSubs.CancelAt = data.CancelAt
+	} else if data.ClearCancelAt {
+		newSubs.CancelAt = nil
+	}
+	if data.PaymentMethodID != nil {
+		newSubs.PaymentMethodID = data.PaymentMethodID
+		if *data.PaymentMethodID == 0 {
+			newSubs.PaymentMethodID = nil
+		}
+		if err := s.checkPaymentMethod(newSubs.PaymentMethodID, userId); err != nil {
+			return err
+		}
+	}
+	if data.NoticePeriodDays != nil {
+		newSubs.NoticePeriodDays = *data.NoticePeriodDays
+	}
+	if data.MinimumTermMonths != nil {
+		newSubs.MinimumTermMonths = *data.MinimumTermMonths
+	}
+	if data.TaxRate != nil {
+		newSubs.TaxRate = *data.TaxRate
+	}
+	if data.PriceIncludesTax != nil {
+		newSubs.PriceIncludesTax = *data.PriceIncludesTax
+	}
+	if data.BusinessExpense != nil {
+		newSubs.BusinessExpense = *data.BusinessExpense
+	}
+	if data.PriceSchedule != nil {
+		newSubs.SetPriceSchedule(dto.ToPriceSchedule(*data.PriceSchedule))
+	}
+	if err := newSubs.ValidatePriceSchedule(); err != nil {
+		return err
+	}
+	now := prefs.Now()
+	if err := checkMinimumTerm(newSubs, now); err != nil {
+		return err
+	}
+	if cancelAtChanged {
+		if err := checkNoticePeriod(newSubs, now); err != nil {
+			return err
+		}
 	}
 	return s.repo.Create(newSubs)
 }
 
+// CancelSubscription schedules the subscription to end at the end of the current billing
+// period, or today if it does not renew again. If the notice period for that renewal has
+// already passed, the cancellation takes effect at the following renewal instead. Cancelling
+// a subscription whose current period ends inside its minimum term is rejected with a
+// MinimumTermError.
+func (s *SubscriptionService) CancelSubscription(uuid string, userId string, prefs domain.Preferences) (*domain.Subscription, error) {
+	sub, err := s.GetSubscription(uuid, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := prefs.Now()
+	sub.CancelAt = nil
+	cancelAt := sub.EarliestCancellation(now)
+	today := truncateDay(now)
+	periodEnd := &today
+	if next := sub.NextRenewal(now); next != nil {
+		periodEnd = next
+	}
+	if termEnd := sub.MinimumTermEnd(); termEnd != nil && periodEnd.Before(*termEnd) {
+		return nil, &MinimumTermError{MinimumTermEnd: *termEnd, Earliest: cancelAt}
+	}
+	if cancelAt == nil {
+		cancelAt = &today
+	}
+
+	// Record the cancellation as a new version of the subscription
+	version := sub.NewVersion()
+	version.CancelAt = cancelAt
+	if err := s.repo.Create(version); err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// SchedulePriceChange records a new version of the subscription whose price schedule
+// switches to price from the first renewal on or after effective
+func (s *SubscriptionService) SchedulePriceChange(uuid string, data dto.PriceChangeRequest, userId string) (*domain.Subscription, error) {
+	sub, err := s.GetSubscription(uuid, userId)
+	if err != nil {
+		return nil, err
+	}
+	version := sub.NewVersion()
+	if err := version.SchedulePriceChange(data.Price, data.EffectiveDate); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(version); err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// checkMinimumTerm rejects a cancellation date that falls inside the subscription's minimum term
+func checkMinimumTerm(sub *domain.Subscription, now time.Time) error {
+	termEnd := sub.MinimumTermEnd()
+	if sub.CancelAt == nil || termEnd == nil || !sub.CancelAt.Before(*termEnd) {
+		return nil
+	}
+	cancelAt := sub.CancelAt
+	sub.CancelAt = nil
+	err := &MinimumTermError{MinimumTermEnd: *termEnd, Earliest: sub.EarliestCancellation(now)}
+	sub.CancelAt = cancelAt
+	return err
+}
+
+// checkNoticePeriod rejects a cancellation date whose notice deadline has passed: the first
+// renewal on or after CancelAt is the one being cancelled, and its notice must still be due
+func checkNoticePeriod(sub *domain.Subscription, now time.Time) error {
+	if sub.CancelAt == nil || sub.NoticePeriodDays <= 0 {
+		return nil
+	}
+	cancelAt := sub.CancelAt
+	sub.CancelAt = nil
+	defer func() { sub.CancelAt = cancelAt }()
+
+	renewal := sub.NextRenewal(*cancelAt)
+	if renewal == nil {
+		return nil
+	}
+	if cancelBy := sub.CancelBy(*renewal); cancelBy.Before(truncateDay(now)) {
+		return &NoticePeriodError{CancelAt: *cancelAt, CancelBy: cancelBy, Earliest: sub.EarliestCancellation(now)}
+	}
+	return nil
+}
+
 func (s *SubscriptionService) GetUserSubscriptions(query dto.SubscriptionQueryParams, userId string) ([]domain.Subscription, error) {
 	q := &domain.SubscriptionRepoQuery{
 		UserID: &userId,
@@ -85,6 +254,25 @@ func (s *SubscriptionService) GetUserSubscriptions(query dto.SubscriptionQueryPa
 	if err != nil {
 		return nil, err
 	}
+
+	latestSubs := latestVersions(subs)
+	if err := annotateUsage(s.usage, latestSubs, userId, query.UnusedDays, time.Now()); err != nil {
+		return nil, err
+	}
+	if query.Unused != nil {
+		filtered := make([]domain.Subscription, 0, len(latestSubs))
+		for _, sub := range latestSubs {
+			if sub.Usage.Unused == *query.Unused {
+				filtered = append(filtered, sub)
+			}
+		}
+		latestSubs = filtered
+	}
+	return latestSubs, nil
+}
+
+// latestVersions keeps only the most recent version of each subscription uuid
+func latestVersions(subs []domain.Subscription) []domain.Subscription {
 	uuidGrouped := make(map[string][]domain.Subscription)
 	for _, sub := range subs {
 		uuidGrouped[sub.Uuid] = append(uuidGrouped[sub.Uuid], sub)
@@ -92,21 +280,41 @@ func (s *SubscriptionService) GetUserSubscriptions(query dto.SubscriptionQueryPa
 	latestSubs := make([]domain.Subscription, 0)
 	for _, subs := range uuidGrouped {
 		sort.Slice(subs, func(i, j int) bool {
+			if subs[i].StartDate.Equal(subs[j].StartDate) {
+				return subs[i].ID > subs[j].ID
+			}
 			return subs[i].StartDate.After(subs[j].StartDate)
 		})
 		latestSubs = append(latestSubs, subs[0])
 	}
-
-	return latestSubs, nil
+	return latestSubs
 }
 
-func (s *SubscriptionService) CreateSubscription(subscription *domain.Subscription) error {
+func (s *SubscriptionService) CreateSubscription(subscription *domain.Subscription, prefs domain.Preferences) error {
 	if subscription.Uuid == "" {
 		subscription.Uuid = uuid.NewString()
 	}
+	if err := s.checkPaymentMethod(subscription.PaymentMethodID, subscription.UserID); err != nil {
+		return err
+	}
+	if err := subscription.ValidatePriceSchedule(); err != nil {
+		return err
+	}
+	if err := checkMinimumTerm(subscription, prefs.Now()); err != nil {
+		return err
+	}
 	return s.repo.Create(subscription)
 }
 
+// checkPaymentMethod makes sure a subscription is only charged to a payment method of its owner
+func (s *SubscriptionService) checkPaymentMethod(id *uint, userId string) error {
+	if id == nil {
+		return nil
+	}
+	_, err := findOwnedPaymentMethod(s.paymentMethods, *id, userId)
+	return err
+}
+
 func (s *SubscriptionService) DeleteSubscription(id string, userId string) error {
 	return s.repo.Delete(id)
 }
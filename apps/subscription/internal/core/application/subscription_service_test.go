@@ -0,0 +1,123 @@
+package application
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+	"github.com/subscription-tracker/subscription/internal/interface/http/dto"
+)
+
+func TestUpdateSubscriptionCarriesDatesForward(t *testing.T) {
+	today := truncateDay(time.Now())
+	trialEnd := today.AddDate(0, 0, 10)
+	cancelAt := domain.AddMonths(today, 2)
+	newTrialEnd := today.AddDate(0, 0, 20)
+	newCancelAt := domain.AddMonths(today, 3)
+
+	tests := []struct {
+		name         string
+		request      dto.UpdateSubscriptionRequest
+		wantTrialEnd *time.Time
+		wantCancelAt *time.Time
+	}{
+		{name: "left out", wantTrialEnd: &trialEnd, wantCancelAt: &cancelAt},
+		{
+			name:         "replaced",
+			request:      dto.UpdateSubscriptionRequest{TrialEndDate: &newTrialEnd, CancelAt: &newCancelAt},
+			wantTrialEnd: &newTrialEnd,
+			wantCancelAt: &newCancelAt,
+		},
+		{
+			name:    "cleared",
+			request: dto.UpdateSubscriptionRequest{ClearTrialEndDate: true, ClearCancelAt: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeSubscriptionRepository{subs: []domain.Subscription{{
+				ID: 1, Uuid: "sub-1", UserID: "user-1", Name: "Netflix", Price: 15.49, BillingCycle: 1,
+				StartDate: today.AddDate(0, -1, 0), IsActive: true, TrialEndDate: &trialEnd, CancelAt: &cancelAt,
+			}}}
+			service := NewSubscriptionService(repo, nil, nil)
+			request := tt.request
+			request.Name, request.Price, request.StartDate = "Netflix", 17.99, today.AddDate(0, -1, 0)
+
+			if err := service.UpdateSubscription("sub-1", request, "user-1", domain.DefaultPreferences()); err != nil {
+				t.Fatalf("UpdateSubscription: %v", err)
+			}
+			if len(repo.subs) != 2 {
+				t.Fatalf("stored %d versions, want 2", len(repo.subs))
+			}
+			got := repo.subs[1]
+			if got.Price != 17.99 {
+				t.Errorf("price = %v, want 17.99", got.Price)
+			}
+			if !equalTimes(got.TrialEndDate, tt.wantTrialEnd) {
+				t.Errorf("trialEndDate = %v, want %v", got.TrialEndDate, tt.wantTrialEnd)
+			}
+			if !equalTimes(got.CancelAt, tt.wantCancelAt) {
+				t.Errorf("cancelAt = %v, want %v", got.CancelAt, tt.wantCancelAt)
+			}
+		})
+	}
+}
+
+func TestUpdateSubscriptionChecksNoticePeriod(t *testing.T) {
+	today := truncateDay(time.Now())
+	// The next renewal is a few days away, well inside the 14 day notice period
+	start := domain.AddMonths(today.AddDate(0, 0, 5), -1)
+	nextRenewal := domain.AddMonths(start, 1)
+	followingRenewal := domain.AddMonths(start, 2)
+
+	tests := []struct {
+		name         string
+		cancelAt     *time.Time
+		current      *time.Time
+		wantEarliest *time.Time
+	}{
+		{name: "notice passed", cancelAt: &nextRenewal, wantEarliest: &followingRenewal},
+		{name: "between renewals", cancelAt: timePtr(nextRenewal.AddDate(0, 0, -2)), wantEarliest: &followingRenewal},
+		{name: "notice still due", cancelAt: &followingRenewal},
+		{name: "unchanged cancellation", cancelAt: &nextRenewal, current: &nextRenewal},
+		{name: "no cancellation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeSubscriptionRepository{subs: []domain.Subscription{{
+				ID: 1, Uuid: "sub-1", UserID: "user-1", Name: "Gym", Price: 30, BillingCycle: 1,
+				StartDate: start, IsActive: true, NoticePeriodDays: 14, CancelAt: tt.current,
+			}}}
+			service := NewSubscriptionService(repo, nil, nil)
+			request := dto.UpdateSubscriptionRequest{Name: "Gym", Price: 35, StartDate: start, CancelAt: tt.cancelAt}
+
+			err := service.UpdateSubscription("sub-1", request, "user-1", domain.DefaultPreferences())
+			if tt.wantEarliest == nil {
+				if err != nil {
+					t.Fatalf("UpdateSubscription: %v", err)
+				}
+				return
+			}
+			var noticeErr *NoticePeriodError
+			if !errors.As(err, &noticeErr) {
+				t.Fatalf("error = %v, want a NoticePeriodError", err)
+			}
+			if !noticeErr.CancelBy.Equal(nextRenewal.AddDate(0, 0, -14)) {
+				t.Errorf("cancelBy = %v, want 14 days before %v", noticeErr.CancelBy, nextRenewal)
+			}
+			if !equalTimes(noticeErr.Earliest, tt.wantEarliest) {
+				t.Errorf("earliest = %v, want %v", noticeErr.Earliest, tt.wantEarliest)
+			}
+			if len(repo.subs) != 1 {
+				t.Errorf("stored %d versions, want the rejected update not to be stored", len(repo.subs))
+			}
+		})
+	}
+}
+
+func equalTimes(a, b *time.Time) bool {
+	return (a == nil) == (b == nil) && (a == nil || a.Equal(*b))
+}
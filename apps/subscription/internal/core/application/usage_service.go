@@ -0,0 +1,140 @@
+package application
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+	"github.com/subscription-tracker/subscription/internal/interface/http/dto"
+)
+
+var ErrInvalidUsageWindow = errors.New("invalid usage window")
+
+// DefaultUnusedDays is how long a subscription may go without use before it is flagged
+const DefaultUnusedDays = 30
+
+// maxUnusedDays bounds the usage window
+const maxUnusedDays = 366
+
+type UsageService struct {
+	repo          domain.UsageEventRepository
+	subscriptions domain.SubscriptionRepository
+}
+
+func NewUsageService(repo domain.UsageEventRepository, subscriptions domain.SubscriptionRepository) *UsageService {
+	return &UsageService{repo: repo, subscriptions: subscriptions}
+}
+
+// RecordUsage records a usage event against one of the user's subscriptions
+func (s *UsageService) RecordUsage(subscriptionUuid string, data dto.RecordUsageRequest, userId string) (*domain.UsageEvent, error) {
+	if err := checkSubscriptionOwner(s.subscriptions, subscriptionUuid, userId); err != nil {
+		return nil, err
+	}
+	event := data.ToUsageEvent(subscriptionUuid, userId, time.Now())
+	if err := s.repo.Create(event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// GetDigest returns the user's subscriptions that were not used in the last unusedDays
+// days, most expensive first, and the cancellation deadlines from today, in the user's time
+// zone, up to their reminder lead days ahead, soonest first. The digest is built on
+// request; nothing sends it on a schedule.
+func (s *UsageService) GetDigest(userId string, unusedDays int, prefs domain.Preferences) (*domain.UsageDigest, error) {
+	subs, err := s.subscriptions.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	now := prefs.Now()
+	subs = latestVersions(subs)
+	if err := annotateUsage(s.repo, subs, userId, unusedDays, now); err != nil {
+		return nil, err
+	}
+
+	digest := &domain.UsageDigest{
+		GeneratedAt: now,
+		UnusedDays:  unusedDays,
+		Unused:      make([]domain.Subscription, 0),
+		CancelBy:    make([]domain.CancellationDeadline, 0),
+	}
+	// A lead of 0 days still lists the deadlines that fall today
+	horizon := truncateDay(now).AddDate(0, 0, prefs.ReminderLeadDays+1)
+	for _, sub := range subs {
+		if sub.Usage.Unused {
+			digest.Unused = append(digest.Unused, sub)
+			digest.PotentialYearlySavings = domain.RoundCents(digest.PotentialYearlySavings + yearlyCost(&sub, now))
+		}
+		if sub.NoticePeriodDays <= 0 && sub.MinimumTermMonths <= 0 {
+			continue
+		}
+		if cancelBy := sub.CancelByDate(now); cancelBy != nil && cancelBy.Before(horizon) {
+			digest.CancelBy = append(digest.CancelBy, domain.CancellationDeadline{
+				SubscriptionUuid: sub.Uuid,
+				Name:             sub.Name,
+				CancelBy:         *cancelBy,
+				Renewal:          *sub.EarliestCancellation(now),
+				Unused:           sub.Usage.Unused,
+			})
+		}
+	}
+	sort.Slice(digest.Unused, func(i, j int) bool {
+		return digest.Unused[i].Usage.Cost > digest.Unused[j].Usage.Cost
+	})
+	sort.Slice(digest.CancelBy, func(i, j int) bool {
+		return digest.CancelBy[i].CancelBy.Before(digest.CancelBy[j].CancelBy)
+	})
+	return digest, nil
+}
+
+// annotateUsage sets the usage of every subscription over the last windowDays days. A
+// subscription that is still being charged is unused when it has not been used in the
+// window and is older than the window.
+func annotateUsage(repo domain.UsageEventRepository, subs []domain.Subscription, userId string, windowDays int, now time.Time) error {
+	if windowDays < 1 || windowDays > maxUnusedDays {
+		return ErrInvalidUsageWindow
+	}
+	since := now.AddDate(0, 0, -windowDays)
+	summaries, err := repo.Summaries(userId, since)
+	if err != nil {
+		return err
+	}
+
+	for i := range subs {
+		sub := &subs[i]
+		summary := summaries[sub.Uuid]
+		usage := &domain.SubscriptionUsage{
+			WindowDays: windowDays,
+			Uses:       summary.Uses,
+			LastUsedAt: summary.LastUsedAt,
+		}
+		for _, renewal := range sub.Renewals(since, now) {
+			usage.Cost = domain.RoundCents(usage.Cost + renewal.Gross)
+		}
+		if usage.Uses > 0 {
+			costPerUse := domain.RoundCents(usage.Cost / float64(usage.Uses))
+			usage.CostPerUse = &costPerUse
+		}
+		charging := sub.NextRenewal(now) != nil
+		usedInWindow := summary.LastUsedAt != nil && !summary.LastUsedAt.Before(since)
+		usage.Unused = charging && !usedInWindow && sub.StartDate.Before(since)
+		sub.Usage = usage
+	}
+	return nil
+}
+
+// checkSubscriptionOwner makes sure the subscription exists and belongs to the user
+func checkSubscriptionOwner(repo domain.SubscriptionRepository, subscriptionUuid string, userId string) error {
+	subs, err := repo.Find(&domain.SubscriptionRepoQuery{
+		Uuid:   &subscriptionUuid,
+		UserID: &userId,
+	}, nil)
+	if err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		return ErrSubscriptionNotFound
+	}
+	return nil
+}
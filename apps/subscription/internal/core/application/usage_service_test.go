@@ -0,0 +1,68 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+)
+
+func TestDigestCancellationDeadlines(t *testing.T) {
+	today := truncateDay(time.Now())
+	// Renew in about 20 days, so with 14 days notice the deadlines fall within a week.
+	// AddMonths may clamp the renewals to the end of a month by up to three days.
+	gym := domain.Subscription{ID: 1, Uuid: "gym", UserID: "user-1", Name: "Gym", Price: 30, BillingCycle: 1,
+		StartDate: domain.AddMonths(today.AddDate(0, 0, 20), -1), IsActive: true, NoticePeriodDays: 14}
+	phone := domain.Subscription{ID: 2, Uuid: "phone", UserID: "user-1", Name: "Phone", Price: 40, BillingCycle: 1,
+		StartDate: domain.AddMonths(today.AddDate(0, 0, 19), -1), IsActive: true, NoticePeriodDays: 14}
+	repo := &fakeSubscriptionRepository{subs: []domain.Subscription{
+		gym,
+		phone,
+		// No notice period: renewing soon is not a deadline
+		{ID: 3, Uuid: "netflix", UserID: "user-1", Name: "Netflix", Price: 15.49, BillingCycle: 1,
+			StartDate: domain.AddMonths(today.AddDate(0, 0, 2), -1), IsActive: true},
+		// The deadline is weeks away
+		{ID: 4, Uuid: "insurance", UserID: "user-1", Name: "Insurance", Price: 20, BillingCycle: 12,
+			StartDate: domain.AddMonths(today.AddDate(0, 0, 60), -12), IsActive: true, NoticePeriodDays: 30},
+		// Already being cancelled
+		{ID: 5, Uuid: "cancelled", UserID: "user-1", Name: "Cancelled", Price: 10, BillingCycle: 1,
+			StartDate: domain.AddMonths(today.AddDate(0, 0, 17), -1), IsActive: true, NoticePeriodDays: 14,
+			CancelAt: timePtr(domain.AddMonths(today.AddDate(0, 0, 17), 1))},
+	}}
+	service := NewUsageService(&fakeUsageEventRepository{}, repo)
+	prefs := domain.DefaultPreferences()
+
+	// The deadlines are at least two days away, so a shorter lead leaves them out
+	prefs.ReminderLeadDays = 1
+	digest, err := service.GetDigest("user-1", DefaultUnusedDays, prefs)
+	if err != nil {
+		t.Fatalf("GetDigest: %v", err)
+	}
+	if len(digest.CancelBy) != 0 {
+		t.Errorf("digest with a one day lead has deadlines %+v", digest.CancelBy)
+	}
+
+	prefs.ReminderLeadDays = 7
+	digest, err = service.GetDigest("user-1", DefaultUnusedDays, prefs)
+	if err != nil {
+		t.Fatalf("GetDigest: %v", err)
+	}
+	if len(digest.CancelBy) != 2 {
+		t.Fatalf("digest has %d deadlines, want 2: %+v", len(digest.CancelBy), digest.CancelBy)
+	}
+	if digest.CancelBy[1].CancelBy.Before(digest.CancelBy[0].CancelBy) {
+		t.Errorf("deadlines %+v are not soonest first", digest.CancelBy)
+	}
+	for _, sub := range []domain.Subscription{phone, gym} {
+		renewal := domain.AddMonths(sub.StartDate, 1)
+		found := false
+		for _, deadline := range digest.CancelBy {
+			if deadline.SubscriptionUuid == sub.Uuid {
+				found = deadline.Renewal.Equal(renewal) && deadline.CancelBy.Equal(renewal.AddDate(0, 0, -14))
+			}
+		}
+		if !found {
+			t.Errorf("deadlines %+v, want %s by 14 days before %v", digest.CancelBy, sub.Uuid, renewal)
+		}
+	}
+}
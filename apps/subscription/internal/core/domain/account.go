@@ -0,0 +1,9 @@
+package domain
+
+// AccountRepository removes everything stored for a user
+type AccountRepository interface {
+	// PurgeUser deletes the user's subscriptions with their price schedules, attachments,
+	// usage events, payment methods and recommendation dismissals in one transaction, and
+	// returns the storage keys of the deleted attachments
+	PurgeUser(userId string) ([]string, error)
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"io"
+	"time"
+)
+
+// Attachment is a receipt, invoice or contract stored next to a subscription.
+// The file itself lives in a BlobStore under StorageKey.
+type Attachment struct {
+	ID               uint   `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Uuid             string `gorm:"column:uuid;not null;uniqueIndex" json:"uuid"`
+	SubscriptionUuid string `gorm:"column:subscription_uuid;not null;index" json:"subscriptionUuid"`
+	UserID           string `gorm:"column:user_id;not null" json:"userId"`
+	FileName         string `gorm:"column:file_name;not null" json:"fileName"`
+	ContentType      string `gorm:"column:content_type;not null" json:"contentType"`
+	Size             int64  `gorm:"column:size;not null" json:"size"`
+	StorageKey       string `gorm:"column:storage_key;not null" json:"-"`
+
+	CreatedAt time.Time `gorm:"column:created_at;not null;autoCreateTime" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"column:updated_at;not null;autoUpdateTime" json:"updatedAt"`
+}
+
+type AttachmentRepository interface {
+	FindBySubscriptionUuid(subscriptionUuid string) ([]Attachment, error)
+	FindByUserId(userId string) ([]Attachment, error)
+	FindByUuid(uuid string) (*Attachment, error)
+	Create(attachment *Attachment) error
+	Delete(uuid string) error
+}
+
+// BlobStore stores attachment contents by key
+type BlobStore interface {
+	Put(key string, content io.Reader) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
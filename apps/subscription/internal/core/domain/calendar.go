@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+// CalendarEntry represents a single subscription event on a calendar day
+type CalendarEntry struct {
+	Uuid string `json:"uuid"`
+	Name string `json:"name"`
+	Logo string `json:"logo"`
+	// Price is the gross amount charged, split into Net and Tax
+	Price           float64 `json:"price"`
+	Net             float64 `json:"net"`
+	Tax             float64 `json:"tax"`
+	BillingCycle    int     `json:"billingCycle"`
+	Renewal         bool    `json:"renewal"`
+	TrialEnding     bool    `json:"trialEnding"`
+	CancellationDue bool    `json:"cancellationDue"`
+	CancelBy        bool    `json:"cancelBy"`
+}
+
+// CalendarDay groups the subscription events of one day together with the
+// amount charged that day and the amount charged so far in the period
+type CalendarDay struct {
+	Date            string          `json:"date"`
+	Entries         []CalendarEntry `json:"entries"`
+	Total           float64         `json:"total"`
+	RunningTotal    float64         `json:"runningTotal"`
+	TrialEnding     bool            `json:"trialEnding"`
+	CancellationDue bool            `json:"cancellationDue"`
+	CancelBy        bool            `json:"cancelBy"`
+}
+
+// Calendar is a contiguous range of calendar days
+type Calendar struct {
+	From     time.Time     `json:"from"`
+	To       time.Time     `json:"to"`
+	Days     []CalendarDay `json:"days"`
+	Total    float64       `json:"total"`
+	Currency string        `json:"currency,omitempty"`
+}
+
+// CalendarDateFormat is the layout used for CalendarDay.Date
+const CalendarDateFormat = "2006-01-02"
@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// Payment is a renewal charged so far
+type Payment struct {
+	Date  time.Time `json:"date"`
+	Price float64   `json:"price"`
+	TaxBreakdown
+}
+
+// SubscriptionHistory is a subscription with every version recorded for it, oldest first
+type SubscriptionHistory struct {
+	Uuid     string         `json:"uuid"`
+	Versions []Subscription `json:"versions"`
+	// Payments are computed from the latest version, like spend reports
+	Payments    []Payment    `json:"payments"`
+	UsageEvents []UsageEvent `json:"usageEvents"`
+	Attachments []Attachment `json:"attachments"`
+}
+
+// UserDataExport is everything the service stores about a user
+type UserDataExport struct {
+	UserID                   string                    `json:"userId"`
+	GeneratedAt              time.Time                 `json:"generatedAt"`
+	Subscriptions            []SubscriptionHistory     `json:"subscriptions"`
+	PaymentMethods           []PaymentMethod           `json:"paymentMethods"`
+	RecommendationDismissals []RecommendationDismissal `json:"recommendationDismissals"`
+}
@@ -0,0 +1,58 @@
+package domain
+
+import "time"
+
+// Payday is a recurring monthly income used to overlay a balance on a forecast.
+// A DayOfMonth past the end of a month falls on the month's last day.
+type Payday struct {
+	DayOfMonth int     `json:"dayOfMonth"`
+	Amount     float64 `json:"amount"`
+}
+
+// DateIn returns the payday's date in the month of t
+func (p Payday) DateIn(t time.Time) time.Time {
+	first := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	day := p.DayOfMonth
+	if day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// ForecastDay is the projected money movement of a single day. Balance is only set
+// when the forecast overlays a balance.
+type ForecastDay struct {
+	Date     string   `json:"date"`
+	Outflow  float64  `json:"outflow"`
+	Inflow   float64  `json:"inflow"`
+	Balance  *float64 `json:"balance,omitempty"`
+	Renewals []string `json:"renewals"`
+}
+
+// ForecastMonth totals the projected outflows of a calendar month
+type ForecastMonth struct {
+	Year     int     `json:"year"`
+	Month    int     `json:"month"`
+	Outflow  float64 `json:"outflow"`
+	Inflow   float64 `json:"inflow"`
+	Renewals int     `json:"renewals"`
+}
+
+// ForecastLowPoint is the lowest projected balance in the run-up to a payday
+type ForecastLowPoint struct {
+	Payday        string  `json:"payday"`
+	LowestBalance float64 `json:"lowestBalance"`
+	Date          string  `json:"date"`
+}
+
+// Forecast projects renewals between From and To. Days lists only days with money movement.
+type Forecast struct {
+	From      time.Time          `json:"from"`
+	To        time.Time          `json:"to"`
+	Outflow   float64            `json:"outflow"`
+	Days      []ForecastDay      `json:"days"`
+	Months    []ForecastMonth    `json:"months"`
+	LowPoints []ForecastLowPoint `json:"lowPoints,omitempty"`
+	Currency  string             `json:"currency,omitempty"`
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidPaymentMethod wraps the reasons a payment method fails validation
+var ErrInvalidPaymentMethod = errors.New("invalid payment method")
+
+// PaymentMethod represents a card or account a subscription is charged to.
+// Only the last four digits of a card are ever stored.
+type PaymentMethod struct {
+	ID          uint   `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UserID      string `gorm:"column:user_id;not null;index" json:"userId"`
+	Label       string `gorm:"column:label;not null" json:"label"`
+	Type        string `gorm:"column:type;not null" json:"type"`
+	LastFour    string `gorm:"column:last_four" json:"lastFour"`
+	ExpiryMonth int    `gorm:"column:expiry_month" json:"expiryMonth"`
+	ExpiryYear  int    `gorm:"column:expiry_year" json:"expiryYear"`
+
+	CreatedAt time.Time `gorm:"column:created_at;not null;autoCreateTime" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"column:updated_at;not null;autoUpdateTime" json:"updatedAt"`
+}
+
+var PaymentMethodTypes = []string{"card", "bank_account", "paypal", "other"}
+
+// Validate performs basic validation on the payment method
+func (pm *PaymentMethod) Validate() error {
+	if pm.Label == "" {
+		return fmt.Errorf("%w: label is required", ErrInvalidPaymentMethod)
+	}
+	valid := false
+	for _, t := range PaymentMethodTypes {
+		if pm.Type == t {
+			valid = true
+		}
+	}
+	if !valid {
+		return fmt.Errorf("%w: invalid payment method type", ErrInvalidPaymentMethod)
+	}
+	if pm.LastFour != "" {
+		if len(pm.LastFour) != 4 {
+			return fmt.Errorf("%w: last four must be exactly four digits", ErrInvalidPaymentMethod)
+		}
+		for _, r := range pm.LastFour {
+			if r < '0' || r > '9' {
+				return fmt.Errorf("%w: last four must be exactly four digits", ErrInvalidPaymentMethod)
+			}
+		}
+	}
+	if (pm.ExpiryMonth == 0) != (pm.ExpiryYear == 0) {
+		return fmt.Errorf("%w: expiry month and year must be given together", ErrInvalidPaymentMethod)
+	}
+	if pm.ExpiryMonth != 0 && (pm.ExpiryMonth < 1 || pm.ExpiryMonth > 12) {
+		return fmt.Errorf("%w: expiry month must be between 1 and 12", ErrInvalidPaymentMethod)
+	}
+	return nil
+}
+
+// HasExpiry reports whether an expiry date was recorded for the payment method
+func (pm *PaymentMethod) HasExpiry() bool {
+	return pm.ExpiryMonth != 0 && pm.ExpiryYear != 0
+}
+
+// ExpiresAt returns the first instant the payment method can no longer be charged,
+// i.e. the start of the month after the expiry month
+func (pm *PaymentMethod) ExpiresAt() time.Time {
+	return time.Date(pm.ExpiryYear, time.Month(pm.ExpiryMonth)+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// PaymentMethodAlert lists the subscriptions that will fail to renew once a payment method expires
+type PaymentMethodAlert struct {
+	PaymentMethod PaymentMethod  `json:"paymentMethod"`
+	ExpiresAt     time.Time      `json:"expiresAt"`
+	Subscriptions []Subscription `json:"subscriptions"`
+}
+
+type PaymentMethodRepository interface {
+	FindByUserId(userId string) ([]PaymentMethod, error)
+	FindByID(id uint) (*PaymentMethod, error)
+	Create(paymentMethod *PaymentMethod) error
+	Update(paymentMethod *PaymentMethod) error
+	Delete(id uint) error
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// Preferences are the settings a user chose for how their subscriptions are presented.
+// The user service owns them and carries them in access tokens.
+type Preferences struct {
+	// Currency is the ISO 4217 code totals are labelled with. Amounts are not converted.
+	Currency  string
+	Location  *time.Location
+	WeekStart time.Weekday
+	// ReminderLeadDays is how many days ahead the digest lists cancellation deadlines and
+	// payment method alerts look for expiring cards
+	ReminderLeadDays int
+}
+
+// DefaultPreferences returns the preferences of a request whose token carries none
+func DefaultPreferences() Preferences {
+	return Preferences{
+		Location:         time.UTC,
+		WeekStart:        time.Monday,
+		ReminderLeadDays: 3,
+	}
+}
+
+// Now returns the current time in the user's time zone
+func (p Preferences) Now() time.Time {
+	return time.Now().In(p.Location)
+}
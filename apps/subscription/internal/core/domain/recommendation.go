@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	RecommendationSwitchToAnnual = "switch_to_annual"
+	RecommendationDuplicate      = "duplicate"
+	RecommendationOverlap        = "overlap"
+	RecommendationAboveCatalog   = "above_catalog"
+)
+
+// Recommendation is a suggested change that would lower a user's subscription spend
+type Recommendation struct {
+	// ID is derived from the recommendation's content so a dismissal keeps applying
+	// for as long as the same situation persists
+	ID                     string   `json:"id"`
+	Type                   string   `json:"type"`
+	Message                string   `json:"message"`
+	SubscriptionUuids      []string `json:"subscriptionUuids"`
+	Provider               string   `json:"provider,omitempty"`
+	Category               string   `json:"category,omitempty"`
+	PlanID                 *uint    `json:"planId,omitempty"`
+	EstimatedYearlySavings float64  `json:"estimatedYearlySavings"`
+}
+
+// NewRecommendation builds a recommendation and derives its ID from the type, the
+// subscriptions involved and the suggested plan
+func NewRecommendation(kind string, message string, subscriptionUuids []string, planID *uint, savings float64) Recommendation {
+	uuids := append([]string(nil), subscriptionUuids...)
+	sort.Strings(uuids)
+	key := kind + ":" + strings.Join(uuids, ",")
+	if planID != nil {
+		key += ":" + strconv.FormatUint(uint64(*planID), 10)
+	}
+	sum := sha256.Sum256([]byte(key))
+	return Recommendation{
+		ID:                     hex.EncodeToString(sum[:8]),
+		Type:                   kind,
+		Message:                message,
+		SubscriptionUuids:      uuids,
+		PlanID:                 planID,
+		EstimatedYearlySavings: RoundCents(savings),
+	}
+}
+
+// RecommendationDismissal records that a user does not want to see a recommendation again
+type RecommendationDismissal struct {
+	ID               uint   `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UserID           string `gorm:"column:user_id;not null;uniqueIndex:idx_recommendation_dismissals_user_recommendation" json:"userId"`
+	RecommendationID string `gorm:"column:recommendation_id;not null;uniqueIndex:idx_recommendation_dismissals_user_recommendation" json:"recommendationId"`
+
+	CreatedAt time.Time `gorm:"column:created_at;not null;autoCreateTime" json:"createdAt"`
+}
+
+type RecommendationDismissalRepository interface {
+	FindByUserId(userId string) ([]RecommendationDismissal, error)
+	Create(dismissal *RecommendationDismissal) error
+}
@@ -0,0 +1,40 @@
+package domain
+
+import "time"
+
+// PaymentMethodSpend is the amount charged to one payment method over a report period.
+// PaymentMethodID is nil for subscriptions without a payment method.
+type PaymentMethodSpend struct {
+	PaymentMethodID *uint  `json:"paymentMethodId"`
+	Label           string `json:"label"`
+	Renewals        int    `json:"renewals"`
+	// Total is the gross amount charged
+	Total float64 `json:"total"`
+	Net   float64 `json:"net"`
+	Tax   float64 `json:"tax"`
+}
+
+// QuarterTax is the tax charged in one calendar quarter. Reclaimable is the part of
+// Tax charged on business expense subscriptions.
+type QuarterTax struct {
+	Year        int     `json:"year"`
+	Quarter     int     `json:"quarter"`
+	Gross       float64 `json:"gross"`
+	Net         float64 `json:"net"`
+	Tax         float64 `json:"tax"`
+	Reclaimable float64 `json:"reclaimable"`
+}
+
+// SpendReport summarises the renewals charged between From and To
+type SpendReport struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// Total is the gross amount charged, split into NetTotal and TaxTotal
+	Total           float64              `json:"total"`
+	NetTotal        float64              `json:"netTotal"`
+	TaxTotal        float64              `json:"taxTotal"`
+	ReclaimableTax  float64              `json:"reclaimableTax"`
+	ByPaymentMethod []PaymentMethodSpend `json:"byPaymentMethod"`
+	ByQuarter       []QuarterTax         `json:"byQuarter"`
+	Currency        string               `json:"currency,omitempty"`
+}
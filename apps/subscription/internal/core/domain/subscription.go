@@ -1,22 +1,266 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrOneOffPriceChange    = errors.New("one-off subscriptions cannot change price")
+	ErrInvalidPriceSchedule = errors.New("every price step but the last must last at least one cycle")
+)
 
 type Subscription struct {
-	ID           uint      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
-	Uuid         string    `gorm:"column:uuid; not null" json:"uuid"`
-	Name         string    `gorm:"column:name;not null" json:"name"`
-	Price        float64   `gorm:"column:price;not null" json:"price"`
-	BillingCycle int       `gorm:"column:billing_cycle;not null" json:"billingCycle"`
-	StartDate    time.Time `gorm:"column:start_date;not null" json:"startDate"`
-	Logo         string    `gorm:"column:logo" json:"logo"`
-	UserID       string    `gorm:"column:user_id;not null" json:"userId"`
-	IsActive     bool      `gorm:"column:is_active;not null;default:true" json:"isActive"`
+	ID              uint       `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Uuid            string     `gorm:"column:uuid; not null" json:"uuid"`
+	Name            string     `gorm:"column:name;not null" json:"name"`
+	Price           float64    `gorm:"column:price;not null" json:"price"`
+	BillingCycle    int        `gorm:"column:billing_cycle;not null" json:"billingCycle"`
+	StartDate       time.Time  `gorm:"column:start_date;not null" json:"startDate"`
+	Logo            string     `gorm:"column:logo" json:"logo"`
+	UserID          string     `gorm:"column:user_id;not null" json:"userId"`
+	IsActive        bool       `gorm:"column:is_active;not null;default:true" json:"isActive"`
+	TrialEndDate    *time.Time `gorm:"column:trial_end_date" json:"trialEndDate"`
+	CancelAt        *time.Time `gorm:"column:cancel_at" json:"cancelAt"`
+	PaymentMethodID *uint      `gorm:"column:payment_method_id;index" json:"paymentMethodId"`
+	// NoticePeriodDays is how many days before a renewal a cancellation must be submitted
+	NoticePeriodDays int `gorm:"column:notice_period_days;not null;default:0" json:"noticePeriodDays"`
+	// MinimumTermMonths is the minimum contract length counted from StartDate
+	MinimumTermMonths int `gorm:"column:minimum_term_months;not null;default:0" json:"minimumTermMonths"`
+	// TaxRate is the tax percentage applied to the price, e.g. 20 for 20% VAT
+	TaxRate float64 `gorm:"column:tax_rate;not null;default:0" json:"taxRate"`
+	// PriceIncludesTax tells whether prices are gross (personal) or net of tax (business)
+	PriceIncludesTax bool `gorm:"column:price_includes_tax;not null;default:false" json:"priceIncludesTax"`
+	// BusinessExpense marks subscriptions whose tax can be reclaimed
+	BusinessExpense bool `gorm:"column:business_expense;not null;default:false" json:"businessExpense"`
+	// PriceSchedule overrides Price when set, see PriceForCycle
+	PriceSchedule []SubscriptionPriceStep `gorm:"foreignKey:SubscriptionID;constraint:OnDelete:CASCADE" json:"priceSchedule"`
+	// Usage is computed from usage events when listing subscriptions and is not stored
+	Usage *SubscriptionUsage `gorm:"-" json:"usage,omitempty"`
 
 	CreatedAt time.Time `gorm:"column:created_at;not null;autoCreateTime" json:"createdAt"`
 	UpdatedAt time.Time `gorm:"column:updated_at;not null;autoUpdateTime" json:"updatedAt"`
 }
 
+// SubscriptionPriceStep is one step of a subscription's price schedule: Price is charged
+// for Cycles renewals. The last step of a schedule is open-ended and has Cycles set to 0.
+type SubscriptionPriceStep struct {
+	ID             uint    `gorm:"column:id;primaryKey;autoIncrement" json:"-"`
+	SubscriptionID uint    `gorm:"column:subscription_id;not null;index" json:"-"`
+	Position       int     `gorm:"column:position;not null" json:"-"`
+	Price          float64 `gorm:"column:price;not null" json:"price"`
+	Cycles         int     `gorm:"column:cycles;not null" json:"cycles"`
+}
+
+// Renewal is a single charge of a subscription
+type Renewal struct {
+	Date time.Time
+	// Cycle is the number of billing periods between StartDate and Date
+	Cycle int
+	// Price is the listed price, Gross the amount actually charged
+	Price float64
+	TaxBreakdown
+}
+
+// Renewals returns the renewals of the subscription that fall within [from, to).
+// Renewals are counted from StartDate every BillingCycle months; a BillingCycle
+// of 0 is treated as a one-off charge on StartDate. Renewals still inside the
+// trial period and renewals on or after CancelAt are skipped.
+func (s *Subscription) Renewals(from, to time.Time) []Renewal {
+	var renewals []Renewal
+	if !s.IsActive {
+		return renewals
+	}
+	for k := 0; ; k++ {
+		date := AddMonths(s.StartDate, k*s.BillingCycle)
+		if !date.Before(to) || (s.CancelAt != nil && !date.Before(*s.CancelAt)) {
+			break
+		}
+		if !date.Before(from) && !s.InTrial(date) {
+			price := s.PriceForCycle(k)
+			renewals = append(renewals, Renewal{
+				Date:         date,
+				Cycle:        k,
+				Price:        price,
+				TaxBreakdown: SplitTax(price, s.TaxRate, s.PriceIncludesTax),
+			})
+		}
+		if s.BillingCycle <= 0 {
+			break
+		}
+	}
+	return renewals
+}
+
+// Occurrences returns the renewal dates of the subscription that fall within [from, to)
+func (s *Subscription) Occurrences(from, to time.Time) []time.Time {
+	var dates []time.Time
+	for _, renewal := range s.Renewals(from, to) {
+		dates = append(dates, renewal.Date)
+	}
+	return dates
+}
+
+// PriceForCycle returns the price charged for the renewal `cycle` billing periods after
+// StartDate. Trial periods count towards the schedule like any other period.
+func (s *Subscription) PriceForCycle(cycle int) float64 {
+	if len(s.PriceSchedule) == 0 {
+		return s.Price
+	}
+	for _, step := range s.PriceSchedule {
+		if step.Cycles <= 0 || cycle < step.Cycles {
+			return step.Price
+		}
+		cycle -= step.Cycles
+	}
+	return s.PriceSchedule[len(s.PriceSchedule)-1].Price
+}
+
+// SchedulePriceChange makes price apply from the first renewal on or after effective,
+// keeping the schedule before it and dropping any step that followed
+func (s *Subscription) SchedulePriceChange(price float64, effective time.Time) error {
+	if s.BillingCycle <= 0 {
+		return ErrOneOffPriceChange
+	}
+	cycle := 0
+	for AddMonths(s.StartDate, cycle*s.BillingCycle).Before(effective) {
+		cycle++
+	}
+
+	// Run-length encode the prices charged before the change, then append the new open-ended step
+	schedule := make([]SubscriptionPriceStep, 0, len(s.PriceSchedule)+1)
+	for k := 0; k < cycle; k++ {
+		current := s.PriceForCycle(k)
+		if n := len(schedule); n > 0 && schedule[n-1].Price == current {
+			schedule[n-1].Cycles++
+		} else {
+			schedule = append(schedule, SubscriptionPriceStep{Price: current, Cycles: 1})
+		}
+	}
+	schedule = append(schedule, SubscriptionPriceStep{Price: price})
+	s.SetPriceSchedule(schedule)
+	return nil
+}
+
+// SetPriceSchedule replaces the price schedule, numbering the steps and making the last one open-ended
+func (s *Subscription) SetPriceSchedule(schedule []SubscriptionPriceStep) {
+	s.PriceSchedule = make([]SubscriptionPriceStep, len(schedule))
+	for i, step := range schedule {
+		s.PriceSchedule[i] = SubscriptionPriceStep{Position: i, Price: step.Price, Cycles: step.Cycles}
+	}
+	if len(s.PriceSchedule) > 0 {
+		s.PriceSchedule[len(s.PriceSchedule)-1].Cycles = 0
+	}
+}
+
+// ValidatePriceSchedule checks that every step but the open-ended last one lasts at least one cycle
+func (s *Subscription) ValidatePriceSchedule() error {
+	for i, step := range s.PriceSchedule {
+		if i < len(s.PriceSchedule)-1 && step.Cycles < 1 {
+			return ErrInvalidPriceSchedule
+		}
+	}
+	return nil
+}
+
+// NewVersion returns a copy of the subscription that is saved as a new row, keeping its uuid
+func (s *Subscription) NewVersion() *Subscription {
+	version := *s
+	version.ID = 0
+	version.Usage = nil
+	version.CreatedAt = time.Time{}
+	version.UpdatedAt = time.Time{}
+	version.SetPriceSchedule(s.PriceSchedule)
+	return &version
+}
+
+// InTrial reports whether the given date falls before the end of the trial period
+func (s *Subscription) InTrial(date time.Time) bool {
+	return s.TrialEndDate != nil && date.Before(*s.TrialEndDate)
+}
+
+// NextRenewal returns the first renewal on or after the given date, or nil if the
+// subscription does not renew again. Renewals skipped by a trial or a future StartDate do
+// not end the search.
+func (s *Subscription) NextRenewal(after time.Time) *time.Time {
+	if !s.IsActive {
+		return nil
+	}
+	for k := 0; ; k++ {
+		date := AddMonths(s.StartDate, k*s.BillingCycle)
+		if s.CancelAt != nil && !date.Before(*s.CancelAt) {
+			return nil
+		}
+		if !date.Before(after) && !s.InTrial(date) {
+			return &date
+		}
+		if s.BillingCycle <= 0 {
+			return nil
+		}
+	}
+}
+
+// MinimumTermEnd returns the end of the minimum contract term, or nil if the subscription has none
+func (s *Subscription) MinimumTermEnd() *time.Time {
+	if s.MinimumTermMonths <= 0 {
+		return nil
+	}
+	end := AddMonths(s.StartDate, s.MinimumTermMonths)
+	return &end
+}
+
+// CancelBy returns the last date a cancellation can be submitted to avoid being charged for the given renewal
+func (s *Subscription) CancelBy(renewal time.Time) time.Time {
+	return renewal.AddDate(0, 0, -s.NoticePeriodDays)
+}
+
+// EarliestCancellation returns the first renewal, from the given date on, that can still
+// be avoided: its notice deadline has not passed and it does not fall inside the minimum
+// term. A cancellation takes effect on that date. It returns nil if the subscription does
+// not renew again. Renewal dates are calendar days at UTC midnight, so the calendar day of
+// now in its own time zone is what counts as today.
+func (s *Subscription) EarliestCancellation(now time.Time) *time.Time {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	termEnd := s.MinimumTermEnd()
+	for renewal := s.NextRenewal(today); renewal != nil; renewal = s.NextRenewal(renewal.AddDate(0, 0, 1)) {
+		if s.CancelBy(*renewal).Before(today) {
+			continue
+		}
+		if termEnd != nil && renewal.Before(*termEnd) {
+			continue
+		}
+		return renewal
+	}
+	return nil
+}
+
+// CancelByDate returns the last date a cancellation can be submitted to avoid the
+// next renewal that can still be cancelled, or nil if there is nothing to cancel
+func (s *Subscription) CancelByDate(now time.Time) *time.Time {
+	if s.CancelAt != nil {
+		return nil
+	}
+	renewal := s.EarliestCancellation(now)
+	if renewal == nil {
+		return nil
+	}
+	cancelBy := s.CancelBy(*renewal)
+	return &cancelBy
+}
+
+// AddMonths adds n months to t, clamping the day to the end of the target
+// month so that a renewal on the 31st falls on the 30th or 28th/29th instead
+// of spilling into the following month.
+func AddMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
 type SubscriptionRepoQuery struct {
 	StartDateFrom *time.Time
 	StartDateTo   *time.Time
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"errors"
+	"strings"
 	"time"
 )
 
@@ -10,6 +11,7 @@ type SubscriptionConfig struct {
 	ID          uint                     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
 	Provider    string                   `gorm:"column:provider;not null;uniqueIndex" json:"provider" validate:"required"`
 	Description string                   `gorm:"column:description" json:"description"`
+	Category    string                   `gorm:"column:category" json:"category"`
 	Logo        string                   `gorm:"column:logo" json:"logo" validate:"url"`
 	Website     string                   `gorm:"column:website" json:"website" validate:"url"`
 	Status      string                   `gorm:"column:status;default:'active'" json:"status" validate:"oneof=active inactive deprecated"`
@@ -27,6 +29,8 @@ type SubscriptionConfigPlan struct {
 	Price                float64 `gorm:"column:price;not null" json:"price" validate:"required,gte=0"`
 	Currency             string  `gorm:"column:currency;not null" json:"currency" validate:"required,iso4217"`
 	BillingCycle         int32   `gorm:"column:billing_cycle;not null" json:"billing_cycle" validate:"required,gte=1"`
+	TaxRate              float64 `gorm:"column:tax_rate;not null;default:0" json:"tax_rate" validate:"gte=0,lte=100"`
+	PriceIncludesTax     bool    `gorm:"column:price_includes_tax;not null;default:false" json:"price_includes_tax"`
 	Status               string  `gorm:"column:status;default:'active'" json:"status" validate:"oneof=active inactive deprecated"`
 	SubscriptionConfigID uint    `gorm:"column:subscription_config_id;not null" json:"subscription_config_id"`
 
@@ -69,14 +73,39 @@ func (scp *SubscriptionConfigPlan) Validate() error {
 	if scp.BillingCycle < 1 {
 		return errors.New("billing cycle must be positive")
 	}
+	if scp.TaxRate < 0 || scp.TaxRate > 100 {
+		return errors.New("tax rate must be between 0 and 100")
+	}
 	return nil
 }
 
+// PriceBreakdown returns the net, tax and gross price of the plan
+func (scp *SubscriptionConfigPlan) PriceBreakdown() TaxBreakdown {
+	return SplitTax(scp.Price, scp.TaxRate, scp.PriceIncludesTax)
+}
+
 // IsActive checks if the plan is active
 func (scp *SubscriptionConfigPlan) IsActive() bool {
 	return scp.Status == "active"
 }
 
+// MatchSubscriptionConfig returns the catalog entry whose provider name appears in the
+// subscription name, preferring the longest match, or nil if no provider matches
+func MatchSubscriptionConfig(configs []SubscriptionConfig, sub *Subscription) *SubscriptionConfig {
+	name := strings.ToLower(sub.Name)
+	var match *SubscriptionConfig
+	for i := range configs {
+		provider := strings.ToLower(configs[i].Provider)
+		if provider == "" || !strings.Contains(name, provider) {
+			continue
+		}
+		if match == nil || len(provider) > len(match.Provider) {
+			match = &configs[i]
+		}
+	}
+	return match
+}
+
 type SubscriptionConfigRepository interface {
 	Find() (*[]SubscriptionConfig, error)
 	FindByProvider(provider string) (*SubscriptionConfig, error)
@@ -0,0 +1,373 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func equalTimes(a, b *time.Time) bool {
+	return (a == nil) == (b == nil) && (a == nil || a.Equal(*b))
+}
+
+func TestNextRenewal(t *testing.T) {
+	tests := []struct {
+		name  string
+		sub   Subscription
+		after time.Time
+		want  *time.Time
+	}{
+		{
+			name:  "monthly",
+			sub:   Subscription{BillingCycle: 1, StartDate: date(2024, time.January, 15), IsActive: true},
+			after: date(2024, time.March, 16),
+			want:  timePtr(date(2024, time.April, 15)),
+		},
+		{
+			name:  "on a renewal",
+			sub:   Subscription{BillingCycle: 1, StartDate: date(2024, time.January, 15), IsActive: true},
+			after: date(2024, time.March, 15),
+			want:  timePtr(date(2024, time.March, 15)),
+		},
+		{
+			name:  "clamped to the end of the month",
+			sub:   Subscription{BillingCycle: 1, StartDate: date(2024, time.January, 31), IsActive: true},
+			after: date(2024, time.February, 1),
+			want:  timePtr(date(2024, time.February, 29)),
+		},
+		// Regression: a trial longer than a billing cycle used to end the search with no renewal
+		{
+			name: "long trial",
+			sub: Subscription{BillingCycle: 1, StartDate: date(2024, time.January, 1), IsActive: true,
+				TrialEndDate: timePtr(date(2024, time.June, 10))},
+			after: date(2024, time.February, 1),
+			want:  timePtr(date(2024, time.July, 1)),
+		},
+		// Regression: a start date more than a cycle away used to end the search with no renewal
+		{
+			name:  "future start date",
+			sub:   Subscription{BillingCycle: 1, StartDate: date(2024, time.September, 1), IsActive: true},
+			after: date(2024, time.February, 1),
+			want:  timePtr(date(2024, time.September, 1)),
+		},
+		{
+			name:  "yearly",
+			sub:   Subscription{BillingCycle: 12, StartDate: date(2022, time.May, 3), IsActive: true},
+			after: date(2024, time.May, 4),
+			want:  timePtr(date(2025, time.May, 3)),
+		},
+		{
+			name: "cancelled before the renewal",
+			sub: Subscription{BillingCycle: 1, StartDate: date(2024, time.January, 15), IsActive: true,
+				CancelAt: timePtr(date(2024, time.April, 15))},
+			after: date(2024, time.March, 16),
+		},
+		{
+			name:  "one-off in the past",
+			sub:   Subscription{BillingCycle: 0, StartDate: date(2024, time.January, 15), IsActive: true},
+			after: date(2024, time.March, 16),
+		},
+		{
+			name:  "one-off in the future",
+			sub:   Subscription{BillingCycle: 0, StartDate: date(2024, time.April, 1), IsActive: true},
+			after: date(2024, time.March, 16),
+			want:  timePtr(date(2024, time.April, 1)),
+		},
+		{
+			name:  "inactive",
+			sub:   Subscription{BillingCycle: 1, StartDate: date(2024, time.January, 15)},
+			after: date(2024, time.March, 16),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sub.NextRenewal(tt.after); !equalTimes(got, tt.want) {
+				t.Errorf("NextRenewal = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinimumTermEnd(t *testing.T) {
+	tests := []struct {
+		name string
+		sub  Subscription
+		want *time.Time
+	}{
+		{name: "no minimum term", sub: Subscription{StartDate: date(2024, time.January, 15)}},
+		{name: "twelve months", sub: Subscription{StartDate: date(2024, time.January, 15), MinimumTermMonths: 12}, want: timePtr(date(2025, time.January, 15))},
+		{name: "clamped to the end of the month", sub: Subscription{StartDate: date(2023, time.August, 31), MinimumTermMonths: 6}, want: timePtr(date(2024, time.February, 29))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sub.MinimumTermEnd(); !equalTimes(got, tt.want) {
+				t.Errorf("MinimumTermEnd = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEarliestCancellationAndCancelByDate(t *testing.T) {
+	// Renews on the 20th of every month
+	monthly := func(noticeDays, termMonths int) Subscription {
+		return Subscription{BillingCycle: 1, StartDate: date(2023, time.March, 20), IsActive: true,
+			NoticePeriodDays: noticeDays, MinimumTermMonths: termMonths}
+	}
+
+	tests := []struct {
+		name         string
+		sub          Subscription
+		now          time.Time
+		wantEarliest *time.Time
+		wantCancelBy *time.Time
+	}{
+		{
+			name:         "no notice period",
+			sub:          monthly(0, 0),
+			now:          date(2024, time.March, 10),
+			wantEarliest: timePtr(date(2024, time.March, 20)),
+			wantCancelBy: timePtr(date(2024, time.March, 20)),
+		},
+		{
+			name:         "notice still due",
+			sub:          monthly(10, 0),
+			now:          date(2024, time.March, 10),
+			wantEarliest: timePtr(date(2024, time.March, 20)),
+			wantCancelBy: timePtr(date(2024, time.March, 10)),
+		},
+		{
+			name:         "time of day on the deadline",
+			sub:          monthly(10, 0),
+			now:          date(2024, time.March, 10).Add(23 * time.Hour),
+			wantEarliest: timePtr(date(2024, time.March, 20)),
+			wantCancelBy: timePtr(date(2024, time.March, 10)),
+		},
+		{
+			// 20:00 on the 10th in UTC-8 is already the 11th in UTC
+			name:         "deadline day behind UTC",
+			sub:          monthly(10, 0),
+			now:          time.Date(2024, time.March, 10, 20, 0, 0, 0, time.FixedZone("UTC-8", -8*60*60)),
+			wantEarliest: timePtr(date(2024, time.March, 20)),
+			wantCancelBy: timePtr(date(2024, time.March, 10)),
+		},
+		{
+			// 01:00 on the 11th in UTC+9 is still the 10th in UTC
+			name:         "day after the deadline ahead of UTC",
+			sub:          monthly(10, 0),
+			now:          time.Date(2024, time.March, 11, 1, 0, 0, 0, time.FixedZone("UTC+9", 9*60*60)),
+			wantEarliest: timePtr(date(2024, time.April, 20)),
+			wantCancelBy: timePtr(date(2024, time.April, 10)),
+		},
+		{
+			name:         "notice passed",
+			sub:          monthly(10, 0),
+			now:          date(2024, time.March, 11),
+			wantEarliest: timePtr(date(2024, time.April, 20)),
+			wantCancelBy: timePtr(date(2024, time.April, 10)),
+		},
+		{
+			name:         "inside the minimum term",
+			sub:          monthly(30, 24),
+			now:          date(2024, time.March, 1),
+			wantEarliest: timePtr(date(2025, time.March, 20)),
+			wantCancelBy: timePtr(date(2025, time.February, 18)),
+		},
+		{
+			name:         "renewal at the end of the minimum term",
+			sub:          monthly(0, 12),
+			now:          date(2024, time.March, 1),
+			wantEarliest: timePtr(date(2024, time.March, 20)),
+			wantCancelBy: timePtr(date(2024, time.March, 20)),
+		},
+		{
+			name: "no further renewal",
+			sub: Subscription{BillingCycle: 0, StartDate: date(2024, time.January, 15), IsActive: true,
+				NoticePeriodDays: 10},
+			now: date(2024, time.March, 1),
+		},
+		{
+			name: "already cancelled",
+			sub: Subscription{BillingCycle: 1, StartDate: date(2023, time.March, 20), IsActive: true,
+				NoticePeriodDays: 10, CancelAt: timePtr(date(2024, time.June, 20))},
+			now:          date(2024, time.March, 1),
+			wantEarliest: timePtr(date(2024, time.March, 20)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sub.EarliestCancellation(tt.now); !equalTimes(got, tt.wantEarliest) {
+				t.Errorf("EarliestCancellation = %v, want %v", got, tt.wantEarliest)
+			}
+			if got := tt.sub.CancelByDate(tt.now); !equalTimes(got, tt.wantCancelBy) {
+				t.Errorf("CancelByDate = %v, want %v", got, tt.wantCancelBy)
+			}
+		})
+	}
+}
+
+func TestPriceForCycle(t *testing.T) {
+	// Three discounted months, then nine months at 12, then 15 from the second year on
+	schedule := []SubscriptionPriceStep{{Price: 5, Cycles: 3}, {Price: 12, Cycles: 9}, {Price: 15}}
+
+	tests := []struct {
+		name     string
+		price    float64
+		schedule []SubscriptionPriceStep
+		cycle    int
+		want     float64
+	}{
+		{name: "no schedule", price: 9.99, cycle: 7, want: 9.99},
+		{name: "first cycle", price: 9.99, schedule: schedule, cycle: 0, want: 5},
+		{name: "last cycle of a step", price: 9.99, schedule: schedule, cycle: 2, want: 5},
+		{name: "first cycle of the next step", price: 9.99, schedule: schedule, cycle: 3, want: 12},
+		{name: "open-ended step", price: 9.99, schedule: schedule, cycle: 12, want: 15},
+		{name: "long after the schedule", price: 9.99, schedule: schedule, cycle: 120, want: 15},
+		// A schedule whose last step is not open-ended keeps charging the last price
+		{name: "bounded last step", price: 9.99, schedule: []SubscriptionPriceStep{{Price: 5, Cycles: 3}, {Price: 12, Cycles: 2}}, cycle: 8, want: 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := Subscription{Price: tt.price, PriceSchedule: tt.schedule}
+			if got := sub.PriceForCycle(tt.cycle); got != tt.want {
+				t.Errorf("PriceForCycle(%d) = %v, want %v", tt.cycle, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenewalsFollowPriceSchedule(t *testing.T) {
+	sub := Subscription{BillingCycle: 1, StartDate: date(2024, time.January, 10), IsActive: true, Price: 9.99,
+		PriceSchedule: []SubscriptionPriceStep{{Price: 5, Cycles: 2}, {Price: 12}},
+		// The trial skips the first renewal but still counts towards the schedule
+		TrialEndDate: timePtr(date(2024, time.January, 20))}
+
+	var prices []float64
+	for _, renewal := range sub.Renewals(date(2024, time.January, 1), date(2024, time.May, 1)) {
+		prices = append(prices, renewal.Price)
+	}
+	if want := []float64{5, 12, 12}; !reflect.DeepEqual(prices, want) {
+		t.Errorf("renewal prices = %v, want %v", prices, want)
+	}
+}
+
+func TestSetPriceSchedule(t *testing.T) {
+	sub := Subscription{ID: 7, PriceSchedule: []SubscriptionPriceStep{{ID: 1, SubscriptionID: 7, Price: 1}}}
+	sub.SetPriceSchedule([]SubscriptionPriceStep{
+		{ID: 3, SubscriptionID: 7, Position: 5, Price: 5, Cycles: 3},
+		{Price: 12, Cycles: 9},
+		{Price: 15, Cycles: 4},
+	})
+
+	want := []SubscriptionPriceStep{
+		{Position: 0, Price: 5, Cycles: 3},
+		{Position: 1, Price: 12, Cycles: 9},
+		{Position: 2, Price: 15, Cycles: 0},
+	}
+	if !reflect.DeepEqual(sub.PriceSchedule, want) {
+		t.Errorf("schedule = %+v, want %+v", sub.PriceSchedule, want)
+	}
+
+	sub.SetPriceSchedule(nil)
+	if len(sub.PriceSchedule) != 0 {
+		t.Errorf("schedule = %+v, want it cleared", sub.PriceSchedule)
+	}
+}
+
+func TestValidatePriceSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule []SubscriptionPriceStep
+		wantErr  bool
+	}{
+		{name: "no schedule"},
+		{name: "single open-ended step", schedule: []SubscriptionPriceStep{{Price: 15}}},
+		{name: "steps then open-ended", schedule: []SubscriptionPriceStep{{Price: 5, Cycles: 3}, {Price: 12, Cycles: 1}, {Price: 15}}},
+		{name: "empty step", schedule: []SubscriptionPriceStep{{Price: 5, Cycles: 0}, {Price: 15}}, wantErr: true},
+		{name: "negative step", schedule: []SubscriptionPriceStep{{Price: 5, Cycles: 3}, {Price: 12, Cycles: -1}, {Price: 15}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := Subscription{PriceSchedule: tt.schedule}
+			err := sub.ValidatePriceSchedule()
+			if tt.wantErr && !errors.Is(err, ErrInvalidPriceSchedule) {
+				t.Errorf("error = %v, want ErrInvalidPriceSchedule", err)
+			} else if !tt.wantErr && err != nil {
+				t.Errorf("ValidatePriceSchedule: %v", err)
+			}
+		})
+	}
+}
+
+func TestSchedulePriceChange(t *testing.T) {
+	tests := []struct {
+		name      string
+		sub       Subscription
+		effective time.Time
+		want      []SubscriptionPriceStep
+		wantErr   error
+	}{
+		{
+			name:      "from the next renewal",
+			sub:       Subscription{BillingCycle: 1, StartDate: date(2024, time.January, 10), Price: 9.99},
+			effective: date(2024, time.March, 11),
+			want:      []SubscriptionPriceStep{{Position: 0, Price: 9.99, Cycles: 3}, {Position: 1, Price: 12.99}},
+		},
+		{
+			name:      "on a renewal",
+			sub:       Subscription{BillingCycle: 1, StartDate: date(2024, time.January, 10), Price: 9.99},
+			effective: date(2024, time.March, 10),
+			want:      []SubscriptionPriceStep{{Position: 0, Price: 9.99, Cycles: 2}, {Position: 1, Price: 12.99}},
+		},
+		{
+			name: "keeps the steps before and drops the ones after",
+			sub: Subscription{BillingCycle: 1, StartDate: date(2024, time.January, 10), Price: 9.99,
+				PriceSchedule: []SubscriptionPriceStep{{Price: 5, Cycles: 2}, {Price: 8, Cycles: 4}, {Price: 10}}},
+			effective: date(2024, time.April, 1),
+			want:      []SubscriptionPriceStep{{Position: 0, Price: 5, Cycles: 2}, {Position: 1, Price: 8, Cycles: 1}, {Position: 2, Price: 12.99}},
+		},
+		{
+			name:      "before the start",
+			sub:       Subscription{BillingCycle: 1, StartDate: date(2024, time.January, 10), Price: 9.99},
+			effective: date(2023, time.December, 1),
+			want:      []SubscriptionPriceStep{{Position: 0, Price: 12.99}},
+		},
+		{
+			name:      "one-off",
+			sub:       Subscription{BillingCycle: 0, StartDate: date(2024, time.January, 10), Price: 9.99},
+			effective: date(2024, time.March, 1),
+			wantErr:   ErrOneOffPriceChange,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.sub.SchedulePriceChange(12.99, tt.effective)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SchedulePriceChange: %v", err)
+			}
+			if !reflect.DeepEqual(tt.sub.PriceSchedule, tt.want) {
+				t.Errorf("schedule = %+v, want %+v", tt.sub.PriceSchedule, tt.want)
+			}
+		})
+	}
+}
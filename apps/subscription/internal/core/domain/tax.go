@@ -0,0 +1,26 @@
+package domain
+
+import "math"
+
+// TaxBreakdown splits a charged amount into its net, tax and gross parts
+type TaxBreakdown struct {
+	Net   float64 `json:"net"`
+	Tax   float64 `json:"tax"`
+	Gross float64 `json:"gross"`
+}
+
+// SplitTax computes the tax breakdown of price at the given tax rate, a percentage.
+// includesTax tells whether price is the gross (tax inclusive) or net amount.
+func SplitTax(price, rate float64, includesTax bool) TaxBreakdown {
+	if includesTax {
+		net := RoundCents(price / (1 + rate/100))
+		return TaxBreakdown{Net: net, Tax: RoundCents(price - net), Gross: price}
+	}
+	tax := RoundCents(price * rate / 100)
+	return TaxBreakdown{Net: price, Tax: tax, Gross: RoundCents(price + tax)}
+}
+
+// RoundCents rounds an amount to whole cents
+func RoundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
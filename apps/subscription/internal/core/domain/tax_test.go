@@ -0,0 +1,50 @@
+package domain
+
+import "testing"
+
+func TestSplitTax(t *testing.T) {
+	tests := []struct {
+		name        string
+		price       float64
+		rate        float64
+		includesTax bool
+		want        TaxBreakdown
+	}{
+		{name: "no tax", price: 9.99, want: TaxBreakdown{Net: 9.99, Gross: 9.99}},
+		{name: "no tax included", price: 9.99, includesTax: true, want: TaxBreakdown{Net: 9.99, Gross: 9.99}},
+		{name: "gross price", price: 12, rate: 20, includesTax: true, want: TaxBreakdown{Net: 10, Tax: 2, Gross: 12}},
+		{name: "net price", price: 10, rate: 20, want: TaxBreakdown{Net: 10, Tax: 2, Gross: 12}},
+		// The net amount is rounded and the tax makes up the rest, so net and tax add up to the price
+		{name: "gross price rounded", price: 9.99, rate: 19, includesTax: true, want: TaxBreakdown{Net: 8.39, Tax: 1.6, Gross: 9.99}},
+		{name: "net price rounded", price: 9.99, rate: 19, want: TaxBreakdown{Net: 9.99, Tax: 1.9, Gross: 11.89}},
+		{name: "fractional rate", price: 100, rate: 7.7, want: TaxBreakdown{Net: 100, Tax: 7.7, Gross: 107.7}},
+		{name: "half a cent", price: 0.5, rate: 5, want: TaxBreakdown{Net: 0.5, Tax: 0.03, Gross: 0.53}},
+		{name: "free", price: 0, rate: 20, includesTax: true, want: TaxBreakdown{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitTax(tt.price, tt.rate, tt.includesTax); got != tt.want {
+				t.Errorf("SplitTax(%v, %v, %v) = %+v, want %+v", tt.price, tt.rate, tt.includesTax, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundCents(t *testing.T) {
+	tests := []struct {
+		amount float64
+		want   float64
+	}{
+		{amount: 1.004, want: 1},
+		{amount: 1.005000001, want: 1.01},
+		{amount: 0.1 + 0.2, want: 0.3},
+		{amount: -2.345, want: -2.35},
+	}
+
+	for _, tt := range tests {
+		if got := RoundCents(tt.amount); got != tt.want {
+			t.Errorf("RoundCents(%v) = %v, want %v", tt.amount, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package domain
+
+import "time"
+
+// UsageEvent records that a subscription was used, by the user or by an integration
+type UsageEvent struct {
+	ID               uint      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	SubscriptionUuid string    `gorm:"column:subscription_uuid;not null;index:idx_usage_events_subscription_occurred" json:"subscriptionUuid"`
+	UserID           string    `gorm:"column:user_id;not null;index" json:"userId"`
+	OccurredAt       time.Time `gorm:"column:occurred_at;not null;index:idx_usage_events_subscription_occurred" json:"occurredAt"`
+	Quantity         int       `gorm:"column:quantity;not null;default:1" json:"quantity"`
+	Source           string    `gorm:"column:source" json:"source"`
+
+	CreatedAt time.Time `gorm:"column:created_at;not null;autoCreateTime" json:"createdAt"`
+}
+
+// UsageSummary aggregates the usage events of one subscription
+type UsageSummary struct {
+	SubscriptionUuid string
+	// Uses is the total quantity used since the start of the summary window
+	Uses       int
+	LastUsedAt *time.Time
+}
+
+// SubscriptionUsage describes how much a subscription was used over the last WindowDays days.
+// CostPerUse is nil when the subscription was not used in the window.
+type SubscriptionUsage struct {
+	WindowDays int        `json:"windowDays"`
+	Uses       int        `json:"uses"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	Cost       float64    `json:"cost"`
+	CostPerUse *float64   `json:"costPerUse"`
+	Unused     bool       `json:"unused"`
+}
+
+type UsageEventRepository interface {
+	Create(event *UsageEvent) error
+	FindByUserId(userId string) ([]UsageEvent, error)
+	// Summaries returns the usage of every subscription of the user that has usage events,
+	// counting uses since the given time, keyed by subscription uuid
+	Summaries(userId string, since time.Time) (map[string]UsageSummary, error)
+}
+
+// UsageDigest lists the subscriptions that went unused and the cancellation deadlines
+// coming up
+type UsageDigest struct {
+	GeneratedAt            time.Time              `json:"generatedAt"`
+	UnusedDays             int                    `json:"unusedDays"`
+	Unused                 []Subscription         `json:"unused"`
+	PotentialYearlySavings float64                `json:"potentialYearlySavings"`
+	CancelBy               []CancellationDeadline `json:"cancelBy"`
+}
+
+// CancellationDeadline is the last date a cancellation can be submitted to avoid Renewal
+type CancellationDeadline struct {
+	SubscriptionUuid string    `json:"subscriptionUuid"`
+	Name             string    `json:"name"`
+	CancelBy         time.Time `json:"cancelBy"`
+	Renewal          time.Time `json:"renewal"`
+	Unused           bool      `json:"unused"`
+}
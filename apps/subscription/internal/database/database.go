@@ -2,7 +2,6 @@ package database
 
 import (
 	"log"
-	"os"
 
 	"github.com/subscription-tracker/subscription/internal/core/domain"
 	postgres "gorm.io/driver/postgres"
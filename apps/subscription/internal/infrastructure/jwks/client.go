@@ -0,0 +1,134 @@
+package jwks
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// cacheTTL is how long fetched keys are trusted before the JWKS is fetched again
+	cacheTTL = time.Hour
+	// minRefreshInterval bounds refetches caused by tokens with unknown key IDs
+	minRefreshInterval = 30 * time.Second
+)
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+}
+
+// Client fetches and caches the public keys the user service signs tokens with
+type Client struct {
+	url        string
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+func NewClient(url string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Client{url: url, httpClient: httpClient}
+}
+
+// Key returns the public key with the given ID. The JWKS is refetched when the cache is
+// stale or the key is unknown, so rotated keys are picked up without a restart.
+func (c *Client) Key(ctx context.Context, kid string) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key, ok := c.keys[kid]
+	stale := time.Since(c.fetchedAt) > cacheTTL
+	if ok && !stale {
+		return key, nil
+	}
+	if stale || time.Since(c.fetchedAt) > minRefreshInterval {
+		if err := c.refresh(ctx); err != nil {
+			if ok {
+				// Keep verifying with the cached key while the user service is unreachable
+				return key, nil
+			}
+			return nil, err
+		}
+	}
+	if key, ok := c.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (c *Client) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching jwks failed with status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return err
+	}
+	keys := make(map[string]interface{})
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if key, err := parseJWK(k); err == nil {
+			keys[k.Kid] = key
+		}
+	}
+	c.keys = keys
+	c.fetchedAt = time.Now()
+	return nil
+}
+
+func parseJWK(k jwk) (interface{}, error) {
+	switch {
+	case k.Kty == "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case k.Kty == "OKP" && k.Crv == "Ed25519":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
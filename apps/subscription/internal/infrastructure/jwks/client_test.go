@@ -0,0 +1,209 @@
+package jwks
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// jwksServer serves a mutable key set and counts the fetches
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	keys    []jwk
+	status  int
+	fetches int
+}
+
+func newJWKSServer(t *testing.T, keys ...jwk) *jwksServer {
+	s := &jwksServer{keys: keys, status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.fetches++
+		if s.status != http.StatusOK {
+			w.WriteHeader(s.status)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string][]jwk{"keys": s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) set(status int, keys ...jwk) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+	s.keys = keys
+}
+
+func (s *jwksServer) fetchCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetches
+}
+
+func ed25519JWK(t *testing.T, kid string) (jwk, ed25519.PublicKey) {
+	t.Helper()
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jwk{Kty: "OKP", Kid: kid, Use: "sig", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(public)}, public
+}
+
+// age moves the last fetch back in time
+func (c *Client) age(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fetchedAt = c.fetchedAt.Add(-d)
+}
+
+func TestKeyCachesFetchedKeys(t *testing.T) {
+	k, public := ed25519JWK(t, "key-1")
+	server := newJWKSServer(t, k)
+	client := NewClient(server.URL, nil)
+
+	for i := 0; i < 3; i++ {
+		key, err := client.Key(context.Background(), "key-1")
+		if err != nil {
+			t.Fatalf("Key: %v", err)
+		}
+		if !public.Equal(key) {
+			t.Fatalf("key = %v, want the published key", key)
+		}
+	}
+	if n := server.fetchCount(); n != 1 {
+		t.Errorf("fetched the JWKS %d times, want once", n)
+	}
+}
+
+func TestKeyUnknownKidIsRateLimited(t *testing.T) {
+	k1, _ := ed25519JWK(t, "key-1")
+	server := newJWKSServer(t, k1)
+	client := NewClient(server.URL, nil)
+	if _, err := client.Key(context.Background(), "key-1"); err != nil {
+		t.Fatalf("Key: %v", err)
+	}
+
+	// Tokens with made up key IDs must not make every request fetch the JWKS
+	for i := 0; i < 5; i++ {
+		if _, err := client.Key(context.Background(), "made-up"); err == nil {
+			t.Fatal("Key returned a key for an unknown key ID")
+		}
+	}
+	if n := server.fetchCount(); n != 1 {
+		t.Errorf("fetched the JWKS %d times within the refresh interval, want once", n)
+	}
+
+	// Once the interval has passed, a rotated key is picked up
+	k2, public2 := ed25519JWK(t, "key-2")
+	server.set(http.StatusOK, k1, k2)
+	if _, err := client.Key(context.Background(), "key-2"); err == nil {
+		t.Fatal("Key refetched the JWKS within the refresh interval")
+	}
+	client.age(minRefreshInterval + time.Second)
+	key, err := client.Key(context.Background(), "key-2")
+	if err != nil {
+		t.Fatalf("Key after the refresh interval: %v", err)
+	}
+	if !public2.Equal(key) {
+		t.Errorf("key = %v, want the rotated key", key)
+	}
+	if n := server.fetchCount(); n != 2 {
+		t.Errorf("fetched the JWKS %d times, want twice", n)
+	}
+}
+
+func TestKeyRefreshesStaleCache(t *testing.T) {
+	k1, public1 := ed25519JWK(t, "key-1")
+	server := newJWKSServer(t, k1)
+	client := NewClient(server.URL, nil)
+	if _, err := client.Key(context.Background(), "key-1"); err != nil {
+		t.Fatalf("Key: %v", err)
+	}
+
+	// A retired key disappears from the JWKS once the cache goes stale
+	k2, _ := ed25519JWK(t, "key-2")
+	server.set(http.StatusOK, k2)
+	client.age(cacheTTL + time.Second)
+	if _, err := client.Key(context.Background(), "key-1"); err == nil {
+		t.Error("Key still returned a key removed from the JWKS")
+	}
+	if _, err := client.Key(context.Background(), "key-2"); err != nil {
+		t.Errorf("Key: %v", err)
+	}
+
+	// While the user service is down, stale keys keep verifying but unknown ones fail
+	server.set(http.StatusOK, k1)
+	client.age(cacheTTL + time.Second)
+	if _, err := client.Key(context.Background(), "key-1"); err != nil {
+		t.Fatalf("Key: %v", err)
+	}
+	server.set(http.StatusServiceUnavailable)
+	client.age(cacheTTL + time.Second)
+	key, err := client.Key(context.Background(), "key-1")
+	if err != nil {
+		t.Fatalf("Key with the user service down: %v", err)
+	}
+	if !public1.Equal(key) {
+		t.Errorf("key = %v, want the cached key", key)
+	}
+	if _, err := client.Key(context.Background(), "key-3"); err == nil {
+		t.Error("Key returned a key for an unknown key ID with the user service down")
+	}
+}
+
+func TestKeyFetchFailure(t *testing.T) {
+	server := newJWKSServer(t)
+	server.set(http.StatusInternalServerError)
+	if _, err := NewClient(server.URL, nil).Key(context.Background(), "key-1"); err == nil {
+		t.Error("Key succeeded without a JWKS")
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey := jwk{
+		Kty: "RSA", Kid: "rsa", Use: "sig",
+		N: base64.RawURLEncoding.EncodeToString(private.N.Bytes()),
+		E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(private.E)).Bytes()),
+	}
+	edKey, edPublic := ed25519JWK(t, "ed")
+	encryption, _ := ed25519JWK(t, "enc")
+	encryption.Use = "enc"
+	short := jwk{Kty: "OKP", Kid: "short", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString([]byte("too short"))}
+	unsupported := jwk{Kty: "EC", Kid: "ec", Crv: "P-256"}
+
+	server := newJWKSServer(t, rsaKey, edKey, encryption, short, unsupported)
+	client := NewClient(server.URL, nil)
+
+	key, err := client.Key(context.Background(), "rsa")
+	if err != nil {
+		t.Fatalf("RSA key: %v", err)
+	}
+	if !private.PublicKey.Equal(key) {
+		t.Errorf("RSA key = %v, want the published key", key)
+	}
+	if key, err := client.Key(context.Background(), "ed"); err != nil || !edPublic.Equal(key) {
+		t.Errorf("Ed25519 key = %v, %v, want the published key", key, err)
+	}
+	for _, kid := range []string{"enc", "short", "ec"} {
+		if _, err := client.Key(context.Background(), kid); err == nil {
+			t.Errorf("key %q was accepted", kid)
+		}
+	}
+}
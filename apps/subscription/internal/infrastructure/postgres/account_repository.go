@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"fmt"
+
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+	"gorm.io/gorm"
+)
+
+type AccountRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountRepository(db *gorm.DB) *AccountRepository {
+	return &AccountRepository{db: db}
+}
+
+func (r *AccountRepository) PurgeUser(userId string) ([]string, error) {
+	var storageKeys []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.Attachment{}).Where("user_id = ?", userId).Pluck("storage_key", &storageKeys).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userId).Delete(&domain.Attachment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userId).Delete(&domain.UsageEvent{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userId).Delete(&domain.RecommendationDismissal{}).Error; err != nil {
+			return err
+		}
+		subscriptionIds := tx.Model(&domain.Subscription{}).Select("id").Where("user_id = ?", userId)
+		if err := tx.Where("subscription_id IN (?)", subscriptionIds).Delete(&domain.SubscriptionPriceStep{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userId).Delete(&domain.Subscription{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userId).Delete(&domain.PaymentMethod{}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge user: %w", err)
+	}
+	return storageKeys, nil
+}
@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"fmt"
+
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+	"gorm.io/gorm"
+)
+
+type AttachmentRepository struct {
+	db *gorm.DB
+}
+
+func NewAttachmentRepository(db *gorm.DB) *AttachmentRepository {
+	db.AutoMigrate(&domain.Attachment{})
+	return &AttachmentRepository{db: db}
+}
+
+func (r *AttachmentRepository) FindBySubscriptionUuid(subscriptionUuid string) ([]domain.Attachment, error) {
+	var attachments []domain.Attachment
+	result := r.db.Order("created_at DESC").Find(&attachments, "subscription_uuid = ?", subscriptionUuid)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to fetch attachments: %w", result.Error)
+	}
+	return attachments, nil
+}
+
+func (r *AttachmentRepository) FindByUserId(userId string) ([]domain.Attachment, error) {
+	var attachments []domain.Attachment
+	result := r.db.Order("created_at").Find(&attachments, "user_id = ?", userId)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to fetch attachments: %w", result.Error)
+	}
+	return attachments, nil
+}
+
+func (r *AttachmentRepository) FindByUuid(uuid string) (*domain.Attachment, error) {
+	var attachment domain.Attachment
+	result := r.db.First(&attachment, "uuid = ?", uuid)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to fetch attachment: %w", result.Error)
+	}
+	return &attachment, nil
+}
+
+func (r *AttachmentRepository) Create(attachment *domain.Attachment) error {
+	if result := r.db.Create(attachment); result.Error != nil {
+		return fmt.Errorf("failed to create attachment: %w", result.Error)
+	}
+	return nil
+}
+
+func (r *AttachmentRepository) Delete(uuid string) error {
+	if result := r.db.Delete(&domain.Attachment{}, "uuid = ?", uuid); result.Error != nil {
+		return fmt.Errorf("failed to delete attachment: %w", result.Error)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"fmt"
+
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+	"gorm.io/gorm"
+)
+
+type PaymentMethodRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentMethodRepository(db *gorm.DB) *PaymentMethodRepository {
+	db.AutoMigrate(&domain.PaymentMethod{})
+	return &PaymentMethodRepository{db: db}
+}
+
+func (r *PaymentMethodRepository) FindByUserId(userId string) ([]domain.PaymentMethod, error) {
+	var paymentMethods []domain.PaymentMethod
+	result := r.db.Order("label").Find(&paymentMethods, "user_id = ?", userId)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to fetch payment methods: %w", result.Error)
+	}
+	return paymentMethods, nil
+}
+
+func (r *PaymentMethodRepository) FindByID(id uint) (*domain.PaymentMethod, error) {
+	var paymentMethod domain.PaymentMethod
+	result := r.db.First(&paymentMethod, "id = ?", id)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to fetch payment method: %w", result.Error)
+	}
+	return &paymentMethod, nil
+}
+
+func (r *PaymentMethodRepository) Create(paymentMethod *domain.PaymentMethod) error {
+	if err := paymentMethod.Validate(); err != nil {
+		return err
+	}
+	if result := r.db.Create(paymentMethod); result.Error != nil {
+		return fmt.Errorf("failed to create payment method: %w", result.Error)
+	}
+	return nil
+}
+
+func (r *PaymentMethodRepository) Update(paymentMethod *domain.PaymentMethod) error {
+	if err := paymentMethod.Validate(); err != nil {
+		return err
+	}
+	if result := r.db.Save(paymentMethod); result.Error != nil {
+		return fmt.Errorf("failed to update payment method: %w", result.Error)
+	}
+	return nil
+}
+
+// Delete removes the payment method and detaches it from every subscription charged to it
+func (r *PaymentMethodRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.Subscription{}).Where("payment_method_id = ?", id).Update("payment_method_id", nil).Error; err != nil {
+			return fmt.Errorf("failed to detach payment method: %w", err)
+		}
+		if err := tx.Delete(&domain.PaymentMethod{}, id).Error; err != nil {
+			return fmt.Errorf("failed to delete payment method: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"fmt"
+
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RecommendationDismissalRepository struct {
+	db *gorm.DB
+}
+
+func NewRecommendationDismissalRepository(db *gorm.DB) *RecommendationDismissalRepository {
+	db.AutoMigrate(&domain.RecommendationDismissal{})
+	return &RecommendationDismissalRepository{db: db}
+}
+
+func (r *RecommendationDismissalRepository) FindByUserId(userId string) ([]domain.RecommendationDismissal, error) {
+	var dismissals []domain.RecommendationDismissal
+	result := r.db.Find(&dismissals, "user_id = ?", userId)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to fetch recommendation dismissals: %w", result.Error)
+	}
+	return dismissals, nil
+}
+
+// Create records the dismissal; dismissing the same recommendation twice is a no-op
+func (r *RecommendationDismissalRepository) Create(dismissal *domain.RecommendationDismissal) error {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(dismissal)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create recommendation dismissal: %w", result.Error)
+	}
+	return nil
+}
@@ -12,17 +12,24 @@ type SubscriptionRepository struct {
 }
 
 func NewSubscriptionRepository(db *gorm.DB) *SubscriptionRepository {
-	db.AutoMigrate(&domain.Subscription{})
+	db.AutoMigrate(&domain.Subscription{}, &domain.SubscriptionPriceStep{})
 	return &SubscriptionRepository{db: db}
 }
 
+// withPriceSchedule preloads the price schedule of the subscriptions in step order
+func withPriceSchedule(db *gorm.DB) *gorm.DB {
+	return db.Preload("PriceSchedule", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	})
+}
+
 func (r *SubscriptionRepository) FindByUserId(userId string) ([]domain.Subscription, error) {
 	if r.db == nil {
 		return nil, fmt.Errorf("database connection is not initialized")
 	}
 
 	var subscriptions []domain.Subscription
-	result := r.db.Find(&subscriptions, "user_id = ?", userId)
+	result := withPriceSchedule(r.db).Find(&subscriptions, "user_id = ?", userId)
 	if result.Error != nil {
 		return nil, fmt.Errorf("failed to fetch subscriptions: %w", result.Error)
 	}
@@ -31,7 +38,7 @@ func (r *SubscriptionRepository) FindByUserId(userId string) ([]domain.Subscript
 }
 
 func (r *SubscriptionRepository) Find(query *domain.SubscriptionRepoQuery, order *string) ([]domain.Subscription, error) {
-	db := r.db
+	db := withPriceSchedule(r.db)
 
 	if query != nil {
 		if query.StartDateFrom != nil {
@@ -75,7 +82,7 @@ func (r *SubscriptionRepository) Delete(id string) error {
 
 func (r *SubscriptionRepository) FindByUuid(uuid string) (*domain.Subscription, error) {
 	var subscription domain.Subscription
-	result := r.db.First(&subscription, "uuid = ?", uuid)
+	result := withPriceSchedule(r.db).First(&subscription, "uuid = ?", uuid)
 	if result.Error != nil {
 		return nil, fmt.Errorf("failed to fetch subscription: %w", result.Error)
 	}
@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+	"gorm.io/gorm"
+)
+
+type UsageEventRepository struct {
+	db *gorm.DB
+}
+
+func NewUsageEventRepository(db *gorm.DB) *UsageEventRepository {
+	db.AutoMigrate(&domain.UsageEvent{})
+	return &UsageEventRepository{db: db}
+}
+
+func (r *UsageEventRepository) Create(event *domain.UsageEvent) error {
+	if result := r.db.Create(event); result.Error != nil {
+		return fmt.Errorf("failed to create usage event: %w", result.Error)
+	}
+	return nil
+}
+
+func (r *UsageEventRepository) FindByUserId(userId string) ([]domain.UsageEvent, error) {
+	var events []domain.UsageEvent
+	result := r.db.Order("occurred_at").Find(&events, "user_id = ?", userId)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to fetch usage events: %w", result.Error)
+	}
+	return events, nil
+}
+
+func (r *UsageEventRepository) Summaries(userId string, since time.Time) (map[string]domain.UsageSummary, error) {
+	var rows []domain.UsageSummary
+	result := r.db.Model(&domain.UsageEvent{}).
+		Select("subscription_uuid, COALESCE(SUM(quantity) FILTER (WHERE occurred_at >= ?), 0) AS uses, MAX(occurred_at) AS last_used_at", since).
+		Where("user_id = ?", userId).
+		Group("subscription_uuid").
+		Scan(&rows)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to summarise usage events: %w", result.Error)
+	}
+
+	summaries := make(map[string]domain.UsageSummary, len(rows))
+	for _, row := range rows {
+		summaries[row.SubscriptionUuid] = row
+	}
+	return summaries, nil
+}
@@ -0,0 +1,12 @@
+package dto
+
+import "time"
+
+type CalendarWeekQueryParams struct {
+	Date *time.Time `form:"date" time_format:"2006-01-02"`
+}
+
+type CalendarAgendaQueryParams struct {
+	From *time.Time `form:"from" time_format:"2006-01-02"`
+	Days int        `form:"days,default=30"`
+}
@@ -0,0 +1,40 @@
+package dto
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+)
+
+type ForecastQueryParams struct {
+	From    *time.Time `form:"from" time_format:"2006-01-02"`
+	Months  int        `form:"months,default=12"`
+	Balance *float64   `form:"balance"`
+	// Paydays are given as day-of-month:amount, e.g. payday=25:3200
+	Paydays []string `form:"payday"`
+}
+
+// ToPaydays parses the payday query parameters
+func (p *ForecastQueryParams) ToPaydays() ([]domain.Payday, error) {
+	paydays := make([]domain.Payday, 0, len(p.Paydays))
+	for _, value := range p.Paydays {
+		day, amount, ok := strings.Cut(value, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid payday %q", value)
+		}
+		dayOfMonth, err := strconv.Atoi(day)
+		if err != nil {
+			return nil, fmt.Errorf("invalid payday %q", value)
+		}
+		paydayAmount, err := strconv.ParseFloat(amount, 64)
+		if err != nil || math.IsNaN(paydayAmount) || math.IsInf(paydayAmount, 0) {
+			return nil, fmt.Errorf("invalid payday %q", value)
+		}
+		paydays = append(paydays, domain.Payday{DayOfMonth: dayOfMonth, Amount: paydayAmount})
+	}
+	return paydays, nil
+}
@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+)
+
+func TestToPaydays(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    []domain.Payday
+		wantErr bool
+	}{
+		{name: "none", want: []domain.Payday{}},
+		{name: "one", values: []string{"25:3200"}, want: []domain.Payday{{DayOfMonth: 25, Amount: 3200}}},
+		{name: "several", values: []string{"1:1500.50", "15:1500.5"}, want: []domain.Payday{{DayOfMonth: 1, Amount: 1500.5}, {DayOfMonth: 15, Amount: 1500.5}}},
+		// Out of range days and negative amounts are rejected by the forecast service
+		{name: "out of range day", values: []string{"32:100"}, want: []domain.Payday{{DayOfMonth: 32, Amount: 100}}},
+		{name: "missing separator", values: []string{"25"}, wantErr: true},
+		{name: "missing amount", values: []string{"25:"}, wantErr: true},
+		{name: "missing day", values: []string{":3200"}, wantErr: true},
+		{name: "fractional day", values: []string{"2.5:3200"}, wantErr: true},
+		{name: "amount with a currency", values: []string{"25:3200EUR"}, wantErr: true},
+		{name: "not a number", values: []string{"25:NaN"}, wantErr: true},
+		{name: "infinite amount", values: []string{"25:Inf"}, wantErr: true},
+		{name: "one invalid among valid", values: []string{"1:100", "x:100"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := ForecastQueryParams{Paydays: tt.values}
+			got, err := params.ToPaydays()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ToPaydays(%q) = %+v, want an error", tt.values, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ToPaydays(%q): %v", tt.values, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToPaydays(%q) = %+v, want %+v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package dto
+
+import "github.com/subscription-tracker/subscription/internal/core/domain"
+
+// PaymentMethodRequest creates or replaces a payment method. The expiry month and year
+// are given together or not at all.
+type PaymentMethodRequest struct {
+	Label       string `json:"label" binding:"required"`
+	Type        string `json:"type" binding:"required,oneof=card bank_account paypal other"`
+	LastFour    string `json:"lastFour" binding:"omitempty,len=4,numeric"`
+	ExpiryMonth int    `json:"expiryMonth" binding:"required_with=ExpiryYear,omitempty,min=1,max=12"`
+	ExpiryYear  int    `json:"expiryYear" binding:"required_with=ExpiryMonth,omitempty,min=2000,max=2100"`
+}
+
+type PaymentMethodAlertQueryParams struct {
+	// Days defaults to the user's reminder lead days
+	Days *int `form:"days" binding:"omitempty,min=0,max=366"`
+}
+
+// ToPaymentMethod converts PaymentMethodRequest to domain.PaymentMethod
+func (r *PaymentMethodRequest) ToPaymentMethod(userID string) *domain.PaymentMethod {
+	paymentMethod := &domain.PaymentMethod{UserID: userID}
+	r.Apply(paymentMethod)
+	return paymentMethod
+}
+
+// Apply copies the request fields onto an existing payment method
+func (r *PaymentMethodRequest) Apply(paymentMethod *domain.PaymentMethod) {
+	paymentMethod.Label = r.Label
+	paymentMethod.Type = r.Type
+	paymentMethod.LastFour = r.LastFour
+	paymentMethod.ExpiryMonth = r.ExpiryMonth
+	paymentMethod.ExpiryYear = r.ExpiryYear
+}
@@ -0,0 +1,6 @@
+package dto
+
+type SpotifySyncRequest struct {
+	// Product is the `product` field of the Spotify profile, e.g. premium or free
+	Product string `json:"product" binding:"required"`
+}
@@ -7,56 +7,138 @@ import (
 )
 
 type CreateSubscriptionRequest struct {
-	Name         string    `json:"name" binding:"required"`
-	Price        float64   `json:"price" binding:"required,gte=0"`
-	BillingCycle int       `json:"billingCycle" binding:"required,gte=0"`
-	StartDate    time.Time `json:"startDate" binding:"required"`
-	Logo         string    `json:"logo" binding:"required"`
+	Name              string     `json:"name" binding:"required"`
+	Price             float64    `json:"price" binding:"required,gte=0"`
+	BillingCycle      int        `json:"billingCycle" binding:"required,gte=0"`
+	StartDate         time.Time  `json:"startDate" binding:"required"`
+	Logo              string     `json:"logo" binding:"required"`
+	TrialEndDate      *time.Time `json:"trialEndDate"`
+	CancelAt          *time.Time `json:"cancelAt"`
+	PaymentMethodID   *uint      `json:"paymentMethodId"`
+	NoticePeriodDays  int        `json:"noticePeriodDays" binding:"gte=0"`
+	MinimumTermMonths int        `json:"minimumTermMonths" binding:"gte=0"`
+	TaxRate           float64    `json:"taxRate" binding:"gte=0,lte=100"`
+	PriceIncludesTax  bool       `json:"priceIncludesTax"`
+	BusinessExpense   bool       `json:"businessExpense"`
+	// PriceSchedule overrides Price when set; the last step is open-ended
+	PriceSchedule []PriceStepRequest `json:"priceSchedule" binding:"omitempty,dive"`
 }
 
+// UpdateSubscriptionRequest replaces the name, price, start date and logo of a subscription.
+// The optional fields that are left out keep the value of the current version.
 type UpdateSubscriptionRequest struct {
-	Name      string    `json:"name" binding:"required"`
-	Price     float64   `json:"price" binding:"required,gte=0"`
-	StartDate time.Time `json:"startDate" binding:"required"`
-	Logo      string    `json:"logo" binding:"required"`
+	Name         string     `json:"name" binding:"required"`
+	Price        float64    `json:"price" binding:"required,gte=0"`
+	StartDate    time.Time  `json:"startDate" binding:"required"`
+	Logo         string     `json:"logo" binding:"required"`
+	TrialEndDate *time.Time `json:"trialEndDate"`
+	CancelAt     *time.Time `json:"cancelAt"`
+	// ClearTrialEndDate and ClearCancelAt remove the trial end and the scheduled cancellation
+	ClearTrialEndDate bool `json:"clearTrialEndDate"`
+	ClearCancelAt     bool `json:"clearCancelAt"`
+	// PaymentMethodID 0 unassigns the payment method
+	PaymentMethodID   *uint    `json:"paymentMethodId"`
+	NoticePeriodDays  *int     `json:"noticePeriodDays" binding:"omitempty,gte=0"`
+	MinimumTermMonths *int     `json:"minimumTermMonths" binding:"omitempty,gte=0"`
+	TaxRate           *float64 `json:"taxRate" binding:"omitempty,gte=0,lte=100"`
+	PriceIncludesTax  *bool    `json:"priceIncludesTax"`
+	BusinessExpense   *bool    `json:"businessExpense"`
+	// PriceSchedule overrides Price when set; the last step is open-ended. An empty
+	// schedule removes the current one.
+	PriceSchedule *[]PriceStepRequest `json:"priceSchedule" binding:"omitempty,dive"`
+}
+
+type PriceStepRequest struct {
+	Price  float64 `json:"price" binding:"gte=0"`
+	Cycles int     `json:"cycles" binding:"gte=0"`
+}
+
+type PriceChangeRequest struct {
+	Price         float64   `json:"price" binding:"gte=0"`
+	EffectiveDate time.Time `json:"effectiveDate" binding:"required"`
 }
 
 type SubscriptionQueryParams struct {
 	StartDateFrom *time.Time `form:"start_date_from" time_format:"2006-01-02"`
 	StartDateTo   *time.Time `form:"start_date_to" time_format:"2006-01-02" `
+	// UnusedDays is the usage window used to flag unused subscriptions
+	UnusedDays int `form:"unused_days,default=30"`
+	// Unused only returns the subscriptions flagged as unused when true
+	Unused *bool `form:"unused"`
 }
 
 type SubscriptionResponse struct {
-	UUID         string    `json:"uuid"`
-	Name         string    `json:"name"`
-	Price        float64   `json:"price"`
-	BillingCycle int       `json:"billingCycle"`
-	StartDate    time.Time `json:"startDate"`
-	Logo         string    `json:"logo"`
-	UserID       string    `json:"userId"`
+	UUID              string                         `json:"uuid"`
+	Name              string                         `json:"name"`
+	Price             float64                        `json:"price"`
+	BillingCycle      int                            `json:"billingCycle"`
+	StartDate         time.Time                      `json:"startDate"`
+	Logo              string                         `json:"logo"`
+	UserID            string                         `json:"userId"`
+	TrialEndDate      *time.Time                     `json:"trialEndDate"`
+	CancelAt          *time.Time                     `json:"cancelAt"`
+	PaymentMethodID   *uint                          `json:"paymentMethodId"`
+	NoticePeriodDays  int                            `json:"noticePeriodDays"`
+	MinimumTermMonths int                            `json:"minimumTermMonths"`
+	TaxRate           float64                        `json:"taxRate"`
+	PriceIncludesTax  bool                           `json:"priceIncludesTax"`
+	BusinessExpense   bool                           `json:"businessExpense"`
+	PriceSchedule     []domain.SubscriptionPriceStep `json:"priceSchedule"`
+	// CancelBy is the last date a cancellation can be submitted to avoid the next renewal
+	CancelBy *time.Time `json:"cancelBy"`
 }
 
 // ToSubscription converts CreateSubscriptionRequest to domain.Subscription
 func (r *CreateSubscriptionRequest) ToSubscription(userID string) *domain.Subscription {
-	return &domain.Subscription{
-		Name:         r.Name,
-		Price:        r.Price,
-		BillingCycle: r.BillingCycle,
-		StartDate:    r.StartDate,
-		Logo:         r.Logo,
-		UserID:       userID,
+	subscription := &domain.Subscription{
+		Name:              r.Name,
+		Price:             r.Price,
+		BillingCycle:      r.BillingCycle,
+		StartDate:         r.StartDate,
+		Logo:              r.Logo,
+		UserID:            userID,
+		TrialEndDate:      r.TrialEndDate,
+		CancelAt:          r.CancelAt,
+		PaymentMethodID:   r.PaymentMethodID,
+		NoticePeriodDays:  r.NoticePeriodDays,
+		MinimumTermMonths: r.MinimumTermMonths,
+		TaxRate:           r.TaxRate,
+		PriceIncludesTax:  r.PriceIncludesTax,
+		BusinessExpense:   r.BusinessExpense,
+	}
+	subscription.SetPriceSchedule(ToPriceSchedule(r.PriceSchedule))
+	return subscription
+}
+
+// ToPriceSchedule converts price step requests to domain price steps
+func ToPriceSchedule(steps []PriceStepRequest) []domain.SubscriptionPriceStep {
+	schedule := make([]domain.SubscriptionPriceStep, len(steps))
+	for i, step := range steps {
+		schedule[i] = domain.SubscriptionPriceStep{Price: step.Price, Cycles: step.Cycles}
 	}
+	return schedule
 }
 
-// FromSubscription creates SubscriptionResponse from domain.Subscription
-func FromSubscription(s *domain.Subscription) *SubscriptionResponse {
+// FromSubscription creates SubscriptionResponse from domain.Subscription. The cancel-by
+// date is worked out from the current day in the user's time zone.
+func FromSubscription(s *domain.Subscription, prefs domain.Preferences) *SubscriptionResponse {
 	return &SubscriptionResponse{
-		UUID:         s.Uuid,
-		Name:         s.Name,
-		Price:        s.Price,
-		BillingCycle: s.BillingCycle,
-		StartDate:    s.StartDate,
-		Logo:         s.Logo,
-		UserID:       s.UserID,
+		UUID:              s.Uuid,
+		Name:              s.Name,
+		Price:             s.Price,
+		BillingCycle:      s.BillingCycle,
+		StartDate:         s.StartDate,
+		Logo:              s.Logo,
+		UserID:            s.UserID,
+		TrialEndDate:      s.TrialEndDate,
+		CancelAt:          s.CancelAt,
+		PaymentMethodID:   s.PaymentMethodID,
+		NoticePeriodDays:  s.NoticePeriodDays,
+		MinimumTermMonths: s.MinimumTermMonths,
+		TaxRate:           s.TaxRate,
+		PriceIncludesTax:  s.PriceIncludesTax,
+		BusinessExpense:   s.BusinessExpense,
+		PriceSchedule:     s.PriceSchedule,
+		CancelBy:          s.CancelByDate(prefs.Now()),
 	}
 }
@@ -0,0 +1,36 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+)
+
+type RecordUsageRequest struct {
+	// OccurredAt defaults to the time the event is recorded
+	OccurredAt *time.Time `json:"occurredAt"`
+	Quantity   int        `json:"quantity" binding:"gte=0"`
+	Source     string     `json:"source" binding:"max=64"`
+}
+
+type UsageQueryParams struct {
+	UnusedDays int `form:"unused_days,default=30"`
+}
+
+// ToUsageEvent converts RecordUsageRequest to domain.UsageEvent
+func (r *RecordUsageRequest) ToUsageEvent(subscriptionUuid string, userID string, now time.Time) *domain.UsageEvent {
+	event := &domain.UsageEvent{
+		SubscriptionUuid: subscriptionUuid,
+		UserID:           userID,
+		OccurredAt:       now,
+		Quantity:         r.Quantity,
+		Source:           r.Source,
+	}
+	if r.OccurredAt != nil && r.OccurredAt.Before(now) {
+		event.OccurredAt = *r.OccurredAt
+	}
+	if event.Quantity == 0 {
+		event.Quantity = 1
+	}
+	return event
+}
@@ -0,0 +1,11 @@
+package dto
+
+import "time"
+
+// UserEventRequest is an event published by the user service
+type UserEventRequest struct {
+	ID         string    `json:"id" binding:"required"`
+	Type       string    `json:"type" binding:"required"`
+	UserID     string    `json:"userId" binding:"required"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/subscription-tracker/subscription/internal/core/application"
+)
+
+type AttachmentHandler struct {
+	service *application.AttachmentService
+}
+
+func NewAttachmentHandler(service *application.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{service: service}
+}
+
+func (h *AttachmentHandler) GetAttachments(c *gin.Context) {
+	attachments, err := h.service.GetAttachments(c.Param("uuid"), c.GetString("user_id"))
+	if errors.Is(err, application.ErrSubscriptionNotFound) {
+		c.JSON(404, gin.H{"error": "Subscription not found"})
+		return
+	} else if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch attachments"})
+		return
+	}
+	c.JSON(200, attachments)
+}
+
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	// Leave room for the multipart envelope around the file itself
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, application.MaxAttachmentSize+1<<20)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(413, gin.H{"error": "Attachment too large"})
+			return
+		}
+		c.JSON(400, gin.H{"error": "A file is required"})
+		return
+	}
+	if fileHeader.Size > application.MaxAttachmentSize {
+		c.JSON(413, gin.H{"error": "Attachment too large"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.service.UploadAttachment(c.Param("uuid"), c.GetString("user_id"), fileHeader.Filename, file)
+	switch {
+	case errors.Is(err, application.ErrSubscriptionNotFound):
+		c.JSON(404, gin.H{"error": "Subscription not found"})
+	case errors.Is(err, application.ErrAttachmentTooLarge):
+		c.JSON(413, gin.H{"error": "Attachment too large"})
+	case errors.Is(err, application.ErrUnsupportedAttachmentType):
+		c.JSON(415, gin.H{"error": "Unsupported attachment type"})
+	case err != nil:
+		c.JSON(500, gin.H{"error": "Failed to upload attachment"})
+	default:
+		c.JSON(201, attachment)
+	}
+}
+
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	attachment, content, err := h.service.DownloadAttachment(c.Param("uuid"), c.Param("attachmentUuid"), c.GetString("user_id"))
+	if errors.Is(err, application.ErrAttachmentNotFound) {
+		c.JSON(404, gin.H{"error": "Attachment not found"})
+		return
+	} else if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to download attachment"})
+		return
+	}
+	defer content.Close()
+
+	c.DataFromReader(200, attachment.Size, attachment.ContentType, content, map[string]string{
+		"Content-Disposition":    "attachment; filename=" + strconv.Quote(attachment.FileName),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	err := h.service.DeleteAttachment(c.Param("uuid"), c.Param("attachmentUuid"), c.GetString("user_id"))
+	if errors.Is(err, application.ErrAttachmentNotFound) {
+		c.JSON(404, gin.H{"error": "Attachment not found"})
+		return
+	} else if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to delete attachment"})
+		return
+	}
+	c.Status(204)
+}
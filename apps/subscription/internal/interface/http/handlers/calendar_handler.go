@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/subscription-tracker/subscription/internal/core/application"
+	"github.com/subscription-tracker/subscription/internal/interface/http/dto"
+)
+
+type CalendarHandler struct {
+	service *application.CalendarService
+}
+
+func NewCalendarHandler(service *application.CalendarService) *CalendarHandler {
+	return &CalendarHandler{service: service}
+}
+
+func (h *CalendarHandler) GetMonth(c *gin.Context) {
+	year, err := strconv.Atoi(c.Param("year"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid year"})
+		return
+	}
+	month, err := strconv.Atoi(c.Param("month"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid month"})
+		return
+	}
+
+	calendar, err := h.service.GetMonth(c.GetString("user_id"), year, month, preferences(c))
+	if errors.Is(err, application.ErrInvalidCalendarRange) {
+		c.JSON(400, gin.H{"error": "Invalid month"})
+		return
+	} else if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to build calendar"})
+		return
+	}
+	c.JSON(200, calendar)
+}
+
+func (h *CalendarHandler) GetWeek(c *gin.Context) {
+	var params dto.CalendarWeekQueryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+	prefs := preferences(c)
+	// Default to the week of today in the user's time zone
+	date := prefs.Now()
+	if params.Date != nil {
+		date = *params.Date
+	}
+
+	calendar, err := h.service.GetWeek(c.GetString("user_id"), date, prefs)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to build calendar"})
+		return
+	}
+	c.JSON(200, calendar)
+}
+
+func (h *CalendarHandler) GetAgenda(c *gin.Context) {
+	var params dto.CalendarAgendaQueryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+	prefs := preferences(c)
+	from := prefs.Now()
+	if params.From != nil {
+		from = *params.From
+	}
+
+	calendar, err := h.service.GetAgenda(c.GetString("user_id"), from, params.Days, prefs)
+	if errors.Is(err, application.ErrInvalidCalendarRange) {
+		c.JSON(400, gin.H{"error": "Invalid number of days"})
+		return
+	} else if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to build calendar"})
+		return
+	}
+	c.JSON(200, calendar)
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/subscription-tracker/subscription/internal/core/application"
+)
+
+// ExportHandler serves the user service's personal data exports. The service token
+// names the user whose data is exported.
+type ExportHandler struct {
+	service *application.ExportService
+}
+
+func NewExportHandler(service *application.ExportService) *ExportHandler {
+	return &ExportHandler{service: service}
+}
+
+func (h *ExportHandler) GetExport(c *gin.Context) {
+	userId := c.GetString("user_id")
+	if userId == "" {
+		c.JSON(403, gin.H{"error": "Token does not name a user"})
+		return
+	}
+
+	export, err := h.service.Export(userId)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to export user data"})
+		return
+	}
+	c.JSON(200, export)
+}
+
+func (h *ExportHandler) DownloadAttachment(c *gin.Context) {
+	userId := c.GetString("user_id")
+	if userId == "" {
+		c.JSON(403, gin.H{"error": "Token does not name a user"})
+		return
+	}
+
+	attachment, content, err := h.service.OpenAttachment(userId, c.Param("attachmentUuid"))
+	if errors.Is(err, application.ErrAttachmentNotFound) {
+		c.JSON(404, gin.H{"error": "Attachment not found"})
+		return
+	} else if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to download attachment"})
+		return
+	}
+	defer content.Close()
+
+	c.DataFromReader(200, attachment.Size, attachment.ContentType, content, nil)
+}
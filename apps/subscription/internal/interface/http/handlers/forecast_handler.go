@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/subscription-tracker/subscription/internal/core/application"
+	"github.com/subscription-tracker/subscription/internal/interface/http/dto"
+)
+
+type ForecastHandler struct {
+	service *application.ForecastService
+}
+
+func NewForecastHandler(service *application.ForecastService) *ForecastHandler {
+	return &ForecastHandler{service: service}
+}
+
+func (h *ForecastHandler) GetForecast(c *gin.Context) {
+	var params dto.ForecastQueryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+	paydays, err := params.ToPaydays()
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	prefs := preferences(c)
+	options := application.ForecastOptions{
+		From:     prefs.Now(),
+		Months:   params.Months,
+		Balance:  params.Balance,
+		Paydays:  paydays,
+		Currency: prefs.Currency,
+	}
+	if params.From != nil {
+		options.From = *params.From
+	}
+
+	forecast, err := h.service.GetForecast(c.GetString("user_id"), options)
+	if errors.Is(err, application.ErrInvalidForecast) {
+		c.JSON(400, gin.H{"error": "Invalid forecast parameters"})
+		return
+	} else if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to build forecast"})
+		return
+	}
+	c.JSON(200, forecast)
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/subscription-tracker/subscription/internal/core/application"
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+	"github.com/subscription-tracker/subscription/internal/interface/http/dto"
+)
+
+type PaymentMethodHandler struct {
+	service *application.PaymentMethodService
+}
+
+func NewPaymentMethodHandler(service *application.PaymentMethodService) *PaymentMethodHandler {
+	return &PaymentMethodHandler{service: service}
+}
+
+func (h *PaymentMethodHandler) GetPaymentMethods(c *gin.Context) {
+	paymentMethods, err := h.service.GetPaymentMethods(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch payment methods"})
+		return
+	}
+	c.JSON(200, paymentMethods)
+}
+
+func (h *PaymentMethodHandler) GetPaymentMethod(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid payment method id"})
+		return
+	}
+	paymentMethod, err := h.service.GetPaymentMethod(uint(id), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": "Payment method not found"})
+		return
+	}
+	c.JSON(200, paymentMethod)
+}
+
+func (h *PaymentMethodHandler) CreatePaymentMethod(c *gin.Context) {
+	var request dto.PaymentMethodRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	paymentMethod := request.ToPaymentMethod(c.GetString("user_id"))
+	err := h.service.CreatePaymentMethod(paymentMethod)
+	if errors.Is(err, domain.ErrInvalidPaymentMethod) {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to create payment method"})
+		return
+	}
+	c.JSON(201, paymentMethod)
+}
+
+func (h *PaymentMethodHandler) UpdatePaymentMethod(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid payment method id"})
+		return
+	}
+	var request dto.PaymentMethodRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	paymentMethod, err := h.service.UpdatePaymentMethod(uint(id), request, c.GetString("user_id"))
+	if errors.Is(err, application.ErrPaymentMethodNotFound) {
+		c.JSON(404, gin.H{"error": "Payment method not found"})
+		return
+	} else if errors.Is(err, domain.ErrInvalidPaymentMethod) {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to update payment method"})
+		return
+	}
+	c.JSON(200, paymentMethod)
+}
+
+func (h *PaymentMethodHandler) DeletePaymentMethod(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid payment method id"})
+		return
+	}
+
+	err = h.service.DeletePaymentMethod(uint(id), c.GetString("user_id"))
+	if errors.Is(err, application.ErrPaymentMethodNotFound) {
+		c.JSON(404, gin.H{"error": "Payment method not found"})
+		return
+	} else if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to delete payment method"})
+		return
+	}
+	c.Status(204)
+}
+
+func (h *PaymentMethodHandler) GetExpiryAlerts(c *gin.Context) {
+	var params dto.PaymentMethodAlertQueryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	prefs := preferences(c)
+	days := prefs.ReminderLeadDays
+	if params.Days != nil {
+		days = *params.Days
+	}
+	alerts, err := h.service.GetExpiryAlerts(c.GetString("user_id"), days, prefs.Now())
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch payment method alerts"})
+		return
+	}
+	c.JSON(200, alerts)
+}
@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/subscription-tracker/subscription/internal/core/domain"
+)
+
+// preferences returns the preferences the auth middleware read from the user's token
+func preferences(c *gin.Context) domain.Preferences {
+	if prefs, ok := c.Get("preferences"); ok {
+		if prefs, ok := prefs.(domain.Preferences); ok {
+			return prefs
+		}
+	}
+	return domain.DefaultPreferences()
+}
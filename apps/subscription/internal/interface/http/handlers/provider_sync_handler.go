@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/subscription-tracker/subscription/internal/core/application"
+	"github.com/subscription-tracker/subscription/internal/interface/http/dto"
+)
+
+type ProviderSyncHandler struct {
+	service *application.ProviderSyncService
+}
+
+func NewProviderSyncHandler(service *application.ProviderSyncService) *ProviderSyncHandler {
+	return &ProviderSyncHandler{service: service}
+}
+
+func (h *ProviderSyncHandler) SyncSpotify(c *gin.Context) {
+	var request dto.SpotifySyncRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	subscription, err := h.service.SyncSpotify(c.GetString("user_id"), request.Product)
+	if errors.Is(err, application.ErrProviderPlanNotFound) {
+		c.JSON(422, gin.H{"error": "No Spotify plan matches the account"})
+		return
+	} else if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to sync Spotify subscription"})
+		return
+	}
+	if subscription == nil {
+		c.Status(204)
+		return
+	}
+	c.JSON(200, dto.FromSubscription(subscription, preferences(c)))
+}
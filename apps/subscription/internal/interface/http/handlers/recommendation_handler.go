@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/subscription-tracker/subscription/internal/core/application"
+)
+
+type RecommendationHandler struct {
+	service *application.RecommendationService
+}
+
+func NewRecommendationHandler(service *application.RecommendationService) *RecommendationHandler {
+	return &RecommendationHandler{service: service}
+}
+
+func (h *RecommendationHandler) GetRecommendations(c *gin.Context) {
+	recommendations, err := h.service.GetRecommendations(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch recommendations"})
+		return
+	}
+	c.JSON(200, recommendations)
+}
+
+func (h *RecommendationHandler) DismissRecommendation(c *gin.Context) {
+	err := h.service.DismissRecommendation(c.Param("id"), c.GetString("user_id"))
+	if errors.Is(err, application.ErrRecommendationNotFound) {
+		c.JSON(404, gin.H{"error": "Recommendation not found"})
+		return
+	} else if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to dismiss recommendation"})
+		return
+	}
+	c.Status(204)
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/subscription-tracker/subscription/internal/core/application"
+	"github.com/subscription-tracker/subscription/internal/interface/http/dto"
+)
+
+type ReportHandler struct {
+	service *application.ReportService
+}
+
+func NewReportHandler(service *application.ReportService) *ReportHandler {
+	return &ReportHandler{service: service}
+}
+
+// GetSpendReport reports spend for the requested period, defaulting to the current calendar year
+func (h *ReportHandler) GetSpendReport(c *gin.Context) {
+	var params dto.ReportQueryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+	prefs := preferences(c)
+	from := time.Date(prefs.Now().Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	if params.From != nil {
+		from = *params.From
+	}
+	to := from.AddDate(1, 0, 0)
+	if params.To != nil {
+		to = *params.To
+	}
+
+	report, err := h.service.GetSpendReport(c.GetString("user_id"), from, to, prefs.Currency)
+	if errors.Is(err, application.ErrInvalidReportPeriod) {
+		c.JSON(400, gin.H{"error": "Invalid report period"})
+		return
+	} else if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to build report"})
+		return
+	}
+	c.JSON(200, report)
+}
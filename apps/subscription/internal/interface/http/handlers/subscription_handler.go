@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"errors"
+
 	"github.com/gin-gonic/gin"
 	"github.com/subscription-tracker/subscription/internal/core/application"
+	"github.com/subscription-tracker/subscription/internal/core/domain"
 	"github.com/subscription-tracker/subscription/internal/interface/http/dto"
 )
 
@@ -22,7 +25,7 @@ func (h *SubscriptionHandler) GetSubscription(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "Failed to fetch subscription"})
 		return
 	}
-	c.JSON(200, dto.FromSubscription(subscription))
+	c.JSON(200, dto.FromSubscription(subscription, preferences(c)))
 }
 
 func (h *SubscriptionHandler) GetSubscriptions(c *gin.Context) {
@@ -34,7 +37,10 @@ func (h *SubscriptionHandler) GetSubscriptions(c *gin.Context) {
 	}
 
 	subscriptions, err := h.service.GetUserSubscriptions(params, userId.(string))
-	if err != nil {
+	if errors.Is(err, application.ErrInvalidUsageWindow) {
+		c.JSON(400, gin.H{"error": "Invalid number of days"})
+		return
+	} else if err != nil {
 		c.JSON(500, gin.H{"error": "Failed to fetch subscriptions"})
 		return
 	}
@@ -52,11 +58,22 @@ func (h *SubscriptionHandler) CreateSubscription(c *gin.Context) {
 	userID := c.GetString("user_id")
 	subscription := request.ToSubscription(userID)
 
-	if err := h.service.CreateSubscription(subscription); err != nil {
+	err := h.service.CreateSubscription(subscription, preferences(c))
+	var termErr *application.MinimumTermError
+	if errors.Is(err, application.ErrPaymentMethodNotFound) {
+		c.JSON(400, gin.H{"error": "Payment method not found"})
+		return
+	} else if errors.Is(err, domain.ErrInvalidPriceSchedule) {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	} else if errors.As(err, &termErr) {
+		c.JSON(422, gin.H{"error": termErr.Error()})
+		return
+	} else if err != nil {
 		c.JSON(500, gin.H{"error": "Failed to create subscription"})
 		return
 	}
-	c.JSON(201, dto.FromSubscription(subscription))
+	c.JSON(201, dto.FromSubscription(subscription, preferences(c)))
 }
 
 func (h *SubscriptionHandler) UpdateSubscription(c *gin.Context) {
@@ -67,8 +84,29 @@ func (h *SubscriptionHandler) UpdateSubscription(c *gin.Context) {
 	}
 	id := c.Param("uuid")
 	userId := c.GetString("user_id")
-	err := h.service.UpdateSubscription(id, request, userId)
-	if err != nil {
+	err := h.service.UpdateSubscription(id, request, userId, preferences(c))
+	var termErr *application.MinimumTermError
+	var noticeErr *application.NoticePeriodError
+	if errors.Is(err, application.ErrSubscriptionNotFound) {
+		c.JSON(404, gin.H{"error": "Subscription not found"})
+		return
+	} else if errors.Is(err, application.ErrPaymentMethodNotFound) {
+		c.JSON(400, gin.H{"error": "Payment method not found"})
+		return
+	} else if errors.Is(err, domain.ErrInvalidPriceSchedule) {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	} else if errors.As(err, &termErr) {
+		c.JSON(422, gin.H{"error": termErr.Error()})
+		return
+	} else if errors.As(err, &noticeErr) {
+		response := gin.H{"error": noticeErr.Error(), "cancelBy": noticeErr.CancelBy}
+		if noticeErr.Earliest != nil {
+			response["earliestCancellation"] = noticeErr.Earliest
+		}
+		c.JSON(422, response)
+		return
+	} else if err != nil {
 		c.JSON(500, gin.H{"error": "Failed to update subscription"})
 		return
 	}
@@ -77,7 +115,48 @@ func (h *SubscriptionHandler) UpdateSubscription(c *gin.Context) {
 		c.JSON(500, gin.H{"error": "Failed to fetch subscription"})
 		return
 	}
-	c.JSON(200, dto.FromSubscription(subscription))
+	c.JSON(200, dto.FromSubscription(subscription, preferences(c)))
+}
+
+func (h *SubscriptionHandler) CancelSubscription(c *gin.Context) {
+	subscription, err := h.service.CancelSubscription(c.Param("uuid"), c.GetString("user_id"), preferences(c))
+	var termErr *application.MinimumTermError
+	if errors.Is(err, application.ErrSubscriptionNotFound) {
+		c.JSON(404, gin.H{"error": "Subscription not found"})
+		return
+	} else if errors.As(err, &termErr) {
+		response := gin.H{"error": termErr.Error(), "minimumTermEnd": termErr.MinimumTermEnd}
+		if termErr.Earliest != nil {
+			response["earliestCancellation"] = termErr.Earliest
+		}
+		c.JSON(422, response)
+		return
+	} else if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to cancel subscription"})
+		return
+	}
+	c.JSON(200, dto.FromSubscription(subscription, preferences(c)))
+}
+
+func (h *SubscriptionHandler) SchedulePriceChange(c *gin.Context) {
+	var request dto.PriceChangeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	subscription, err := h.service.SchedulePriceChange(c.Param("uuid"), request, c.GetString("user_id"))
+	if errors.Is(err, application.ErrSubscriptionNotFound) {
+		c.JSON(404, gin.H{"error": "Subscription not found"})
+		return
+	} else if errors.Is(err, domain.ErrOneOffPriceChange) {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to schedule price change"})
+		return
+	}
+	c.JSON(200, dto.FromSubscription(subscription, preferences(c)))
 }
 
 func (h *SubscriptionHandler) DeleteSubscription(c *gin.Context) {
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/subscription-tracker/subscription/internal/core/application"
+	"github.com/subscription-tracker/subscription/internal/interface/http/dto"
+)
+
+type UsageHandler struct {
+	service *application.UsageService
+}
+
+func NewUsageHandler(service *application.UsageService) *UsageHandler {
+	return &UsageHandler{service: service}
+}
+
+func (h *UsageHandler) RecordUsage(c *gin.Context) {
+	var request dto.RecordUsageRequest
+	// An empty body records a single use now
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+
+	event, err := h.service.RecordUsage(c.Param("uuid"), request, c.GetString("user_id"))
+	if errors.Is(err, application.ErrSubscriptionNotFound) {
+		c.JSON(404, gin.H{"error": "Subscription not found"})
+		return
+	} else if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to record usage"})
+		return
+	}
+	c.JSON(201, event)
+}
+
+func (h *UsageHandler) GetDigest(c *gin.Context) {
+	var params dto.UsageQueryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+
+	digest, err := h.service.GetDigest(c.GetString("user_id"), params.UnusedDays, preferences(c))
+	if errors.Is(err, application.ErrInvalidUsageWindow) {
+		c.JSON(400, gin.H{"error": "Invalid number of days"})
+		return
+	} else if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to build digest"})
+		return
+	}
+	c.JSON(200, digest)
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/subscription-tracker/subscription/internal/core/application"
+	"github.com/subscription-tracker/subscription/internal/interface/http/dto"
+)
+
+type UserEventHandler struct {
+	service *application.AccountService
+}
+
+func NewUserEventHandler(service *application.AccountService) *UserEventHandler {
+	return &UserEventHandler{service: service}
+}
+
+// HandleUserEvent processes an event published by the user service. A 200 response
+// confirms the event was processed; any other status makes the user service retry.
+// The service token is issued for one event, so it must name the event and its user.
+func (h *UserEventHandler) HandleUserEvent(c *gin.Context) {
+	var request dto.UserEventRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if c.GetString("event_id") != request.ID || c.GetString("user_id") != request.UserID {
+		c.JSON(403, gin.H{"error": "Token was not issued for this event"})
+		return
+	}
+
+	event := application.UserEvent{
+		ID:         request.ID,
+		Type:       request.Type,
+		UserID:     request.UserID,
+		OccurredAt: request.OccurredAt,
+	}
+	if err := h.service.HandleUserEvent(event); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to process event"})
+		return
+	}
+	c.JSON(200, gin.H{"id": request.ID, "status": "processed"})
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandleUserEventChecksToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	body := `{"id":"event-1","type":"user.deleted","userId":"user-1"}`
+	tests := []struct {
+		name    string
+		eventID string
+		userID  string
+	}{
+		{name: "token for another event", eventID: "event-2", userID: "user-1"},
+		{name: "token for another user", eventID: "event-1", userID: "user-2"},
+		{name: "token without an event", userID: "user-1"},
+		{name: "token without a user", eventID: "event-1"},
+	}
+
+	// The service is never reached, so the handler needs none
+	handler := NewUserEventHandler(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/internal/events", strings.NewReader(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+			if tt.eventID != "" {
+				c.Set("event_id", tt.eventID)
+			}
+			if tt.userID != "" {
+				c.Set("user_id", tt.userID)
+			}
+
+			handler.HandleUserEvent(c)
+			if recorder.Code != http.StatusForbidden {
+				t.Errorf("status = %d, want 403", recorder.Code)
+			}
+		})
+	}
+}
@@ -1,17 +1,25 @@
 package middleware
 
 import (
+	"context"
 	"fmt"
 	"net/http"
-	"os"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/subscription-tracker/subscription/internal/core/domain"
 )
 
-// AuthMiddleware validates JWT tokens from the Authorization header
-func AuthMiddleware() gin.HandlerFunc {
+// KeySource looks up the public key a token was signed with by its key ID
+type KeySource interface {
+	Key(ctx context.Context, kid string) (interface{}, error)
+}
+
+// AuthMiddleware validates JWT tokens from the Authorization header against the user
+// service's published keys. Tokens must come from issuer and be addressed to audience.
+func AuthMiddleware(keys KeySource, issuer string, audience string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get the Authorization header
 		authHeader := c.GetHeader("Authorization")
@@ -32,19 +40,17 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		// Parse and validate the token
 		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Validate the signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-
-			// Get the secret key from environment variable
-			secretKey := os.Getenv("JWT_SECRET")
-			if secretKey == "" {
-				secretKey = "secret-key" // Default key for development
+			kid, ok := token.Header["kid"].(string)
+			if !ok {
+				return nil, fmt.Errorf("token has no key ID")
 			}
-
-			return []byte(secretKey), nil
-		})
+			return keys.Key(c.Request.Context(), kid)
+		},
+			jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+			jwt.WithIssuer(issuer),
+			jwt.WithAudience(audience),
+			jwt.WithExpirationRequired(),
+		)
 
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
@@ -57,6 +63,17 @@ func AuthMiddleware() gin.HandlerFunc {
 			if userID, exists := claims["user_id"].(string); exists {
 				c.Set("user_id", userID)
 			}
+			// Service tokens carry a scope and may name the event they deliver
+			if tokenType, exists := claims["type"].(string); exists {
+				c.Set("token_type", tokenType)
+			}
+			if scope, exists := claims["scope"].(string); exists {
+				c.Set("scope", scope)
+			}
+			if eventID, exists := claims["event_id"].(string); exists {
+				c.Set("event_id", eventID)
+			}
+			c.Set("preferences", preferences(claims))
 			c.Next()
 		} else {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
@@ -64,3 +81,50 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 	}
 }
+
+// Scopes of the service tokens the user service calls the internal routes with
+const (
+	ScopeUserDeletion = "user:delete"
+	ScopeUserExport   = "user:export"
+)
+
+// RequireScope restricts a route to service tokens granted scope. It has to run after
+// AuthMiddleware.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("token_type") != "service" || c.GetString("scope") != scope {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Token lacks the required scope"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// preferences reads the user's preferences from the prefs claim, keeping the defaults for
+// anything the token does not carry or this service does not understand
+func preferences(claims jwt.MapClaims) domain.Preferences {
+	result := domain.DefaultPreferences()
+	prefs, ok := claims["prefs"].(map[string]interface{})
+	if !ok {
+		return result
+	}
+	if currency, ok := prefs["currency"].(string); ok {
+		result.Currency = currency
+	}
+	if tz, ok := prefs["tz"].(string); ok {
+		if location, err := time.LoadLocation(tz); err == nil {
+			result.Location = location
+		}
+	}
+	if weekStart, ok := prefs["week_start"].(string); ok {
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if strings.EqualFold(day.String(), weekStart) {
+				result.WeekStart = day
+			}
+		}
+	}
+	if days, ok := prefs["reminder_lead_days"].(float64); ok {
+		result.ReminderLeadDays = int(days)
+	}
+	return result
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type staticKeys struct {
+	key ed25519.PublicKey
+}
+
+func (k staticKeys) Key(ctx context.Context, kid string) (interface{}, error) {
+	return k.key, nil
+}
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(claims jwt.MapClaims) string {
+		claims["iss"] = "issuer"
+		claims["aud"] = "internal"
+		claims["exp"] = time.Now().Add(time.Minute).Unix()
+		token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+		token.Header["kid"] = "key-1"
+		signed, err := token.SignedString(private)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	router := gin.New()
+	router.GET("/export", AuthMiddleware(staticKeys{public}, "issuer", "internal"), RequireScope(ScopeUserExport), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"userId": c.GetString("user_id")})
+	})
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		want   int
+	}{
+		{name: "export token", claims: jwt.MapClaims{"type": "service", "scope": ScopeUserExport, "user_id": "user-1"}, want: http.StatusOK},
+		{name: "deletion token", claims: jwt.MapClaims{"type": "service", "scope": ScopeUserDeletion, "user_id": "user-1"}, want: http.StatusForbidden},
+		{name: "unscoped service token", claims: jwt.MapClaims{"type": "service", "user_id": "user-1"}, want: http.StatusForbidden},
+		{name: "scope without the service type", claims: jwt.MapClaims{"scope": ScopeUserExport, "user_id": "user-1"}, want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/export", nil)
+			request.Header.Set("Authorization", "Bearer "+sign(tt.claims))
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			if recorder.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, tt.want, recorder.Body)
+			}
+		})
+	}
+}
@@ -10,7 +10,7 @@ import (
 )
 
 func main() {
-	stmts, err := gormschema.New("postgres").Load(&domain.Subscription{}, &domain.SubscriptionConfig{}, &domain.SubscriptionConfigPlan{})
+	stmts, err := gormschema.New("postgres").Load(&domain.Subscription{}, &domain.SubscriptionPriceStep{}, &domain.SubscriptionConfig{}, &domain.SubscriptionConfigPlan{}, &domain.PaymentMethod{}, &domain.Attachment{}, &domain.RecommendationDismissal{}, &domain.UsageEvent{})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to load gorm schema: %v\n", err)
 		os.Exit(1)
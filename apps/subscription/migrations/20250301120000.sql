@@ -0,0 +1,2 @@
+-- Modify "subscriptions" table
+ALTER TABLE "public"."subscriptions" ADD COLUMN "trial_end_date" timestamptz NULL, ADD COLUMN "cancel_at" timestamptz NULL;
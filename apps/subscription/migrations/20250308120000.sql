@@ -0,0 +1,19 @@
+-- Create "payment_methods" table
+CREATE TABLE "public"."payment_methods" (
+  "id" bigserial NOT NULL,
+  "user_id" text NOT NULL,
+  "label" text NOT NULL,
+  "type" text NOT NULL,
+  "last_four" text NULL,
+  "expiry_month" bigint NULL,
+  "expiry_year" bigint NULL,
+  "created_at" timestamptz NOT NULL,
+  "updated_at" timestamptz NOT NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_payment_methods_user_id" to table: "payment_methods"
+CREATE INDEX "idx_payment_methods_user_id" ON "public"."payment_methods" ("user_id");
+-- Modify "subscriptions" table
+ALTER TABLE "public"."subscriptions" ADD COLUMN "payment_method_id" bigint NULL;
+-- Create index "idx_subscriptions_payment_method_id" to table: "subscriptions"
+CREATE INDEX "idx_subscriptions_payment_method_id" ON "public"."subscriptions" ("payment_method_id");
@@ -0,0 +1,18 @@
+-- Create "attachments" table
+CREATE TABLE "public"."attachments" (
+  "id" bigserial NOT NULL,
+  "uuid" text NOT NULL,
+  "subscription_uuid" text NOT NULL,
+  "user_id" text NOT NULL,
+  "file_name" text NOT NULL,
+  "content_type" text NOT NULL,
+  "size" bigint NOT NULL,
+  "storage_key" text NOT NULL,
+  "created_at" timestamptz NOT NULL,
+  "updated_at" timestamptz NOT NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_attachments_subscription_uuid" to table: "attachments"
+CREATE INDEX "idx_attachments_subscription_uuid" ON "public"."attachments" ("subscription_uuid");
+-- Create index "idx_attachments_uuid" to table: "attachments"
+CREATE UNIQUE INDEX "idx_attachments_uuid" ON "public"."attachments" ("uuid");
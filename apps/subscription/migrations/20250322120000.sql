@@ -0,0 +1,2 @@
+-- Modify "subscriptions" table
+ALTER TABLE "public"."subscriptions" ADD COLUMN "notice_period_days" bigint NOT NULL DEFAULT 0, ADD COLUMN "minimum_term_months" bigint NOT NULL DEFAULT 0;
@@ -0,0 +1,12 @@
+-- Create "subscription_price_steps" table
+CREATE TABLE "public"."subscription_price_steps" (
+  "id" bigserial NOT NULL,
+  "subscription_id" bigint NOT NULL,
+  "position" bigint NOT NULL,
+  "price" numeric NOT NULL,
+  "cycles" bigint NOT NULL,
+  PRIMARY KEY ("id"),
+  CONSTRAINT "fk_subscriptions_price_schedule" FOREIGN KEY ("subscription_id") REFERENCES "public"."subscriptions" ("id") ON UPDATE NO ACTION ON DELETE CASCADE
+);
+-- Create index "idx_subscription_price_steps_subscription_id" to table: "subscription_price_steps"
+CREATE INDEX "idx_subscription_price_steps_subscription_id" ON "public"."subscription_price_steps" ("subscription_id");
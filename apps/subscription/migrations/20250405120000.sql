@@ -0,0 +1,4 @@
+-- Modify "subscriptions" table
+ALTER TABLE "public"."subscriptions" ADD COLUMN "tax_rate" numeric NOT NULL DEFAULT 0, ADD COLUMN "price_includes_tax" boolean NOT NULL DEFAULT false, ADD COLUMN "business_expense" boolean NOT NULL DEFAULT false;
+-- Modify "subscription_config_plans" table
+ALTER TABLE "public"."subscription_config_plans" ADD COLUMN "tax_rate" numeric NOT NULL DEFAULT 0, ADD COLUMN "price_includes_tax" boolean NOT NULL DEFAULT false;
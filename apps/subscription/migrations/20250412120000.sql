@@ -0,0 +1,12 @@
+-- Modify "subscription_configs" table
+ALTER TABLE "public"."subscription_configs" ADD COLUMN "category" text NULL;
+-- Create "recommendation_dismissals" table
+CREATE TABLE "public"."recommendation_dismissals" (
+  "id" bigserial NOT NULL,
+  "user_id" text NOT NULL,
+  "recommendation_id" text NOT NULL,
+  "created_at" timestamptz NOT NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_recommendation_dismissals_user_recommendation" to table: "recommendation_dismissals"
+CREATE UNIQUE INDEX "idx_recommendation_dismissals_user_recommendation" ON "public"."recommendation_dismissals" ("user_id", "recommendation_id");
@@ -0,0 +1,15 @@
+-- Create "usage_events" table
+CREATE TABLE "public"."usage_events" (
+  "id" bigserial NOT NULL,
+  "subscription_uuid" text NOT NULL,
+  "user_id" text NOT NULL,
+  "occurred_at" timestamptz NOT NULL,
+  "quantity" bigint NOT NULL DEFAULT 1,
+  "source" text NULL,
+  "created_at" timestamptz NOT NULL,
+  PRIMARY KEY ("id")
+);
+-- Create index "idx_usage_events_subscription_occurred" to table: "usage_events"
+CREATE INDEX "idx_usage_events_subscription_occurred" ON "public"."usage_events" ("subscription_uuid", "occurred_at");
+-- Create index "idx_usage_events_user_id" to table: "usage_events"
+CREATE INDEX "idx_usage_events_user_id" ON "public"."usage_events" ("user_id");
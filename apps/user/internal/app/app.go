@@ -1,12 +1,25 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/subscription-tracker/user/internal/core/application"
 	"github.com/subscription-tracker/user/internal/infrastructure/email"
+	"github.com/subscription-tracker/user/internal/infrastructure/filestore"
+	"github.com/subscription-tracker/user/internal/infrastructure/jwtkeys"
 	"github.com/subscription-tracker/user/internal/infrastructure/mongodb"
+	"github.com/subscription-tracker/user/internal/infrastructure/oauth"
+	"github.com/subscription-tracker/user/internal/infrastructure/ratelimit"
+	"github.com/subscription-tracker/user/internal/infrastructure/spotify"
+	"github.com/subscription-tracker/user/internal/infrastructure/subscription"
+	"github.com/subscription-tracker/user/internal/infrastructure/webauthn"
 	"github.com/subscription-tracker/user/internal/interface/http/handlers"
 	"github.com/subscription-tracker/user/internal/interface/http/middleware"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -26,11 +39,26 @@ func NewApplication(db *mongo.Client) (*Application, error) {
 		DB:     db.Database("subs"),
 	}
 
+	// Forwarded client addresses are only taken from the proxies listed in TRUSTED_PROXIES,
+	// so clients cannot choose the IP they are throttled by
+	if err := app.Router.SetTrustedProxies(trustedProxies()); err != nil {
+		return nil, err
+	}
+
 	// Configure CORS
 	app.Router.Use(func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		// The app itself may send credentials, so the cookie binding a link request to the
+		// browser that started it is kept
+		if origin := c.GetHeader("Origin"); origin != "" && origin == application.FrontendURL() {
+			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+			c.Writer.Header().Add("Vary", "Origin")
+		} else {
+			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Retry-After")
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
 			return
@@ -40,6 +68,50 @@ func NewApplication(db *mongo.Client) (*Application, error) {
 
 	// Initialize repositories
 	userRepo := mongodb.NewUserRepository(app.DB)
+	identityRepo, err := mongodb.NewIdentityRepository(app.DB)
+	if err != nil {
+		return nil, err
+	}
+	sessionRepo, err := mongodb.NewSessionRepository(app.DB)
+	if err != nil {
+		return nil, err
+	}
+	refreshTokenRepo, err := mongodb.NewRefreshTokenRepository(app.DB)
+	if err != nil {
+		return nil, err
+	}
+	loginTokenRepo, err := mongodb.NewMongoTokenRepository(app.DB)
+	if err != nil {
+		return nil, err
+	}
+	passwordResetRepo, err := mongodb.NewPasswordResetRepository(app.DB)
+	if err != nil {
+		return nil, err
+	}
+	loginAttemptRepo, err := mongodb.NewLoginAttemptRepository(app.DB)
+	if err != nil {
+		return nil, err
+	}
+	dataExportRepo, err := mongodb.NewDataExportRepository(app.DB)
+	if err != nil {
+		return nil, err
+	}
+	loginCodeRepo, err := mongodb.NewLoginCodeRepository(app.DB)
+	if err != nil {
+		return nil, err
+	}
+	passkeyChallengeRepo, err := mongodb.NewPasskeyChallengeRepository(app.DB)
+	if err != nil {
+		return nil, err
+	}
+	exportsDir := os.Getenv("EXPORTS_DIR")
+	if exportsDir == "" {
+		exportsDir = "data/exports"
+	}
+	exportFiles, err := filestore.NewLocalStore(exportsDir)
+	if err != nil {
+		return nil, err
+	}
 	emailService := email.NewEmailService(&email.SMTPConfig{
 		Host:     "smtp.gmail.com",
 		Port:     587,
@@ -48,32 +120,214 @@ func NewApplication(db *mongo.Client) (*Application, error) {
 		From:     "subs@tracker.com",
 	})
 
+	spotifyClient := spotify.NewClient(os.Getenv("SPOTIFY_API_URL"), nil)
+	subscriptionURL := os.Getenv("SUBSCRIPTION_SERVICE_URL")
+	if subscriptionURL == "" {
+		subscriptionURL = "http://localhost:8081"
+	}
+	subscriptionClient := subscription.NewClient(subscriptionURL, nil)
+
+	providers, err := loginProviders()
+	if err != nil {
+		return nil, err
+	}
+
 	// Initialize services
-	userService := application.NewUserService(userRepo, emailService)
+	issuer := os.Getenv("JWT_ISSUER")
+	if issuer == "" {
+		issuer = "subscription-tracker-user"
+	}
+	var retiredKeys []string
+	if value := os.Getenv("JWT_RETIRED_KEYS"); value != "" {
+		retiredKeys = strings.Split(value, ",")
+	}
+	// JWT_EPHEMERAL_KEY=true lets a development setup run without a signing key
+	allowEphemeral := os.Getenv("JWT_EPHEMERAL_KEY") == "true"
+	keys, err := jwtkeys.Load(issuer, os.Getenv("JWT_SIGNING_KEY"), retiredKeys, allowEphemeral)
+	if errors.Is(err, jwtkeys.ErrNoSigningKey) {
+		return nil, fmt.Errorf("%w: set JWT_SIGNING_KEY to a PEM private key, or JWT_EPHEMERAL_KEY=true for development", err)
+	} else if err != nil {
+		return nil, err
+	}
+
+	rateLimits := application.RateLimits{
+		LoginLinkPerEmail:     ratelimit.NewLimiter(5, time.Hour),
+		LoginLinkPerIP:        ratelimit.NewLimiter(20, time.Hour),
+		VerificationPerUser:   ratelimit.NewLimiter(3, time.Hour),
+		PasswordResetPerEmail: ratelimit.NewLimiter(5, time.Hour),
+		DataExportPerUser:     ratelimit.NewLimiter(3, 24*time.Hour),
+	}
+	userService := application.NewUserService(userRepo, emailService, spotifyClient, subscriptionClient, providers, identityRepo, sessionRepo, refreshTokenRepo, loginTokenRepo, rateLimits, keys, passwordResetRepo, relyingParty(), loginAttemptRepo, dataExportRepo, exportFiles, loginCodeRepo, passkeyChallengeRepo)
+	if value := os.Getenv("ADMIN_EMAILS"); value != "" {
+		userService.PromoteAdmins(strings.Split(value, ","))
+	}
+	go userService.RunAccountPurger(context.Background(), time.Hour)
+	go userService.RunExportWorker(context.Background(), 30*time.Second)
 
 	// Initialize handlers
-	userHandler := handlers.NewUserHandler(userService)
+	secure, err := secureCookies()
+	if err != nil {
+		return nil, err
+	}
+	userHandler := handlers.NewUserHandler(userService, application.FrontendURL(), secure)
+	jwksHandler := handlers.NewJWKSHandler(keys)
 
 	// Register routes
+	app.Router.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
 	api := app.Router.Group("/api")
 	{
-		protected := api.Group("", middleware.AuthMiddleware([]byte(os.Getenv("JWT_SECRET"))))
+		protected := api.Group("", middleware.AuthMiddleware(keys, application.AccessTokenAudience()))
 		{
 			protected.GET("/me", userHandler.GetCurrentUser)
+			protected.GET("/me/preferences", userHandler.GetPreferences)
+			protected.PATCH("/me/preferences", userHandler.UpdatePreferences)
+			protected.POST("/me/spotify/sync", userHandler.SyncSpotify)
+			protected.POST("/me/email/verification", userHandler.ResendVerificationEmail)
+			protected.POST("/me/2fa/totp", userHandler.EnrolTOTP)
+			protected.POST("/me/2fa/totp/confirm", userHandler.ConfirmTOTP)
+			protected.POST("/me/2fa/totp/disable", userHandler.DisableTOTP)
+			protected.POST("/me/passkeys/register/options", userHandler.BeginPasskeyRegistration)
+			protected.POST("/me/passkeys/register", userHandler.RegisterPasskey)
+			protected.GET("/me/passkeys", userHandler.ListPasskeys)
+			protected.PUT("/me/passkeys/:id", userHandler.RenamePasskey)
+			protected.DELETE("/me/passkeys/:id", userHandler.DeletePasskey)
+			protected.GET("/sessions", userHandler.ListSessions)
+			protected.DELETE("/sessions/:id", userHandler.RevokeSession)
+			protected.POST("/logout", userHandler.Logout)
+			protected.POST("/logout/all", userHandler.LogoutEverywhere)
+			protected.GET("/me/identities", userHandler.GetLoginMethods)
+			protected.POST("/me/identities/:provider/link", userHandler.LinkIdentity)
+			protected.DELETE("/me/identities/:provider", userHandler.UnlinkIdentity)
+			protected.GET("/users/:id", userHandler.GetUser)
+			protected.PUT("/users/:id", userHandler.UpdateUser)
+			protected.DELETE("/users/:id", userHandler.DeleteUser)
+			protected.POST("/users/:id/restore", userHandler.RestoreUser)
+			protected.POST("/me/exports", middleware.RequireVerifiedEmail(), userHandler.RequestDataExport)
+			protected.GET("/me/exports", userHandler.ListDataExports)
+			protected.GET("/admin/users", userHandler.ListUsers)
+			protected.PUT("/admin/users/:id/role", userHandler.SetUserRole)
 		}
 		// User routes
 		api.POST("/register", userHandler.Register)
 		api.POST("/login", userHandler.Login)
+		api.POST("/login/exchange", userHandler.ExchangeLoginCode)
+		api.POST("/login/2fa", userHandler.CompleteTwoFactorLogin)
+		api.POST("/login/2fa/passkey/options", userHandler.BeginTwoFactorPasskey)
+		api.POST("/login/2fa/passkey", userHandler.CompleteTwoFactorPasskey)
+		api.POST("/passkeys/login/options", userHandler.BeginPasskeyLogin)
+		api.POST("/passkeys/login", userHandler.PasskeyLogin)
+		api.POST("/token/refresh", userHandler.RefreshToken)
+		api.POST("/email/verify", userHandler.VerifyEmail)
+		api.POST("/password/forgot", userHandler.ForgotPassword)
+		api.POST("/password/reset", userHandler.ResetPassword)
+		api.POST("/account/restore", userHandler.RestoreAccount)
+		api.GET("/exports/:id/download", userHandler.DownloadDataExport)
 		api.POST("/passwordless/initiate", userHandler.InitiatePasswordlessLogin)
 		api.POST("/passwordless/verify", userHandler.VerifyLoginToken)
-		api.PUT("/users/:id", userHandler.UpdateUser)
-		api.DELETE("/users/:id", userHandler.DeleteUser)
 
 		// Spotify OAuth routes
 		api.GET("/auth/spotify", userHandler.InitiateLogin)
 		api.GET("/spotify-callback", userHandler.HandleCallback)
 
+		// External login provider routes
+		api.GET("/auth/providers", userHandler.ListLoginProviders)
+		api.GET("/auth/:provider", userHandler.InitiateProviderLogin)
+		api.GET("/auth/:provider/callback", userHandler.HandleProviderCallback)
+
 	}
 
 	return app, nil
 }
+
+// secureCookies reports whether cookies are only sent over HTTPS. COOKIE_SECURE sets it
+// explicitly; otherwise cookies are secure when the frontend is served over HTTPS.
+func secureCookies() (bool, error) {
+	if value := os.Getenv("COOKIE_SECURE"); value != "" {
+		secure, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, fmt.Errorf("invalid COOKIE_SECURE %q: %w", value, err)
+		}
+		return secure, nil
+	}
+	return strings.HasPrefix(application.FrontendURL(), "https://"), nil
+}
+
+// trustedProxies returns the addresses or CIDR ranges of the reverse proxies in front of
+// the service, separated by commas in TRUSTED_PROXIES. None are trusted by default.
+func trustedProxies() []string {
+	value := os.Getenv("TRUSTED_PROXIES")
+	if value == "" {
+		return nil
+	}
+	var proxies []string
+	for _, proxy := range strings.Split(value, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// relyingParty configures passkeys for the frontend's domain. WEBAUTHN_ORIGINS lists
+// the origins allowed to use them, separated by commas.
+func relyingParty() *webauthn.RelyingParty {
+	id := os.Getenv("WEBAUTHN_RP_ID")
+	if id == "" {
+		id = "localhost"
+	}
+	name := os.Getenv("WEBAUTHN_RP_NAME")
+	if name == "" {
+		name = "Subscription Tracker"
+	}
+	origins := []string{strings.TrimSuffix(application.FrontendURL(), "/")}
+	if value := os.Getenv("WEBAUTHN_ORIGINS"); value != "" {
+		origins = strings.Split(value, ",")
+	}
+	return webauthn.NewRelyingParty(id, name, origins)
+}
+
+// loginProviders registers the external login providers configured in the environment.
+// A provider is enabled by setting its client ID.
+func loginProviders() (*application.ProviderRegistry, error) {
+	registry := application.NewProviderRegistry()
+	if clientID := os.Getenv("GOOGLE_CLIENT_ID"); clientID != "" {
+		err := registry.Register(oauth.NewGoogleProvider(oauth.Config{
+			ClientID:     clientID,
+			ClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("GOOGLE_REDIRECT_URL"),
+		}, nil))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if clientID := os.Getenv("GITHUB_CLIENT_ID"); clientID != "" {
+		err := registry.Register(oauth.NewGitHubProvider(oauth.Config{
+			ClientID:     clientID,
+			ClientSecret: os.Getenv("GITHUB_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("GITHUB_REDIRECT_URL"),
+		}, os.Getenv("GITHUB_URL"), os.Getenv("GITHUB_API_URL"), nil))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if issuer := os.Getenv("OIDC_ISSUER"); issuer != "" {
+		name := os.Getenv("OIDC_PROVIDER_NAME")
+		if name == "" {
+			name = "oidc"
+		}
+		var scopes []string
+		if value := os.Getenv("OIDC_SCOPES"); value != "" {
+			scopes = strings.Fields(value)
+		}
+		err := registry.Register(oauth.NewOIDCProvider(name, issuer, oauth.Config{
+			ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+			Scopes:       scopes,
+		}, nil))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies string
+		want    string
+	}{
+		{name: "none trusted by default", want: "203.0.113.7"},
+		{name: "peer is not a trusted proxy", proxies: "10.0.0.0/8", want: "203.0.113.7"},
+		{name: "peer is a trusted proxy", proxies: "10.0.0.0/8, 203.0.113.7", want: "198.51.100.1"},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TRUSTED_PROXIES", tt.proxies)
+			router := gin.New()
+			if err := router.SetTrustedProxies(trustedProxies()); err != nil {
+				t.Fatal(err)
+			}
+			var got string
+			router.GET("/", func(c *gin.Context) { got = c.ClientIP() })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "203.0.113.7:4711"
+			req.Header.Set("X-Forwarded-For", "198.51.100.1")
+			router.ServeHTTP(httptest.NewRecorder(), req)
+			if got != tt.want {
+				t.Errorf("client IP = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecureCookies(t *testing.T) {
+	tests := []struct {
+		name        string
+		secure      string
+		frontendURL string
+		want        bool
+		wantErr     bool
+	}{
+		{name: "local frontend", want: false},
+		{name: "https frontend", frontendURL: "https://tracker.example.com", want: true},
+		{name: "explicitly off", secure: "false", frontendURL: "https://tracker.example.com", want: false},
+		{name: "explicitly on", secure: "true", frontendURL: "http://localhost:3000", want: true},
+		{name: "invalid", secure: "sometimes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("COOKIE_SECURE", tt.secure)
+			t.Setenv("FRONTEND_URL", tt.frontendURL)
+			got, err := secureCookies()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("secureCookies accepted an invalid COOKIE_SECURE")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("secureCookies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
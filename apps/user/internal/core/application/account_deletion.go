@@ -0,0 +1,226 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+const (
+	// UserDeletedEvent tells other services to purge the data of a deleted user
+	UserDeletedEvent = "user.deleted"
+	// purgeBatchSize bounds the accounts purged per run
+	purgeBatchSize = 50
+)
+
+var (
+	ErrAccountPendingDeletion    = errors.New("account is scheduled for deletion")
+	ErrAccountNotPendingDeletion = errors.New("account is not scheduled for deletion")
+)
+
+// UserEvent is published to the other services when something happens to an account
+type UserEvent struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	UserID     string    `json:"userId"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+// deletionGracePeriod is how long a deleted account can be restored, configurable
+// through ACCOUNT_DELETION_GRACE_PERIOD
+func deletionGracePeriod() time.Duration {
+	if period, err := time.ParseDuration(os.Getenv("ACCOUNT_DELETION_GRACE_PERIOD")); err == nil && period >= 0 {
+		return period
+	}
+	return 14 * 24 * time.Hour
+}
+
+// DeleteUser schedules the deletion of a user the actor may delete. The account is locked
+// and signed out at once, and purged everywhere when the grace period ends unless it is
+// restored with the link emailed to the user.
+func (s *UserService) DeleteUser(actorID string, id string) (*domain.AccountDeletion, error) {
+	if err := s.authorize(actorID, ActionDeleteUser, id); err != nil {
+		return nil, err
+	}
+	user, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if user.PendingDeletion() {
+		return user.Deletion, nil
+	}
+
+	now := time.Now()
+	user.Deletion = &domain.AccountDeletion{
+		RequestedAt: now,
+		RequestedBy: actorID,
+		PurgeAfter:  now.Add(deletionGracePeriod()),
+	}
+	if err := s.repo.Update(user); err != nil {
+		return nil, err
+	}
+	if err := s.RevokeAllSessions(id); err != nil {
+		return nil, err
+	}
+	if err := s.sendRestoreEmail(user); err != nil {
+		log.Printf("Failed to send account restore email: %v", err)
+	}
+	return user.Deletion, nil
+}
+
+// sendRestoreEmail emails a link that cancels the deletion. The token names the deletion
+// request, so it cannot cancel a later one.
+func (s *UserService) sendRestoreEmail(user *domain.User) error {
+	token, err := s.signer.Sign(jwt.MapClaims{
+		"user_id":      user.ID,
+		"requested_at": user.Deletion.RequestedAt.Unix(),
+		"aud":          s.signer.Issuer(),
+		"exp":          user.Deletion.PurgeAfter.Unix(),
+		"type":         "restore_account",
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/restore-account?token=%s", FrontendURL(), token)
+	return s.emailService.Send(user.Email, "Your account will be deleted",
+		fmt.Sprintf("Your account and all its subscriptions will be deleted on %s. To keep your account, click the link: %s",
+			user.Deletion.PurgeAfter.UTC().Format(time.RFC1123), link))
+}
+
+// RestoreAccount cancels the deletion named in an emailed restore token
+func (s *UserService) RestoreAccount(token string) error {
+	claims, err := s.signer.Verify(token, s.signer.Issuer())
+	if err != nil || claims["type"] != "restore_account" {
+		return ErrInvalidToken
+	}
+	userID, ok := claims["user_id"].(string)
+	if !ok {
+		return ErrInvalidToken
+	}
+	requestedAt, ok := claims["requested_at"].(float64)
+	if !ok {
+		return ErrInvalidToken
+	}
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if !user.PendingDeletion() || user.Deletion.RequestedAt.Unix() != int64(requestedAt) {
+		return ErrAccountNotPendingDeletion
+	}
+	return s.restore(user)
+}
+
+// RestoreUser cancels the pending deletion of a user on behalf of an admin
+func (s *UserService) RestoreUser(actorID string, id string) error {
+	if err := s.authorize(actorID, ActionDeleteUser, id); err != nil {
+		return err
+	}
+	user, err := s.repo.FindByID(id)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if !user.PendingDeletion() {
+		return ErrAccountNotPendingDeletion
+	}
+	return s.restore(user)
+}
+
+func (s *UserService) restore(user *domain.User) error {
+	// The purge may have started; the other services' data might be gone already
+	if time.Now().After(user.Deletion.PurgeAfter) {
+		return ErrAccountNotPendingDeletion
+	}
+	user.Deletion = nil
+	return s.repo.Update(user)
+}
+
+// RunAccountPurger purges the accounts whose grace period ended, every interval until
+// ctx is done
+func (s *UserService) RunAccountPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.PurgeDeletedAccounts(ctx); err != nil {
+			log.Printf("Failed to purge deleted accounts: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeDeletedAccounts publishes a user-deleted event for every account past its grace
+// period and removes the account once the other services confirmed they purged it.
+// Accounts whose event was not confirmed are retried on the next run.
+func (s *UserService) PurgeDeletedAccounts(ctx context.Context) error {
+	users, err := s.repo.FindDueForPurge(time.Now(), purgeBatchSize)
+	if err != nil {
+		return err
+	}
+	for i := range users {
+		if err := s.purge(ctx, &users[i]); err != nil {
+			log.Printf("Failed to purge user %s: %v", users[i].ID, err)
+			users[i].Deletion.PurgeAttempts++
+			if err := s.repo.Update(&users[i]); err != nil {
+				log.Printf("Failed to record purge attempt of user %s: %v", users[i].ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *UserService) purge(ctx context.Context, user *domain.User) error {
+	event := UserEvent{
+		// The ID stays the same across retries so services can deduplicate the event
+		ID:         fmt.Sprintf("%s-%d", user.ID, user.Deletion.RequestedAt.Unix()),
+		Type:       UserDeletedEvent,
+		UserID:     user.ID,
+		OccurredAt: user.Deletion.PurgeAfter,
+	}
+	token, err := s.serviceToken(ScopeUserDeletion, jwt.MapClaims{"event_id": event.ID, "user_id": event.UserID})
+	if err != nil {
+		return err
+	}
+	if err := s.subscriptionClient.PublishUserEvent(ctx, token, event); err != nil {
+		return err
+	}
+
+	if err := s.identities.DeleteByUserID(user.ID); err != nil {
+		return err
+	}
+	if err := s.refreshTokens.DeleteByUserID(user.ID); err != nil {
+		return err
+	}
+	if err := s.sessions.DeleteByUserID(user.ID); err != nil {
+		return err
+	}
+	if err := s.loginTokens.DeleteByUserID(user.ID); err != nil {
+		return err
+	}
+	if err := s.passwordResets.DeleteByUserID(user.ID); err != nil {
+		return err
+	}
+	if err := s.loginAttempts.Clear(accountAttemptKey(user.Email)); err != nil {
+		return err
+	}
+	exports, err := s.dataExports.FindByUserID(user.ID)
+	if err != nil {
+		return err
+	}
+	for _, export := range exports {
+		if err := s.deleteDataExport(export); err != nil {
+			return err
+		}
+	}
+	return s.repo.Delete(user.ID)
+}
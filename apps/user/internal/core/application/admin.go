@@ -0,0 +1,94 @@
+package application
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+var ErrInvalidRole = errors.New("invalid role")
+
+// UserFilter narrows an admin user listing. Query matches part of the email or name.
+type UserFilter struct {
+	Query string
+	Role  domain.Role
+}
+
+// UserPage is one page of an admin user listing
+type UserPage struct {
+	Users    []domain.User `json:"users"`
+	Total    int64         `json:"total"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"page_size"`
+}
+
+// ListUsers returns a page of the users matching filter. Pages start at 1.
+func (s *UserService) ListUsers(actorID string, filter UserFilter, page int, pageSize int) (*UserPage, error) {
+	if err := s.authorize(actorID, ActionListUsers, ""); err != nil {
+		return nil, err
+	}
+	if filter.Role != "" && !filter.Role.Valid() {
+		return nil, ErrInvalidRole
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+
+	users, total, err := s.repo.List(filter, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &UserPage{Users: users, Total: total, Page: page, PageSize: pageSize}, nil
+}
+
+// SetRole changes the role of another user
+func (s *UserService) SetRole(actorID string, userID string, role domain.Role) error {
+	if err := s.authorize(actorID, ActionSetRole, userID); err != nil {
+		return err
+	}
+	if !role.Valid() {
+		return ErrInvalidRole
+	}
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	user.Role = role
+	return s.repo.Update(user)
+}
+
+// PromoteAdmins gives the admin role to the existing users with the given emails, so a
+// deployment can bootstrap its first admins. Users who have not verified their email are
+// skipped, as anyone could have registered the address.
+func (s *UserService) PromoteAdmins(emails []string) {
+	for _, email := range emails {
+		email = strings.TrimSpace(email)
+		user, err := s.repo.FindByEmail(email)
+		if err != nil {
+			log.Printf("Cannot promote %s to admin: user not found", email)
+			continue
+		}
+		if !user.EmailVerified {
+			log.Printf("Cannot promote %s to admin: email not verified", email)
+			continue
+		}
+		if user.Role == domain.RoleAdmin {
+			continue
+		}
+		user.Role = domain.RoleAdmin
+		if err := s.repo.Update(user); err != nil {
+			log.Printf("Failed to promote %s to admin: %v", email, err)
+		}
+	}
+}
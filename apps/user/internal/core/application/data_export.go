@@ -0,0 +1,306 @@
+package application
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+const (
+	// dataExportTTL is how long a finished archive can be downloaded
+	dataExportTTL = 48 * time.Hour
+	// dataExportTimeout is how long building an archive may take before another worker retries it
+	dataExportTimeout = 30 * time.Minute
+)
+
+var (
+	ErrDataExportInProgress = errors.New("a data export is already in progress")
+	ErrDataExportNotFound   = errors.New("data export not found")
+)
+
+// DataExportRepository stores data export requests and works through them as a queue
+type DataExportRepository interface {
+	Create(export *domain.DataExport) error
+	FindByID(id string) (*domain.DataExport, error)
+	FindByUserID(userID string) ([]domain.DataExport, error)
+	ClaimNext(staleBefore time.Time) (*domain.DataExport, error)
+	Update(export *domain.DataExport) error
+	FindExpired(before time.Time) ([]domain.DataExport, error)
+	Delete(id string) error
+}
+
+// FileStore stores files by key
+type FileStore interface {
+	Put(key string, content io.Reader) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// PublicURL is the base URL the user service is reachable at in emailed links,
+// configurable through PUBLIC_URL
+func PublicURL() string {
+	if url := os.Getenv("PUBLIC_URL"); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+	return "http://localhost:8080"
+}
+
+// RequestDataExport queues an archive of everything stored about the user. The user is
+// emailed a download link once it is ready.
+func (s *UserService) RequestDataExport(userID string) (*domain.DataExport, error) {
+	if _, err := s.repo.FindByID(userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+	exports, err := s.dataExports.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, export := range exports {
+		if export.Status == domain.DataExportPending || export.Status == domain.DataExportRunning {
+			return nil, ErrDataExportInProgress
+		}
+	}
+	if !s.limits.DataExportPerUser.Allow(userID) {
+		return nil, ErrRateLimited
+	}
+
+	export := &domain.DataExport{
+		UserID:    userID,
+		Status:    domain.DataExportPending,
+		CreatedAt: time.Now(),
+	}
+	if err := s.dataExports.Create(export); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// ListDataExports returns the user's data exports, newest first
+func (s *UserService) ListDataExports(userID string) ([]domain.DataExport, error) {
+	return s.dataExports.FindByUserID(userID)
+}
+
+// OpenDataExport returns a ready export named in an emailed download token and a reader
+// over its archive; the caller must close it
+func (s *UserService) OpenDataExport(id string, token string) (*domain.DataExport, io.ReadCloser, error) {
+	claims, err := s.signer.Verify(token, s.signer.Issuer())
+	if err != nil || claims["type"] != "data_export" || claims["export_id"] != id {
+		return nil, nil, ErrInvalidToken
+	}
+	export, err := s.dataExports.FindByID(id)
+	if err != nil || export.Status != domain.DataExportReady || time.Now().After(*export.ExpiresAt) {
+		return nil, nil, ErrDataExportNotFound
+	}
+	content, err := s.exportFiles.Get(export.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return export, content, nil
+}
+
+// RunExportWorker builds the queued data exports and removes expired archives, every
+// interval until ctx is done
+func (s *UserService) RunExportWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for {
+			export, err := s.dataExports.ClaimNext(time.Now().Add(-dataExportTimeout))
+			if err != nil {
+				break
+			}
+			s.processDataExport(ctx, export)
+		}
+		if err := s.removeExpiredDataExports(); err != nil {
+			log.Printf("Failed to remove expired data exports: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *UserService) processDataExport(ctx context.Context, export *domain.DataExport) {
+	ctx, cancel := context.WithTimeout(ctx, dataExportTimeout)
+	defer cancel()
+
+	now := time.Now()
+	expiresAt := now.Add(dataExportTTL)
+	export.CompletedAt = &now
+	export.ExpiresAt = &expiresAt
+	export.StorageKey = fmt.Sprintf("%s/%s.zip", export.UserID, export.ID)
+	if err := s.buildDataExport(ctx, export); err != nil {
+		log.Printf("Failed to build data export %s: %v", export.ID, err)
+		export.Status = domain.DataExportFailed
+		export.Error = err.Error()
+		export.StorageKey = ""
+		if err := s.dataExports.Update(export); err != nil {
+			log.Printf("Failed to record failed data export %s: %v", export.ID, err)
+		}
+		return
+	}
+
+	export.Status = domain.DataExportReady
+	if err := s.dataExports.Update(export); err != nil {
+		log.Printf("Failed to record data export %s: %v", export.ID, err)
+		return
+	}
+	if err := s.sendDataExportEmail(export); err != nil {
+		log.Printf("Failed to send data export email: %v", err)
+	}
+}
+
+// exportedAttachments is the part of the subscription service's export naming the attachment files
+type exportedAttachments struct {
+	Subscriptions []struct {
+		Uuid        string `json:"uuid"`
+		Attachments []struct {
+			Uuid     string `json:"uuid"`
+			FileName string `json:"fileName"`
+		} `json:"attachments"`
+	} `json:"subscriptions"`
+}
+
+// buildDataExport writes the ZIP archive of the export: the user's profile, linked
+// identities and sessions, the subscription service's export and the attachment files
+func (s *UserService) buildDataExport(ctx context.Context, export *domain.DataExport) error {
+	user, err := s.repo.FindByID(export.UserID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	identities, err := s.identities.FindByUserID(user.ID)
+	if err != nil {
+		return err
+	}
+	sessions, err := s.sessions.FindByUserID(user.ID)
+	if err != nil {
+		return err
+	}
+	token, err := s.serviceToken(ScopeUserExport, jwt.MapClaims{"user_id": user.ID})
+	if err != nil {
+		return err
+	}
+	subscriptions, err := s.subscriptionClient.ExportUserData(ctx, token)
+	if err != nil {
+		return err
+	}
+	var attachments exportedAttachments
+	if err := json.Unmarshal(subscriptions, &attachments); err != nil {
+		return err
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(s.writeDataExport(ctx, writer, user, identities, sessions, subscriptions, attachments))
+	}()
+	err = s.exportFiles.Put(export.StorageKey, reader)
+	reader.CloseWithError(err)
+	return err
+}
+
+func (s *UserService) writeDataExport(ctx context.Context, w io.Writer, user *domain.User, identities []domain.Identity, sessions []domain.Session, subscriptions []byte, attachments exportedAttachments) error {
+	archive := zip.NewWriter(w)
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", user},
+		{"preferences.json", user.EffectivePreferences()},
+		{"identities.json", identities},
+		{"sessions.json", sessions},
+		{"subscriptions.json", json.RawMessage(subscriptions)},
+	}
+	for _, file := range files {
+		data, err := json.MarshalIndent(file.data, "", "  ")
+		if err != nil {
+			return err
+		}
+		f, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(data); err != nil {
+			return err
+		}
+	}
+
+	for _, subscription := range attachments.Subscriptions {
+		for _, attachment := range subscription.Attachments {
+			token, err := s.serviceToken(ScopeUserExport, jwt.MapClaims{"user_id": user.ID})
+			if err != nil {
+				return err
+			}
+			content, err := s.subscriptionClient.DownloadAttachment(ctx, token, attachment.Uuid)
+			if err != nil {
+				return err
+			}
+			name := path.Join("attachments", path.Base(subscription.Uuid), path.Base(attachment.Uuid+"-"+attachment.FileName))
+			f, err := archive.Create(name)
+			if err == nil {
+				_, err = io.Copy(f, content)
+			}
+			content.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return archive.Close()
+}
+
+// sendDataExportEmail emails a download link valid until the archive expires
+func (s *UserService) sendDataExportEmail(export *domain.DataExport) error {
+	user, err := s.repo.FindByID(export.UserID)
+	if err != nil {
+		return err
+	}
+	token, err := s.signer.Sign(jwt.MapClaims{
+		"export_id": export.ID,
+		"aud":       s.signer.Issuer(),
+		"exp":       export.ExpiresAt.Unix(),
+		"type":      "data_export",
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/api/exports/%s/download?token=%s", PublicURL(), export.ID, token)
+	return s.emailService.Send(user.Email, "Your data export is ready",
+		fmt.Sprintf("Your data export is ready. Download it before %s: %s", export.ExpiresAt.UTC().Format(time.RFC1123), link))
+}
+
+// removeExpiredDataExports deletes the archives and records of expired exports
+func (s *UserService) removeExpiredDataExports() error {
+	exports, err := s.dataExports.FindExpired(time.Now())
+	if err != nil {
+		return err
+	}
+	for _, export := range exports {
+		if err := s.deleteDataExport(export); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *UserService) deleteDataExport(export domain.DataExport) error {
+	if export.StorageKey != "" {
+		if err := s.exportFiles.Delete(export.StorageKey); err != nil {
+			return err
+		}
+	}
+	return s.dataExports.Delete(export.ID)
+}
@@ -0,0 +1,349 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+// fakeUserRepository keeps users in memory; methods the tests do not need panic
+type fakeUserRepository struct {
+	UserRepository
+	users   map[string]domain.User
+	updates int
+}
+
+func (r *fakeUserRepository) Create(user *domain.User) error {
+	user.ID = fmt.Sprintf("user-%d", len(r.users)+1)
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *fakeUserRepository) FindByID(id string) (*domain.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	return &user, nil
+}
+
+func (r *fakeUserRepository) FindByEmail(email string) (*domain.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+func (r *fakeUserRepository) Update(user *domain.User) error {
+	r.users[user.ID] = *user
+	r.updates++
+	return nil
+}
+
+func (r *fakeUserRepository) FindByPasskeyID(id string) (*domain.User, error) {
+	for _, user := range r.users {
+		if user.FindPasskey(id) != nil {
+			return &user, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+// fakeIdentityRepository keeps identities in memory
+type fakeIdentityRepository struct {
+	identities []domain.Identity
+}
+
+func (r *fakeIdentityRepository) Create(identity *domain.Identity) error {
+	r.identities = append(r.identities, *identity)
+	return nil
+}
+
+func (r *fakeIdentityRepository) FindByProviderSubject(provider string, subject string) (*domain.Identity, error) {
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return &identity, nil
+		}
+	}
+	return nil, errors.New("identity not found")
+}
+
+func (r *fakeIdentityRepository) FindByUserID(userID string) ([]domain.Identity, error) {
+	var identities []domain.Identity
+	for _, identity := range r.identities {
+		if identity.UserID == userID {
+			identities = append(identities, identity)
+		}
+	}
+	return identities, nil
+}
+
+func (r *fakeIdentityRepository) Delete(userID string, provider string) error {
+	kept := r.identities[:0]
+	for _, identity := range r.identities {
+		if identity.UserID != userID || identity.Provider != provider {
+			kept = append(kept, identity)
+		}
+	}
+	r.identities = kept
+	return nil
+}
+
+func (r *fakeIdentityRepository) DeleteByUserID(userID string) error {
+	kept := r.identities[:0]
+	for _, identity := range r.identities {
+		if identity.UserID != userID {
+			kept = append(kept, identity)
+		}
+	}
+	r.identities = kept
+	return nil
+}
+
+// fakeSigner signs tokens with HMAC and checks the audience like the key set does
+type fakeSigner struct{}
+
+func (fakeSigner) Issuer() string { return "user-service" }
+
+func (fakeSigner) Sign(claims jwt.MapClaims) (string, error) {
+	claims["iss"] = "user-service"
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-secret"))
+}
+
+func (fakeSigner) Verify(tokenString string, audience string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(*jwt.Token) (interface{}, error) {
+		return []byte("test-secret"), nil
+	}, jwt.WithAudience(audience), jwt.WithExpirationRequired())
+	return claims, err
+}
+
+// fakeLoginCodeRepository keeps login codes in memory
+type fakeLoginCodeRepository struct {
+	codes map[string]fakeLoginCode
+}
+
+type fakeLoginCode struct {
+	userID string
+	expiry time.Time
+}
+
+func (r *fakeLoginCodeRepository) Store(code string, userID string, expiry time.Time) error {
+	if r.codes == nil {
+		r.codes = map[string]fakeLoginCode{}
+	}
+	r.codes[code] = fakeLoginCode{userID: userID, expiry: expiry}
+	return nil
+}
+
+func (r *fakeLoginCodeRepository) Consume(code string) (string, error) {
+	stored, ok := r.codes[code]
+	delete(r.codes, code)
+	if !ok || !stored.expiry.After(time.Now()) {
+		return "", errors.New("login code not found")
+	}
+	return stored.userID, nil
+}
+
+// fakeLoginTokenRepository keeps login links in memory by token
+type fakeLoginTokenRepository struct {
+	tokens map[string]string
+}
+
+func (r *fakeLoginTokenRepository) Store(token string, binding string, userID string, expiry time.Time) error {
+	if r.tokens == nil {
+		r.tokens = map[string]string{}
+	}
+	r.tokens[token] = userID
+	return nil
+}
+
+func (r *fakeLoginTokenRepository) Verify(token string, binding string) (string, error) {
+	userID, ok := r.tokens[token]
+	if !ok {
+		return "", errors.New("login token not found")
+	}
+	delete(r.tokens, token)
+	return userID, nil
+}
+
+func (r *fakeLoginTokenRepository) DeleteByUserID(userID string) error {
+	for token, owner := range r.tokens {
+		if owner == userID {
+			delete(r.tokens, token)
+		}
+	}
+	return nil
+}
+
+// fakePasswordResetRepository keeps reset links in memory by token
+type fakePasswordResetRepository struct {
+	tokens map[string]string
+}
+
+func (r *fakePasswordResetRepository) Store(token string, userID string, expiry time.Time) error {
+	if r.tokens == nil {
+		r.tokens = map[string]string{}
+	}
+	r.tokens[token] = userID
+	return nil
+}
+
+func (r *fakePasswordResetRepository) Consume(token string) (string, error) {
+	userID, ok := r.tokens[token]
+	if !ok {
+		return "", errors.New("reset token not found")
+	}
+	delete(r.tokens, token)
+	return userID, nil
+}
+
+func (r *fakePasswordResetRepository) DeleteByUserID(userID string) error {
+	for token, owner := range r.tokens {
+		if owner == userID {
+			delete(r.tokens, token)
+		}
+	}
+	return nil
+}
+
+// fakeSessionRepository records created sessions and whose sessions were revoked
+type fakeSessionRepository struct {
+	SessionRepository
+	created []domain.Session
+	revoked []string
+}
+
+func (r *fakeSessionRepository) Create(session *domain.Session) error {
+	session.ID = fmt.Sprintf("session-%d", len(r.created)+1)
+	r.created = append(r.created, *session)
+	return nil
+}
+
+func (r *fakeSessionRepository) RevokeByUserID(userID string) error {
+	r.revoked = append(r.revoked, userID)
+	return nil
+}
+
+type fakeRefreshTokenRepository struct {
+	RefreshTokenRepository
+}
+
+func (r *fakeRefreshTokenRepository) Store(token *domain.RefreshToken) error {
+	return nil
+}
+
+func (r *fakeRefreshTokenRepository) DeleteByUserID(userID string) error {
+	return nil
+}
+
+// fakeLoginAttemptRepository keeps failed attempts and lockouts in memory by key
+type fakeLoginAttemptRepository struct {
+	attempts map[string][]time.Time
+	locks    map[string]time.Time
+}
+
+func (r *fakeLoginAttemptRepository) Record(key string, at time.Time, expiry time.Time) error {
+	if r.attempts == nil {
+		r.attempts = map[string][]time.Time{}
+	}
+	r.attempts[key] = append(r.attempts[key], at)
+	return nil
+}
+
+func (r *fakeLoginAttemptRepository) Failures(key string, since time.Time) (int, time.Time, error) {
+	count, latest := 0, time.Time{}
+	for _, at := range r.attempts[key] {
+		if at.After(since) {
+			count++
+			if at.After(latest) {
+				latest = at
+			}
+		}
+	}
+	return count, latest, nil
+}
+
+func (r *fakeLoginAttemptRepository) Clear(key string) error {
+	delete(r.attempts, key)
+	return nil
+}
+
+func (r *fakeLoginAttemptRepository) Lock(key string, until time.Time) (bool, error) {
+	if r.locks == nil {
+		r.locks = map[string]time.Time{}
+	}
+	previous := r.locks[key]
+	r.locks[key] = until
+	return !previous.After(time.Now()), nil
+}
+
+func (r *fakeLoginAttemptRepository) LockedUntil(key string) (time.Time, error) {
+	if until := r.locks[key]; until.After(time.Now()) {
+		return until, nil
+	}
+	return time.Time{}, nil
+}
+
+// fakeEmailService records the subjects of sent emails
+type fakeEmailService struct {
+	subjects []string
+}
+
+func (e *fakeEmailService) Send(to, subject, message string) error {
+	e.subjects = append(e.subjects, subject)
+	return nil
+}
+
+// fakePasskeyChallengeRepository keeps open passkey sessions in memory
+type fakePasskeyChallengeRepository struct {
+	sessions map[string]time.Time
+}
+
+func (r *fakePasskeyChallengeRepository) Store(id string, expiry time.Time) error {
+	if r.sessions == nil {
+		r.sessions = map[string]time.Time{}
+	}
+	r.sessions[id] = expiry
+	return nil
+}
+
+func (r *fakePasskeyChallengeRepository) Consume(id string) error {
+	expiry, ok := r.sessions[id]
+	delete(r.sessions, id)
+	if !ok || !expiry.After(time.Now()) {
+		return errors.New("passkey session not found")
+	}
+	return nil
+}
+
+// fakePasskeyVerifier accepts responses whose client data is the challenge, like an
+// authenticator that does not count signatures
+type fakePasskeyVerifier struct{}
+
+func (fakePasskeyVerifier) RegistrationOptions(user *domain.User, challenge string) interface{} {
+	return challenge
+}
+
+func (fakePasskeyVerifier) AssertionOptions(challenge string, allowed []domain.Passkey) interface{} {
+	return challenge
+}
+
+func (fakePasskeyVerifier) VerifyRegistration(challenge string, response PasskeyAttestation) (*domain.Passkey, error) {
+	if string(response.ClientDataJSON) != challenge {
+		return nil, errors.New("challenge mismatch")
+	}
+	return &domain.Passkey{ID: string(response.AttestationObject)}, nil
+}
+
+func (fakePasskeyVerifier) VerifyAssertion(challenge string, passkey *domain.Passkey, response PasskeyAssertion) (uint32, error) {
+	if string(response.ClientDataJSON) != challenge {
+		return 0, errors.New("challenge mismatch")
+	}
+	return 0, nil
+}
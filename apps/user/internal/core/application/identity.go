@@ -0,0 +1,229 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+// SpotifyProvider is the identity provider name of Spotify logins
+const SpotifyProvider = "spotify"
+
+var (
+	ErrIdentityInUse         = errors.New("identity is linked to another user")
+	ErrProviderAlreadyLinked = errors.New("a login of this provider is already linked")
+	ErrIdentityNotFound      = errors.New("identity not found")
+	ErrLastLoginMethod       = errors.New("cannot remove the last login method")
+)
+
+type IdentityRepository interface {
+	Create(identity *domain.Identity) error
+	FindByProviderSubject(provider string, subject string) (*domain.Identity, error)
+	FindByUserID(userID string) ([]domain.Identity, error)
+	Delete(userID string, provider string) error
+	DeleteByUserID(userID string) error
+}
+
+// LoginMethods lists the ways a user can sign in besides an emailed login link
+type LoginMethods struct {
+	Password   bool              `json:"password"`
+	Identities []domain.Identity `json:"identities"`
+	Passkeys   []domain.Passkey  `json:"passkeys"`
+}
+
+// signIn returns the user an external identity belongs to. An unknown identity is linked
+// to the user with the same email only when both the provider and the account verified
+// it. Otherwise whoever registered the account without proving the address could still
+// sign in to the merged account, so the login has to be linked from that account. If no
+// user has the email a new user is created, and asked to verify the email the provider
+// did not.
+func (s *UserService) signIn(provider string, profile *ExternalProfile) (*domain.User, error) {
+	if identity, err := s.identities.FindByProviderSubject(provider, profile.Subject); err == nil {
+		user, err := s.repo.FindByID(identity.UserID)
+		if err != nil {
+			return nil, ErrUserNotFound
+		}
+		return user, nil
+	}
+
+	if profile.Email == "" {
+		return nil, ErrEmailNotVerified
+	}
+	user, err := s.repo.FindByEmail(profile.Email)
+	if err == nil {
+		if !profile.EmailVerified || !user.EmailVerified {
+			return nil, ErrEmailNotVerified
+		}
+	} else {
+		user, err = domain.NewUser(profile.Email, profile.Name, "")
+		if err != nil {
+			return nil, err
+		}
+		user.EmailVerified = profile.EmailVerified
+		if err := s.repo.Create(user); err != nil {
+			return nil, err
+		}
+		if !user.EmailVerified {
+			if err := s.sendVerificationEmail(user); err != nil {
+				log.Printf("Failed to send verification email to user %s: %v", user.ID, err)
+			}
+		}
+	}
+	if err := s.link(user.ID, provider, profile); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// link attaches an external identity to a user
+func (s *UserService) link(userID string, provider string, profile *ExternalProfile) error {
+	if identity, err := s.identities.FindByProviderSubject(provider, profile.Subject); err == nil {
+		if identity.UserID == userID {
+			return nil
+		}
+		return ErrIdentityInUse
+	}
+	identities, err := s.identities.FindByUserID(userID)
+	if err != nil {
+		return err
+	}
+	for _, identity := range identities {
+		if identity.Provider == provider {
+			return ErrProviderAlreadyLinked
+		}
+	}
+	return s.identities.Create(&domain.Identity{
+		UserID:   userID,
+		Provider: provider,
+		Subject:  profile.Subject,
+		Email:    profile.Email,
+	})
+}
+
+// GetLoginMethods returns the password state, the linked identities and the passkeys of a user
+func (s *UserService) GetLoginMethods(userID string) (*LoginMethods, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	identities, err := s.identities.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	passkeys := user.Passkeys
+	if passkeys == nil {
+		passkeys = []domain.Passkey{}
+	}
+	return &LoginMethods{Password: user.Password != "", Identities: identities, Passkeys: passkeys}, nil
+}
+
+// GetLinkURL returns the consent page URL that links the provider's login to the user,
+// and a nonce the caller keeps in the browser that asked for the link. The state is a
+// short-lived signed token naming the user, so the callback can tell a link from a login.
+// It only completes together with the nonce, so another browser cannot finish the link.
+func (s *UserService) GetLinkURL(ctx context.Context, userID string, provider string) (string, string, error) {
+	provider = strings.ToLower(provider)
+	if _, err := s.repo.FindByID(userID); err != nil {
+		return "", "", ErrUserNotFound
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return "", "", err
+	}
+	state, err := s.linkState(userID, provider, nonce)
+	if err != nil {
+		return "", "", err
+	}
+	if provider == SpotifyProvider {
+		return s.spotifyConfig.AuthCodeURL(state), nonce, nil
+	}
+	url, err := s.GetProviderAuthURL(ctx, provider, state)
+	if err != nil {
+		return "", "", err
+	}
+	return url, nonce, nil
+}
+
+// HandleProviderLink links the login of an external provider to the user named in state.
+// nonce is the one GetLinkURL returned to the browser that asked for the link.
+func (s *UserService) HandleProviderLink(ctx context.Context, name string, code string, state string, nonce string) error {
+	userID, err := s.parseLinkState(state, strings.ToLower(name), nonce)
+	if err != nil {
+		return err
+	}
+	provider, err := s.providers.Get(name)
+	if err != nil {
+		return err
+	}
+	profile, err := provider.Exchange(ctx, code, state)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProviderAuth, err)
+	}
+	return s.link(userID, strings.ToLower(name), profile)
+}
+
+// UnlinkIdentity removes the user's login of a provider unless it is their last login method
+func (s *UserService) UnlinkIdentity(userID string, provider string) error {
+	provider = strings.ToLower(provider)
+	methods, err := s.GetLoginMethods(userID)
+	if err != nil {
+		return err
+	}
+	linked := false
+	for _, identity := range methods.Identities {
+		linked = linked || identity.Provider == provider
+	}
+	if !linked {
+		return ErrIdentityNotFound
+	}
+	if !methods.Password && len(methods.Passkeys) == 0 && len(methods.Identities) == 1 {
+		return ErrLastLoginMethod
+	}
+
+	if err := s.identities.Delete(userID, provider); err != nil {
+		return err
+	}
+	if provider == SpotifyProvider {
+		return s.forgetSpotifyCredentials(userID)
+	}
+	return nil
+}
+
+// linkState is addressed to the service itself so it cannot be used as an access token.
+// It carries only the hash of the nonce, as the state passes through the provider.
+func (s *UserService) linkState(userID string, provider string, nonce string) (string, error) {
+	return s.signer.Sign(jwt.MapClaims{
+		"user_id":  userID,
+		"provider": provider,
+		"nonce":    hashToken(nonce),
+		"aud":      s.signer.Issuer(),
+		"exp":      time.Now().Add(time.Minute * 10).Unix(),
+		"type":     "link",
+	})
+}
+
+// parseLinkState returns the user of a link state issued for provider to the browser
+// holding nonce
+func (s *UserService) parseLinkState(state string, provider string, nonce string) (string, error) {
+	claims, err := s.signer.Verify(state, s.signer.Issuer())
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	if claims["type"] != "link" || claims["provider"] != provider {
+		return "", ErrInvalidToken
+	}
+	if nonce == "" || claims["nonce"] != hashToken(nonce) {
+		return "", ErrInvalidToken
+	}
+	userID, ok := claims["user_id"].(string)
+	if !ok {
+		return "", ErrInvalidToken
+	}
+	return userID, nil
+}
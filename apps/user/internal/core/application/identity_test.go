@@ -0,0 +1,96 @@
+package application
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+func TestSignInLinksOnlyVerifiedEmails(t *testing.T) {
+	tests := []struct {
+		name            string
+		accountVerified bool
+		profileVerified bool
+		wantErr         error
+		wantLinked      bool
+	}{
+		{name: "both verified", accountVerified: true, profileVerified: true, wantLinked: true},
+		{name: "account unverified", accountVerified: false, profileVerified: true, wantErr: ErrEmailNotVerified},
+		{name: "provider unverified", accountVerified: true, profileVerified: false, wantErr: ErrEmailNotVerified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeUserRepository{users: map[string]domain.User{
+				"user-1": {ID: "user-1", Email: "victim@example.com", EmailVerified: tt.accountVerified, Password: "hash"},
+			}}
+			identities := &fakeIdentityRepository{}
+			service := &UserService{repo: repo, identities: identities}
+
+			user, err := service.signIn("google", &ExternalProfile{Subject: "google-1", Email: "victim@example.com", EmailVerified: tt.profileVerified})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if linked := len(identities.identities) == 1; linked != tt.wantLinked {
+				t.Fatalf("linked = %v, want %v", linked, tt.wantLinked)
+			}
+			if tt.wantLinked && (user.ID != "user-1" || identities.identities[0].UserID != "user-1") {
+				t.Errorf("signed in as %s, linked to %s", user.ID, identities.identities[0].UserID)
+			}
+		})
+	}
+}
+
+func TestSignInCreatesUserForUnknownEmail(t *testing.T) {
+	repo := &fakeUserRepository{users: map[string]domain.User{}}
+	identities := &fakeIdentityRepository{}
+	service := &UserService{repo: repo, identities: identities}
+
+	user, err := service.signIn("google", &ExternalProfile{Subject: "google-1", Email: "new@example.com", EmailVerified: true, Name: "New"})
+	if err != nil {
+		t.Fatalf("signIn: %v", err)
+	}
+	if !user.EmailVerified || user.Password != "" {
+		t.Errorf("created user %+v", user)
+	}
+	if len(identities.identities) != 1 || identities.identities[0].UserID != user.ID {
+		t.Errorf("identities = %+v", identities.identities)
+	}
+
+	again, err := service.signIn("google", &ExternalProfile{Subject: "google-1", Email: "changed@example.com"})
+	if err != nil || again.ID != user.ID {
+		t.Errorf("second sign in returned %v, %v", again, err)
+	}
+}
+
+func TestLinkStateRequiresNonceOfInitiatingBrowser(t *testing.T) {
+	service := &UserService{signer: fakeSigner{}}
+	state, err := service.linkState("user-1", "google", "browser-nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		provider string
+		nonce    string
+		wantErr  error
+	}{
+		{name: "initiating browser", provider: "google", nonce: "browser-nonce"},
+		{name: "no cookie", provider: "google", nonce: "", wantErr: ErrInvalidToken},
+		{name: "other browser", provider: "google", nonce: "other-nonce", wantErr: ErrInvalidToken},
+		{name: "other provider", provider: "github", nonce: "browser-nonce", wantErr: ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID, err := service.parseLinkState(state, tt.provider, tt.nonce)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && userID != "user-1" {
+				t.Errorf("user = %q", userID)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package application
+
+import (
+	"time"
+
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+// loginCodeTTL is how long the app has to exchange the code of a provider login
+const loginCodeTTL = time.Minute
+
+// LoginCodeRepository stores the codes of provider logins by their hash until the app
+// exchanges them
+type LoginCodeRepository interface {
+	Store(code string, userID string, expiry time.Time) error
+	Consume(code string) (string, error)
+}
+
+// issueLoginCode returns the code a provider callback hands to the app in its redirect.
+// The session tokens are only returned when the app exchanges the code, so they never
+// show up in URLs, browser history or referrers.
+func (s *UserService) issueLoginCode(user *domain.User) (string, error) {
+	code, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.loginCodes.Store(hashToken(code), user.ID, time.Now().Add(loginCodeTTL)); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// ExchangeLoginCode signs in with the code of a provider login; each code works once
+func (s *UserService) ExchangeLoginCode(code string, client ClientInfo) (*AuthResponse, error) {
+	userID, err := s.loginCodes.Consume(hashToken(code))
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return s.startSession(user, client)
+}
@@ -0,0 +1,45 @@
+package application
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+func TestExchangeLoginCode(t *testing.T) {
+	repo := &fakeUserRepository{users: map[string]domain.User{
+		"user-1": {ID: "user-1", Email: "user@example.com", TwoFactor: domain.TwoFactor{Enabled: true}},
+	}}
+	codes := &fakeLoginCodeRepository{}
+	service := &UserService{repo: repo, signer: fakeSigner{}, loginCodes: codes}
+
+	code, err := service.issueLoginCode(&domain.User{ID: "user-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := codes.codes[code]; ok {
+		t.Fatal("login code stored in the clear")
+	}
+
+	response, err := service.ExchangeLoginCode(code, ClientInfo{})
+	if err != nil {
+		t.Fatalf("ExchangeLoginCode: %v", err)
+	}
+	if !response.TwoFactorRequired || response.ChallengeToken == "" || response.Token != "" {
+		t.Errorf("response = %+v, want a second factor challenge", response)
+	}
+
+	if _, err := service.ExchangeLoginCode(code, ClientInfo{}); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("reusing the code returned %v", err)
+	}
+	if _, err := service.ExchangeLoginCode("unknown", ClientInfo{}); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("unknown code returned %v", err)
+	}
+
+	codes.Store(hashToken("expired"), "user-1", time.Now().Add(-time.Second))
+	if _, err := service.ExchangeLoginCode("expired", ClientInfo{}); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expired code returned %v", err)
+	}
+}
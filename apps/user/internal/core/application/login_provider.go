@@ -0,0 +1,112 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown login provider")
+	ErrProviderAuth    = errors.New("login provider authentication failed")
+	// ErrEmailNotVerified means an account with the email exists but either the provider or
+	// the account has not verified the email, so the login can only be linked from within
+	// that account
+	ErrEmailNotVerified   = errors.New("login provider did not verify the email address")
+	ErrProviderRegistered = errors.New("login provider already registered")
+)
+
+// ExternalProfile is the identity a login provider vouches for
+type ExternalProfile struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// LoginProvider signs users in through an external OAuth or OIDC identity provider
+type LoginProvider interface {
+	// Name is the identifier of the provider used in routes, e.g. "google"
+	Name() string
+	// AuthCodeURL returns the URL of the provider's consent page
+	AuthCodeURL(ctx context.Context, state string) (string, error)
+	// Exchange redeems the authorization code and returns the verified profile
+	Exchange(ctx context.Context, code string, state string) (*ExternalProfile, error)
+}
+
+// ProviderRegistry holds the configured login providers by name
+type ProviderRegistry struct {
+	providers map[string]LoginProvider
+}
+
+func NewProviderRegistry() *ProviderRegistry {
+	return &ProviderRegistry{providers: make(map[string]LoginProvider)}
+}
+
+// Register adds a provider; provider names are case-insensitive and must be unique
+func (r *ProviderRegistry) Register(provider LoginProvider) error {
+	name := strings.ToLower(provider.Name())
+	if _, ok := r.providers[name]; ok {
+		return fmt.Errorf("%w: %s", ErrProviderRegistered, name)
+	}
+	r.providers[name] = provider
+	return nil
+}
+
+// Get returns the provider registered under name
+func (r *ProviderRegistry) Get(name string) (LoginProvider, error) {
+	provider, ok := r.providers[strings.ToLower(name)]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return provider, nil
+}
+
+// Names returns the names of the registered providers in alphabetical order
+func (r *ProviderRegistry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoginProviders returns the names of the configured external login providers
+func (s *UserService) LoginProviders() []string {
+	return s.providers.Names()
+}
+
+// GetProviderAuthURL returns the consent page URL of the named provider
+func (s *UserService) GetProviderAuthURL(ctx context.Context, name string, state string) (string, error) {
+	provider, err := s.providers.Get(name)
+	if err != nil {
+		return "", err
+	}
+	url, err := provider.AuthCodeURL(ctx, state)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrProviderAuth, err)
+	}
+	return url, nil
+}
+
+// HandleProviderCallback signs in the user of an external provider and returns the login
+// code the app exchanges for the session
+func (s *UserService) HandleProviderCallback(ctx context.Context, name string, code string, state string) (string, error) {
+	provider, err := s.providers.Get(name)
+	if err != nil {
+		return "", err
+	}
+	profile, err := provider.Exchange(ctx, code, state)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrProviderAuth, err)
+	}
+	user, err := s.signIn(strings.ToLower(name), profile)
+	if err != nil {
+		return "", err
+	}
+
+	return s.issueLoginCode(user)
+}
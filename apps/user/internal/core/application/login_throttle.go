@@ -0,0 +1,142 @@
+package application
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+const (
+	// loginFailureWindow is the sliding window failed logins are counted in
+	loginFailureWindow = 15 * time.Minute
+	// Once an account has loginDelayAfter failures, each further attempt has to wait twice
+	// as long as the one before, up to maxLoginDelay
+	loginDelayAfter = 3
+	maxLoginDelay   = 30 * time.Second
+	// accountLockoutFailures and ipLockoutFailures lock an account or an IP address out
+	// for loginLockoutDuration
+	accountLockoutFailures = 10
+	ipLockoutFailures      = 50
+	loginLockoutDuration   = 15 * time.Minute
+)
+
+// LoginAttemptRepository records failed logins per key and the lockouts they caused
+type LoginAttemptRepository interface {
+	Record(key string, at time.Time, expiry time.Time) error
+	Failures(key string, since time.Time) (int, time.Time, error)
+	Clear(key string) error
+	Lock(key string, until time.Time) (bool, error)
+	LockedUntil(key string) (time.Time, error)
+}
+
+// LoginThrottledError is returned while logins of an account or IP address are delayed
+// or locked out. It matches ErrRateLimited.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry after %s", e.RetryAfter)
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	return ErrRateLimited
+}
+
+func accountAttemptKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+// checkLoginThrottle returns a LoginThrottledError while the account or the IP address
+// is locked out, or the account's next attempt is delayed
+func (s *UserService) checkLoginThrottle(email string, ip string) error {
+	now := time.Now()
+	keys := []string{accountAttemptKey(email)}
+	if ip != "" {
+		keys = append(keys, ipAttemptKey(ip))
+	}
+	for _, key := range keys {
+		until, err := s.loginAttempts.LockedUntil(key)
+		if err != nil {
+			return err
+		}
+		if until.After(now) {
+			return &LoginThrottledError{RetryAfter: until.Sub(now)}
+		}
+	}
+
+	failures, last, err := s.loginAttempts.Failures(keys[0], now.Add(-loginFailureWindow))
+	if err != nil {
+		return err
+	}
+	if failures < loginDelayAfter {
+		return nil
+	}
+	delay := maxLoginDelay
+	if shift := failures - loginDelayAfter; shift < 5 {
+		delay = min(time.Second<<shift, maxLoginDelay)
+	}
+	if retryAt := last.Add(delay); retryAt.After(now) {
+		return &LoginThrottledError{RetryAfter: retryAt.Sub(now)}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed login for the account and the IP address and locks
+// them out past their limits. user is nil when no account has the email.
+func (s *UserService) recordLoginFailure(user *domain.User, email string, ip string) error {
+	now := time.Now()
+	accountKey := accountAttemptKey(email)
+	if err := s.loginAttempts.Record(accountKey, now, now.Add(loginFailureWindow)); err != nil {
+		return err
+	}
+	failures, _, err := s.loginAttempts.Failures(accountKey, now.Add(-loginFailureWindow))
+	if err != nil {
+		return err
+	}
+	if failures >= accountLockoutFailures {
+		until := now.Add(loginLockoutDuration)
+		locked, err := s.loginAttempts.Lock(accountKey, until)
+		if err != nil {
+			return err
+		}
+		if locked && user != nil {
+			s.notifyLockout(user.Email, until)
+		}
+	}
+
+	if ip == "" {
+		return nil
+	}
+	ipKey := ipAttemptKey(ip)
+	if err := s.loginAttempts.Record(ipKey, now, now.Add(loginFailureWindow)); err != nil {
+		return err
+	}
+	failures, _, err = s.loginAttempts.Failures(ipKey, now.Add(-loginFailureWindow))
+	if err != nil {
+		return err
+	}
+	if failures >= ipLockoutFailures {
+		if _, err := s.loginAttempts.Lock(ipKey, now.Add(loginLockoutDuration)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notifyLockout tells the user their account was locked in case someone is guessing their password
+func (s *UserService) notifyLockout(email string, until time.Time) {
+	err := s.emailService.Send(email, "Your account was temporarily locked",
+		fmt.Sprintf("There were too many failed attempts to log in to your account, so logins are blocked until %s. "+
+			"If this was not you, reset your password once the lock ends.", until.UTC().Format(time.RFC1123)))
+	if err != nil {
+		log.Printf("Failed to send lockout notification: %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package application
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+func TestCheckLoginThrottleDelay(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		sinceLast time.Duration
+		// wantDelay is the full delay after the last failure, or 0 if the attempt is allowed
+		wantDelay time.Duration
+	}{
+		{name: "no failures"},
+		{name: "below the delay threshold", failures: loginDelayAfter - 1},
+		{name: "first delay", failures: loginDelayAfter, wantDelay: time.Second},
+		{name: "delay doubles", failures: loginDelayAfter + 1, wantDelay: 2 * time.Second},
+		{name: "delay doubles again", failures: loginDelayAfter + 2, wantDelay: 4 * time.Second},
+		{name: "last doubling", failures: loginDelayAfter + 4, wantDelay: 16 * time.Second},
+		{name: "capped delay", failures: loginDelayAfter + 5, wantDelay: maxLoginDelay},
+		{name: "capped delay far above the threshold", failures: accountLockoutFailures + 20, wantDelay: maxLoginDelay},
+		{name: "delay partly waited", failures: loginDelayAfter + 1, sinceLast: time.Second, wantDelay: 2 * time.Second},
+		{name: "delay waited", failures: loginDelayAfter + 1, sinceLast: 2 * time.Second},
+		{name: "failures outside the window", failures: accountLockoutFailures, sinceLast: loginFailureWindow + time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := &fakeLoginAttemptRepository{}
+			last := time.Now().Add(-tt.sinceLast)
+			for i := 0; i < tt.failures; i++ {
+				attempts.Record(accountAttemptKey("user@example.com"), last, last.Add(loginFailureWindow))
+			}
+			service := &UserService{loginAttempts: attempts}
+
+			err := service.checkLoginThrottle("User@Example.com ", "192.0.2.1")
+			if tt.wantDelay == 0 {
+				if err != nil {
+					t.Fatalf("error = %v, want the attempt to be allowed", err)
+				}
+				return
+			}
+			var throttled *LoginThrottledError
+			if !errors.As(err, &throttled) || !errors.Is(err, ErrRateLimited) {
+				t.Fatalf("error = %v, want LoginThrottledError", err)
+			}
+			remaining := tt.wantDelay - tt.sinceLast
+			if throttled.RetryAfter > remaining || throttled.RetryAfter < remaining-time.Second {
+				t.Errorf("retry after %v, want about %v", throttled.RetryAfter, remaining)
+			}
+		})
+	}
+}
+
+func TestRecordLoginFailureLockouts(t *testing.T) {
+	user := &domain.User{ID: "user-1", Email: "user@example.com"}
+	tests := []struct {
+		name string
+		// fail records the failures, returning the email and IP address of the next attempt
+		fail          func(service *UserService) (string, string)
+		wantThrottled bool
+		wantEmails    int
+	}{
+		{
+			name: "account locked once",
+			fail: func(service *UserService) (string, string) {
+				// Failures past the limit extend the lock without another alert
+				for i := 0; i < accountLockoutFailures+2; i++ {
+					service.recordLoginFailure(user, user.Email, "192.0.2.1")
+				}
+				return user.Email, "198.51.100.1"
+			},
+			wantThrottled: true,
+			wantEmails:    1,
+		},
+		{
+			name: "no alert below the limit",
+			fail: func(service *UserService) (string, string) {
+				for i := 0; i < accountLockoutFailures-1; i++ {
+					service.recordLoginFailure(user, user.Email, "192.0.2.1")
+				}
+				return "other@example.com", "198.51.100.1"
+			},
+		},
+		{
+			name: "unknown account locked without an alert",
+			fail: func(service *UserService) (string, string) {
+				for i := 0; i < accountLockoutFailures; i++ {
+					service.recordLoginFailure(nil, "nobody@example.com", "192.0.2.1")
+				}
+				return "nobody@example.com", "198.51.100.1"
+			},
+			wantThrottled: true,
+		},
+		{
+			name: "IP address locked",
+			fail: func(service *UserService) (string, string) {
+				for i := 0; i < ipLockoutFailures; i++ {
+					service.recordLoginFailure(nil, string(rune('a'+i%26))+"@example.com", "192.0.2.1")
+				}
+				return user.Email, "192.0.2.1"
+			},
+			wantThrottled: true,
+		},
+		{
+			name: "other IP address of a locked IP",
+			fail: func(service *UserService) (string, string) {
+				for i := 0; i < ipLockoutFailures; i++ {
+					service.recordLoginFailure(nil, string(rune('a'+i%26))+"@example.com", "192.0.2.1")
+				}
+				return user.Email, "198.51.100.1"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			emails := &fakeEmailService{}
+			service := &UserService{loginAttempts: &fakeLoginAttemptRepository{}, emailService: emails}
+
+			email, ip := tt.fail(service)
+			err := service.checkLoginThrottle(email, ip)
+			var throttled *LoginThrottledError
+			if got := errors.As(err, &throttled); got != tt.wantThrottled {
+				t.Fatalf("error = %v, want throttled %v", err, tt.wantThrottled)
+			}
+			if tt.wantThrottled && (throttled.RetryAfter > loginLockoutDuration || throttled.RetryAfter < loginLockoutDuration-time.Minute) {
+				t.Errorf("retry after %v, want about %v", throttled.RetryAfter, loginLockoutDuration)
+			}
+			if len(emails.subjects) != tt.wantEmails {
+				t.Errorf("sent %v, want %d lockout alerts", emails.subjects, tt.wantEmails)
+			}
+		})
+	}
+}
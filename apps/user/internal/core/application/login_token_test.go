@@ -0,0 +1,97 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+func TestVerifyLoginTokenClaimsUnverifiedAccount(t *testing.T) {
+	// Someone registered the address without owning it and set up every way to sign in
+	squatted := domain.User{
+		ID:            "user-1",
+		Email:         "user@example.com",
+		TwoFactor:     domain.TwoFactor{Enabled: true, Secret: "secret"},
+		Passkeys:      []domain.Passkey{{ID: "credential-1"}},
+		EmailVerified: false,
+	}
+	if err := squatted.HashedPassword("squatter-password"); err != nil {
+		t.Fatal(err)
+	}
+	verified := domain.User{ID: "user-2", Email: "other@example.com", EmailVerified: true}
+	if err := verified.HashedPassword("own-password"); err != nil {
+		t.Fatal(err)
+	}
+	repo := &fakeUserRepository{users: map[string]domain.User{"user-1": squatted, "user-2": verified}}
+	identities := &fakeIdentityRepository{identities: []domain.Identity{
+		{UserID: "user-1", Provider: "google", Subject: "squatter"},
+		{UserID: "user-2", Provider: "google", Subject: "other"},
+	}}
+	loginTokens := &fakeLoginTokenRepository{}
+	resets := &fakePasswordResetRepository{}
+	sessions := &fakeSessionRepository{}
+	service := &UserService{
+		repo:           repo,
+		signer:         fakeSigner{},
+		identities:     identities,
+		sessions:       sessions,
+		refreshTokens:  &fakeRefreshTokenRepository{},
+		loginTokens:    loginTokens,
+		passwordResets: resets,
+	}
+	expiry := time.Now().Add(time.Hour)
+	loginTokens.Store(hashToken("login-link"), hashToken("binding"), "user-1", expiry)
+	loginTokens.Store(hashToken("second-login-link"), hashToken("binding"), "user-1", expiry)
+	loginTokens.Store(hashToken("other-login-link"), hashToken("binding"), "user-2", expiry)
+	resets.Store(hashToken("reset"), "user-1", expiry)
+
+	login, err := service.VerifyLoginToken("login-link", "binding", ClientInfo{})
+	if err != nil {
+		t.Fatalf("VerifyLoginToken: %v", err)
+	}
+	// The squatter's second factor no longer stands between the owner and the account
+	if login.TwoFactorRequired || login.Token == "" {
+		t.Errorf("login = %+v, want a session", login)
+	}
+
+	user := repo.users["user-1"]
+	if !user.EmailVerified {
+		t.Error("address was not verified")
+	}
+	if user.Password != "" {
+		t.Error("password set before the address was verified still works")
+	}
+	if user.HasSecondFactor() {
+		t.Errorf("second factor = %+v and passkeys = %v were kept", user.TwoFactor, user.Passkeys)
+	}
+	if linked, _ := identities.FindByUserID("user-1"); len(linked) != 0 {
+		t.Errorf("linked logins = %v were kept", linked)
+	}
+	if len(sessions.revoked) != 1 || sessions.revoked[0] != "user-1" {
+		t.Errorf("revoked sessions of %v", sessions.revoked)
+	}
+	if _, ok := loginTokens.tokens[hashToken("second-login-link")]; ok {
+		t.Error("pending login link still works")
+	}
+	if _, ok := resets.tokens[hashToken("reset")]; ok {
+		t.Error("pending reset link still works")
+	}
+	if len(sessions.created) != 1 {
+		t.Errorf("created %d sessions, want 1", len(sessions.created))
+	}
+
+	// Signing in to an account whose address was already proven changes nothing
+	if _, err := service.VerifyLoginToken("other-login-link", "binding", ClientInfo{}); err != nil {
+		t.Fatalf("VerifyLoginToken: %v", err)
+	}
+	if user := repo.users["user-2"]; user.ComparePassword("own-password") != nil {
+		t.Error("password of a verified account was cleared")
+	}
+	if linked, _ := identities.FindByUserID("user-2"); len(linked) != 1 {
+		t.Errorf("linked logins of a verified account = %v", linked)
+	}
+	if len(sessions.revoked) != 1 {
+		t.Errorf("revoked sessions of %v", sessions.revoked)
+	}
+}
@@ -0,0 +1,286 @@
+package application
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+// passkeyCeremonyTTL is how long a registration or login ceremony may take
+const passkeyCeremonyTTL = 5 * time.Minute
+
+var (
+	ErrPasskeyNotFound     = errors.New("passkey not found")
+	ErrPasskeyExists       = errors.New("passkey already registered")
+	ErrPasskeyVerification = errors.New("passkey verification failed")
+)
+
+// PasskeyAttestation is the browser's response to a registration ceremony
+type PasskeyAttestation struct {
+	ClientDataJSON    []byte
+	AttestationObject []byte
+}
+
+// PasskeyAssertion is the browser's response to an authentication ceremony
+type PasskeyAssertion struct {
+	CredentialID      string
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+	UserHandle        []byte
+}
+
+// PasskeyVerifier creates WebAuthn ceremony options and verifies the authenticator responses
+type PasskeyVerifier interface {
+	RegistrationOptions(user *domain.User, challenge string) interface{}
+	AssertionOptions(challenge string, allowed []domain.Passkey) interface{}
+	VerifyRegistration(challenge string, response PasskeyAttestation) (*domain.Passkey, error)
+	// VerifyAssertion returns the authenticator's new signature counter
+	VerifyAssertion(challenge string, passkey *domain.Passkey, response PasskeyAssertion) (uint32, error)
+}
+
+// PasskeyChallengeRepository keeps the IDs of open ceremony sessions, so each challenge
+// is answered once
+type PasskeyChallengeRepository interface {
+	Store(id string, expiry time.Time) error
+	// Consume deletes an unexpired session ID, failing if it is unknown or already used
+	Consume(id string) error
+}
+
+// PasskeyCeremony holds the options for navigator.credentials and the session to send back
+// with the response. The session is a short-lived signed token carrying the challenge.
+type PasskeyCeremony struct {
+	Options interface{} `json:"options"`
+	Session string      `json:"session"`
+}
+
+// BeginPasskeyRegistration starts registering a new passkey for the user
+func (s *UserService) BeginPasskeyRegistration(userID string) (*PasskeyCeremony, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	challenge, session, err := s.passkeySession("passkey_registration", userID)
+	if err != nil {
+		return nil, err
+	}
+	return &PasskeyCeremony{Options: s.passkeys.RegistrationOptions(user, challenge), Session: session}, nil
+}
+
+// FinishPasskeyRegistration verifies the authenticator's attestation and stores the passkey
+func (s *UserService) FinishPasskeyRegistration(userID string, session string, name string, response PasskeyAttestation) (*domain.Passkey, error) {
+	challenge, err := s.parsePasskeySession(session, "passkey_registration", userID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	passkey, err := s.passkeys.VerifyRegistration(challenge, response)
+	if err != nil {
+		return nil, ErrPasskeyVerification
+	}
+	if _, err := s.repo.FindByPasskeyID(passkey.ID); err == nil {
+		return nil, ErrPasskeyExists
+	}
+
+	passkey.Name = strings.TrimSpace(name)
+	if passkey.Name == "" {
+		passkey.Name = "Passkey"
+	}
+	passkey.CreatedAt = time.Now()
+	user.Passkeys = append(user.Passkeys, *passkey)
+	if err := s.repo.Update(user); err != nil {
+		return nil, err
+	}
+	return passkey, nil
+}
+
+// ListPasskeys returns the user's passkeys
+func (s *UserService) ListPasskeys(userID string) ([]domain.Passkey, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if user.Passkeys == nil {
+		return []domain.Passkey{}, nil
+	}
+	return user.Passkeys, nil
+}
+
+// RenamePasskey changes the name the user gave a passkey
+func (s *UserService) RenamePasskey(userID string, id string, name string) (*domain.Passkey, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	passkey := user.FindPasskey(id)
+	if passkey == nil {
+		return nil, ErrPasskeyNotFound
+	}
+	passkey.Name = strings.TrimSpace(name)
+	if err := s.repo.Update(user); err != nil {
+		return nil, err
+	}
+	return passkey, nil
+}
+
+// DeletePasskey removes a passkey unless it is the user's last login method
+func (s *UserService) DeletePasskey(userID string, id string) error {
+	methods, err := s.GetLoginMethods(userID)
+	if err != nil {
+		return err
+	}
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.FindPasskey(id) == nil {
+		return ErrPasskeyNotFound
+	}
+	if !methods.Password && len(methods.Identities) == 0 && len(methods.Passkeys) == 1 {
+		return ErrLastLoginMethod
+	}
+
+	passkeys := make([]domain.Passkey, 0, len(user.Passkeys)-1)
+	for _, passkey := range user.Passkeys {
+		if passkey.ID != id {
+			passkeys = append(passkeys, passkey)
+		}
+	}
+	user.Passkeys = passkeys
+	return s.repo.Update(user)
+}
+
+// BeginPasskeyLogin starts a passwordless login. No credentials are listed, so the
+// browser offers the passkeys it has for this site.
+func (s *UserService) BeginPasskeyLogin() (*PasskeyCeremony, error) {
+	challenge, session, err := s.passkeySession("passkey_login", "")
+	if err != nil {
+		return nil, err
+	}
+	return &PasskeyCeremony{Options: s.passkeys.AssertionOptions(challenge, nil), Session: session}, nil
+}
+
+// FinishPasskeyLogin signs in the owner of the asserted passkey. A passkey proves both
+// possession and user verification, so no second factor is asked for.
+func (s *UserService) FinishPasskeyLogin(session string, response PasskeyAssertion, client ClientInfo) (*AuthResponse, error) {
+	challenge, err := s.parsePasskeySession(session, "passkey_login", "")
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.repo.FindByPasskeyID(response.CredentialID)
+	if err != nil {
+		return nil, ErrPasskeyVerification
+	}
+	if len(response.UserHandle) > 0 && string(response.UserHandle) != user.ID {
+		return nil, ErrPasskeyVerification
+	}
+	if err := s.verifyPasskey(user, challenge, response); err != nil {
+		return nil, err
+	}
+	return s.createSession(user, client)
+}
+
+// BeginTwoFactorPasskey starts confirming a login challenge with one of the user's passkeys
+func (s *UserService) BeginTwoFactorPasskey(challengeToken string) (*PasskeyCeremony, error) {
+	user, err := s.parseLoginChallenge(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if len(user.Passkeys) == 0 {
+		return nil, ErrPasskeyNotFound
+	}
+	challenge, session, err := s.passkeySession("passkey_2fa", user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &PasskeyCeremony{Options: s.passkeys.AssertionOptions(challenge, user.Passkeys), Session: session}, nil
+}
+
+// CompleteTwoFactorPasskey exchanges a login challenge and a passkey assertion for a session
+func (s *UserService) CompleteTwoFactorPasskey(challengeToken string, session string, response PasskeyAssertion, client ClientInfo) (*AuthResponse, error) {
+	user, err := s.parseLoginChallenge(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	challenge, err := s.parsePasskeySession(session, "passkey_2fa", user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verifyPasskey(user, challenge, response); err != nil {
+		return nil, err
+	}
+	return s.createSession(user, client)
+}
+
+// verifyPasskey checks an assertion against the user's passkey and records its use
+func (s *UserService) verifyPasskey(user *domain.User, challenge string, response PasskeyAssertion) error {
+	passkey := user.FindPasskey(response.CredentialID)
+	if passkey == nil {
+		return ErrPasskeyVerification
+	}
+	signCount, err := s.passkeys.VerifyAssertion(challenge, passkey, response)
+	if err != nil {
+		return ErrPasskeyVerification
+	}
+	now := time.Now()
+	passkey.SignCount = signCount
+	passkey.LastUsedAt = &now
+	return s.repo.Update(user)
+}
+
+// passkeySession returns a new ceremony challenge and the signed session carrying it. The
+// session's ID is kept until the session is used, as authenticators that do not count
+// signatures would otherwise accept a replayed assertion.
+func (s *UserService) passkeySession(ceremony string, userID string) (string, string, error) {
+	challenge, err := randomToken()
+	if err != nil {
+		return "", "", err
+	}
+	id, err := randomToken()
+	if err != nil {
+		return "", "", err
+	}
+	expiry := time.Now().Add(passkeyCeremonyTTL)
+	session, err := s.signer.Sign(jwt.MapClaims{
+		"jti":       id,
+		"user_id":   userID,
+		"challenge": challenge,
+		"aud":       s.signer.Issuer(),
+		"exp":       expiry.Unix(),
+		"type":      ceremony,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if err := s.passkeyChallenges.Store(id, expiry); err != nil {
+		return "", "", err
+	}
+	return challenge, session, nil
+}
+
+// parsePasskeySession returns the challenge of a ceremony session issued for the user and
+// uses the session up, whether or not the response turns out to be valid
+func (s *UserService) parsePasskeySession(session string, ceremony string, userID string) (string, error) {
+	claims, err := s.signer.Verify(session, s.signer.Issuer())
+	if err != nil || claims["type"] != ceremony || claims["user_id"] != userID {
+		return "", ErrInvalidToken
+	}
+	id, ok := claims["jti"].(string)
+	if !ok || id == "" {
+		return "", ErrInvalidToken
+	}
+	challenge, ok := claims["challenge"].(string)
+	if !ok {
+		return "", ErrInvalidToken
+	}
+	if err := s.passkeyChallenges.Consume(id); err != nil {
+		return "", ErrInvalidToken
+	}
+	return challenge, nil
+}
@@ -0,0 +1,110 @@
+package application
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+func newPasskeyTestService() *UserService {
+	repo := &fakeUserRepository{users: map[string]domain.User{
+		"user-1": {ID: "user-1", Email: "user@example.com", Passkeys: []domain.Passkey{{ID: "credential-1"}}},
+	}}
+	return &UserService{
+		repo:              repo,
+		signer:            fakeSigner{},
+		sessions:          &fakeSessionRepository{},
+		refreshTokens:     &fakeRefreshTokenRepository{},
+		passkeys:          fakePasskeyVerifier{},
+		passkeyChallenges: &fakePasskeyChallengeRepository{},
+	}
+}
+
+func TestPasskeySessionsWorkOnce(t *testing.T) {
+	tests := []struct {
+		name string
+		// finish begins a ceremony and returns a function answering it with the
+		// challenge the ceremony options carried, or a wrong one
+		finish func(t *testing.T, service *UserService) func(correct bool) error
+	}{
+		{
+			name: "login",
+			finish: func(t *testing.T, service *UserService) func(bool) error {
+				ceremony, err := service.BeginPasskeyLogin()
+				if err != nil {
+					t.Fatal(err)
+				}
+				return func(correct bool) error {
+					_, err := service.FinishPasskeyLogin(ceremony.Session, assertion(ceremony, correct), ClientInfo{})
+					return err
+				}
+			},
+		},
+		{
+			name: "second factor",
+			finish: func(t *testing.T, service *UserService) func(bool) error {
+				login, err := service.startSession(&domain.User{ID: "user-1", Passkeys: []domain.Passkey{{ID: "credential-1"}}}, ClientInfo{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				ceremony, err := service.BeginTwoFactorPasskey(login.ChallengeToken)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return func(correct bool) error {
+					_, err := service.CompleteTwoFactorPasskey(login.ChallengeToken, ceremony.Session, assertion(ceremony, correct), ClientInfo{})
+					return err
+				}
+			},
+		},
+		{
+			name: "registration",
+			finish: func(t *testing.T, service *UserService) func(bool) error {
+				ceremony, err := service.BeginPasskeyRegistration("user-1")
+				if err != nil {
+					t.Fatal(err)
+				}
+				return func(correct bool) error {
+					response := PasskeyAttestation{ClientDataJSON: []byte(ceremony.Options.(string)), AttestationObject: []byte("credential-2")}
+					if !correct {
+						response.ClientDataJSON = []byte("another-challenge")
+					}
+					_, err := service.FinishPasskeyRegistration("user-1", ceremony.Session, "Laptop", response)
+					return err
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newPasskeyTestService()
+			answer := tt.finish(t, service)
+			if err := answer(true); err != nil {
+				t.Fatalf("first answer: %v", err)
+			}
+			if err := answer(true); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("replayed answer returned %v, want %v", err, ErrInvalidToken)
+			}
+
+			service = newPasskeyTestService()
+			answer = tt.finish(t, service)
+			if err := answer(false); !errors.Is(err, ErrPasskeyVerification) {
+				t.Fatalf("wrong answer returned %v, want %v", err, ErrPasskeyVerification)
+			}
+			if err := answer(true); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("answer after a failed attempt returned %v, want %v", err, ErrInvalidToken)
+			}
+		})
+	}
+}
+
+// assertion answers a ceremony with credential-1, signing its challenge or a wrong one
+func assertion(ceremony *PasskeyCeremony, correct bool) PasskeyAssertion {
+	clientData := ceremony.Options.(string)
+	if !correct {
+		clientData = "another-challenge"
+	}
+	return PasskeyAssertion{CredentialID: "credential-1", ClientDataJSON: []byte(clientData)}
+}
@@ -0,0 +1,88 @@
+package application
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// passwordResetTTL is how long an emailed password reset link stays valid
+const passwordResetTTL = time.Hour
+
+type PasswordResetRepository interface {
+	Store(token string, userID string, expiry time.Time) error
+	Consume(token string) (string, error)
+	DeleteByUserID(userID string) error
+}
+
+// ForgotPassword emails a password reset link. Unknown addresses are ignored so the
+// response does not reveal which emails have accounts.
+func (s *UserService) ForgotPassword(email string, ip string) error {
+	if !s.limits.LoginLinkPerIP.Allow(ip) || !s.limits.PasswordResetPerEmail.Allow(strings.ToLower(email)) {
+		return ErrRateLimited
+	}
+	user, err := s.repo.FindByEmail(email)
+	if err != nil {
+		return nil
+	}
+
+	// Only the latest link works
+	if err := s.passwordResets.DeleteByUserID(user.ID); err != nil {
+		return err
+	}
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	if err := s.passwordResets.Store(hashToken(token), user.ID, time.Now().Add(passwordResetTTL)); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", FrontendURL(), token)
+	err = s.emailService.Send(user.Email, "Reset your password", fmt.Sprintf("Click the link to choose a new password: %s\nIf you did not ask to reset your password, you can ignore this email.", link))
+	if err != nil {
+		return fmt.Errorf("failed to send password reset email: %w", err)
+	}
+	return nil
+}
+
+// ResetPassword sets a new password with a reset token and signs the user out everywhere.
+// Pending login links and other reset links are dropped too, as they may have been
+// requested by whoever knew the old password or had access to the mailbox.
+func (s *UserService) ResetPassword(token string, password string) error {
+	userID, err := s.passwordResets.Consume(hashToken(token))
+	if err != nil {
+		return ErrInvalidToken
+	}
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	// Following the emailed link proves the user owns the address
+	if !user.EmailVerified {
+		if err := s.claimUnverifiedAccount(user); err != nil {
+			return err
+		}
+	}
+	if err := user.HashedPassword(password); err != nil {
+		return err
+	}
+	if err := s.repo.Update(user); err != nil {
+		return err
+	}
+	if err := s.revokeSignIns(user.ID); err != nil {
+		return err
+	}
+	s.notifyPasswordChanged(user.Email)
+	return nil
+}
+
+// notifyPasswordChanged tells the user about a password change in case it was not them
+func (s *UserService) notifyPasswordChanged(email string) {
+	err := s.emailService.Send(email, "Your password was changed",
+		"The password of your account was just changed. If this was not you, reset your password right away.")
+	if err != nil {
+		log.Printf("Failed to send password change notification: %v", err)
+	}
+}
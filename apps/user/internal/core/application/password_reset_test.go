@@ -0,0 +1,57 @@
+package application
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+func TestResetPasswordInvalidatesPendingLinks(t *testing.T) {
+	repo := &fakeUserRepository{users: map[string]domain.User{
+		"user-1": {ID: "user-1", Email: "user@example.com"},
+		"user-2": {ID: "user-2", Email: "other@example.com"},
+	}}
+	resets := &fakePasswordResetRepository{}
+	loginTokens := &fakeLoginTokenRepository{}
+	sessions := &fakeSessionRepository{}
+	service := &UserService{
+		repo:           repo,
+		emailService:   &fakeEmailService{},
+		identities:     &fakeIdentityRepository{},
+		sessions:       sessions,
+		refreshTokens:  &fakeRefreshTokenRepository{},
+		loginTokens:    loginTokens,
+		passwordResets: resets,
+	}
+	expiry := time.Now().Add(time.Hour)
+	resets.Store(hashToken("reset"), "user-1", expiry)
+	resets.Store(hashToken("second-reset"), "user-1", expiry)
+	resets.Store(hashToken("other-reset"), "user-2", expiry)
+	loginTokens.Store(hashToken("login-link"), hashToken("binding"), "user-1", expiry)
+	loginTokens.Store(hashToken("other-login-link"), hashToken("binding"), "user-2", expiry)
+
+	if err := service.ResetPassword("reset", "new-password"); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+
+	if len(sessions.revoked) != 1 || sessions.revoked[0] != "user-1" {
+		t.Errorf("revoked sessions of %v", sessions.revoked)
+	}
+	if _, err := loginTokens.Verify(hashToken("login-link"), hashToken("binding")); err == nil {
+		t.Error("pending login link still works")
+	}
+	if err := service.ResetPassword("second-reset", "another-password"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("second reset link returned %v", err)
+	}
+	if _, ok := resets.tokens[hashToken("other-reset")]; !ok {
+		t.Error("reset link of another user was dropped")
+	}
+	if _, ok := loginTokens.tokens[hashToken("other-login-link")]; !ok {
+		t.Error("login link of another user was dropped")
+	}
+	if user := repo.users["user-1"]; user.ComparePassword("new-password") != nil {
+		t.Error("password was not changed")
+	}
+}
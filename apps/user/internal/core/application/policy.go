@@ -0,0 +1,52 @@
+package application
+
+import (
+	"errors"
+
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+var ErrForbidden = errors.New("forbidden")
+
+// Action is something a user does to a user account
+type Action string
+
+const (
+	ActionViewUser   Action = "user:view"
+	ActionListUsers  Action = "user:list"
+	ActionUpdateUser Action = "user:update"
+	ActionDeleteUser Action = "user:delete"
+	ActionSetRole    Action = "user:set_role"
+)
+
+// Authorize checks whether actor may perform action on the account with targetID. Users
+// manage their own account, support staff can look up any account, and admins can do
+// everything but change their own role, so there is always an admin left.
+func Authorize(actor *domain.User, action Action, targetID string) error {
+	self := actor.ID == targetID
+	switch actor.EffectiveRole() {
+	case domain.RoleAdmin:
+		if action == ActionSetRole && self {
+			return ErrForbidden
+		}
+		return nil
+	case domain.RoleSupport:
+		if action == ActionViewUser || action == ActionListUsers {
+			return nil
+		}
+	}
+	if self && (action == ActionViewUser || action == ActionUpdateUser || action == ActionDeleteUser) {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// authorize loads the acting user, so role changes apply without waiting for new tokens,
+// and checks the action against the policy
+func (s *UserService) authorize(actorID string, action Action, targetID string) error {
+	actor, err := s.repo.FindByID(actorID)
+	if err != nil {
+		return ErrForbidden
+	}
+	return Authorize(actor, action, targetID)
+}
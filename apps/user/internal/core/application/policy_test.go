@@ -0,0 +1,75 @@
+package application
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    domain.Role
+		action  Action
+		self    bool
+		allowed bool
+	}{
+		{name: "user views self", role: domain.RoleUser, action: ActionViewUser, self: true, allowed: true},
+		{name: "user updates self", role: domain.RoleUser, action: ActionUpdateUser, self: true, allowed: true},
+		{name: "user deletes self", role: domain.RoleUser, action: ActionDeleteUser, self: true, allowed: true},
+		{name: "user sets own role", role: domain.RoleUser, action: ActionSetRole, self: true},
+		{name: "user lists users", role: domain.RoleUser, action: ActionListUsers},
+		{name: "user views other", role: domain.RoleUser, action: ActionViewUser},
+		{name: "user updates other", role: domain.RoleUser, action: ActionUpdateUser},
+		// Users created before roles existed have no role and are regular users
+		{name: "no role views self", action: ActionViewUser, self: true, allowed: true},
+		{name: "no role views other", action: ActionViewUser},
+		{name: "no role lists users", action: ActionListUsers},
+		{name: "support views other", role: domain.RoleSupport, action: ActionViewUser, allowed: true},
+		{name: "support lists users", role: domain.RoleSupport, action: ActionListUsers, allowed: true},
+		{name: "support updates other", role: domain.RoleSupport, action: ActionUpdateUser},
+		{name: "support deletes other", role: domain.RoleSupport, action: ActionDeleteUser},
+		{name: "support sets role", role: domain.RoleSupport, action: ActionSetRole},
+		{name: "support updates self", role: domain.RoleSupport, action: ActionUpdateUser, self: true, allowed: true},
+		{name: "admin updates other", role: domain.RoleAdmin, action: ActionUpdateUser, allowed: true},
+		{name: "admin deletes other", role: domain.RoleAdmin, action: ActionDeleteUser, allowed: true},
+		{name: "admin sets role", role: domain.RoleAdmin, action: ActionSetRole, allowed: true},
+		{name: "admin sets own role", role: domain.RoleAdmin, action: ActionSetRole, self: true},
+		{name: "unknown role", role: domain.Role("owner"), action: ActionListUsers},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actor := &domain.User{ID: "actor", Role: tt.role}
+			target := "target"
+			if tt.self {
+				target = actor.ID
+			}
+			err := Authorize(actor, tt.action, target)
+			if tt.allowed && err != nil {
+				t.Errorf("error = %v, want allowed", err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrForbidden) {
+				t.Errorf("error = %v, want ErrForbidden", err)
+			}
+		})
+	}
+}
+
+func TestPromoteAdminsSkipsUnverifiedEmails(t *testing.T) {
+	repo := &fakeUserRepository{users: map[string]domain.User{
+		"user-1": {ID: "user-1", Email: "admin@example.com", EmailVerified: true},
+		"user-2": {ID: "user-2", Email: "squatted@example.com"},
+	}}
+	service := &UserService{repo: repo}
+
+	service.PromoteAdmins([]string{" admin@example.com", "squatted@example.com", "missing@example.com"})
+
+	if role := repo.users["user-1"].Role; role != domain.RoleAdmin {
+		t.Errorf("verified user has role %q, want admin", role)
+	}
+	if role := repo.users["user-2"].Role; role != "" {
+		t.Errorf("unverified user has role %q, want none", role)
+	}
+}
@@ -0,0 +1,113 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+var (
+	ErrInvalidPreferences = errors.New("invalid preferences")
+	// ErrChannelNeedsVerifiedEmail means a notification channel can only be turned on once
+	// the user proved they own the address, so no one can be signed up for mail they never asked for
+	ErrChannelNeedsVerifiedEmail = errors.New("verify your email address before turning on notifications")
+)
+
+// PreferencesUpdate changes the preferences that are set and keeps the others
+type PreferencesUpdate struct {
+	Currency         *string
+	TimeZone         *string
+	Locale           *string
+	WeekStart        *string
+	ReminderLeadDays *int
+	DigestFrequency  *domain.DigestFrequency
+	EmailNotices     *bool
+	PushNotices      *bool
+}
+
+// GetPreferences returns the user's preferences, filled with the defaults if they never set any
+func (s *UserService) GetPreferences(userID string) (*domain.Preferences, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	preferences := user.EffectivePreferences()
+	return &preferences, nil
+}
+
+// UpdatePreferences applies update to the user's preferences. Services read the preferences
+// from access tokens, so they see the change once the client refreshes its token.
+func (s *UserService) UpdatePreferences(userID string, update PreferencesUpdate) (*domain.Preferences, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	preferences := user.EffectivePreferences()
+	if update.Currency != nil {
+		preferences.Currency = strings.ToUpper(*update.Currency)
+	}
+	if update.TimeZone != nil {
+		if _, err := time.LoadLocation(*update.TimeZone); err != nil || *update.TimeZone == "" {
+			return nil, fmt.Errorf("%w: unknown time zone %q", ErrInvalidPreferences, *update.TimeZone)
+		}
+		preferences.TimeZone = *update.TimeZone
+	}
+	if update.Locale != nil {
+		preferences.Locale = *update.Locale
+	}
+	if update.WeekStart != nil {
+		name := strings.ToLower(*update.WeekStart)
+		if _, ok := domain.ParseWeekday(name); !ok {
+			return nil, fmt.Errorf("%w: unknown week start %q", ErrInvalidPreferences, *update.WeekStart)
+		}
+		preferences.WeekStart = name
+	}
+	if update.ReminderLeadDays != nil {
+		if *update.ReminderLeadDays < 0 || *update.ReminderLeadDays > domain.MaxReminderLeadDays {
+			return nil, fmt.Errorf("%w: reminder lead days must be between 0 and %d", ErrInvalidPreferences, domain.MaxReminderLeadDays)
+		}
+		preferences.ReminderLeadDays = *update.ReminderLeadDays
+	}
+	if update.DigestFrequency != nil {
+		if !update.DigestFrequency.Valid() {
+			return nil, fmt.Errorf("%w: unknown digest frequency %q", ErrInvalidPreferences, *update.DigestFrequency)
+		}
+		preferences.DigestFrequency = *update.DigestFrequency
+	}
+	if !user.EmailVerified && (isSet(update.EmailNotices) || isSet(update.PushNotices)) {
+		return nil, ErrChannelNeedsVerifiedEmail
+	}
+	if update.EmailNotices != nil {
+		preferences.Channels.Email = *update.EmailNotices
+	}
+	if update.PushNotices != nil {
+		preferences.Channels.Push = *update.PushNotices
+	}
+
+	user.Preferences = &preferences
+	if err := s.repo.Update(user); err != nil {
+		return nil, err
+	}
+	return &preferences, nil
+}
+
+// isSet reports whether an optional flag is present and true
+func isSet(flag *bool) bool {
+	return flag != nil && *flag
+}
+
+// preferenceClaims returns the preferences other services apply to a user's data, in the
+// compact form they are carried in access tokens
+func preferenceClaims(user *domain.User) map[string]interface{} {
+	preferences := user.EffectivePreferences()
+	return map[string]interface{}{
+		"currency":           preferences.Currency,
+		"tz":                 preferences.TimeZone,
+		"locale":             preferences.Locale,
+		"week_start":         preferences.WeekStart,
+		"reminder_lead_days": preferences.ReminderLeadDays,
+	}
+}
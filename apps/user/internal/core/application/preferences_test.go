@@ -0,0 +1,46 @@
+package application
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+func TestUpdatePreferencesChannelsNeedVerifiedEmail(t *testing.T) {
+	on, off := true, false
+	currency := "eur"
+	tests := []struct {
+		name     string
+		verified bool
+		update   PreferencesUpdate
+		wantErr  error
+	}{
+		{name: "unverified turns on email", update: PreferencesUpdate{EmailNotices: &on}, wantErr: ErrChannelNeedsVerifiedEmail},
+		{name: "unverified turns on push", update: PreferencesUpdate{PushNotices: &on}, wantErr: ErrChannelNeedsVerifiedEmail},
+		{name: "unverified turns off email", update: PreferencesUpdate{EmailNotices: &off}},
+		{name: "unverified changes currency", update: PreferencesUpdate{Currency: &currency}},
+		{name: "verified turns on push", verified: true, update: PreferencesUpdate{PushNotices: &on}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeUserRepository{users: map[string]domain.User{
+				"user-1": {ID: "user-1", Email: "user@example.com", EmailVerified: tt.verified},
+			}}
+			service := &UserService{repo: repo}
+
+			_, err := service.UpdatePreferences("user-1", tt.update)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			stored := repo.users["user-1"].Preferences
+			if tt.wantErr != nil && stored != nil {
+				t.Errorf("rejected update stored %+v", stored)
+			}
+			if tt.wantErr == nil && stored == nil {
+				t.Error("update was not stored")
+			}
+		})
+	}
+}
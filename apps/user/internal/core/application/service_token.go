@@ -0,0 +1,38 @@
+package application
+
+import (
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// serviceTokenTTL is how long a token for one internal API call stays valid
+const serviceTokenTTL = time.Minute
+
+// Scopes limit a service token to one kind of internal API call, so a token leaked from
+// one call cannot be replayed against another
+const (
+	// ScopeUserDeletion lets a token deliver the deletion event it names to other services
+	ScopeUserDeletion = "user:delete"
+	// ScopeUserExport lets a token read the data of the user it names for a data export
+	ScopeUserExport = "user:export"
+)
+
+// InternalAudience is the audience of the service tokens that authenticate calls to other
+// services' internal APIs, configurable through INTERNAL_AUDIENCE
+func InternalAudience() string {
+	if audience := os.Getenv("INTERNAL_AUDIENCE"); audience != "" {
+		return audience
+	}
+	return "subscription-tracker-internal"
+}
+
+// serviceToken signs a short-lived token for an internal API call with the given scope and claims
+func (s *UserService) serviceToken(scope string, claims jwt.MapClaims) (string, error) {
+	claims["type"] = "service"
+	claims["scope"] = scope
+	claims["aud"] = InternalAudience()
+	claims["exp"] = time.Now().Add(serviceTokenTTL).Unix()
+	return s.signer.Sign(claims)
+}
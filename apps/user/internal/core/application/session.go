@@ -0,0 +1,191 @@
+package application
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+// refreshTokenTTL is how long a session can go unused before the user has to sign in again
+const refreshTokenTTL = 30 * 24 * time.Hour
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrSessionNotFound     = errors.New("session not found")
+)
+
+type SessionRepository interface {
+	Create(session *domain.Session) error
+	FindByID(id string) (*domain.Session, error)
+	FindActiveByUserID(userID string) ([]domain.Session, error)
+	FindByUserID(userID string) ([]domain.Session, error)
+	Touch(id string, userAgent string, ip string) error
+	Revoke(id string) error
+	RevokeByUserID(userID string) error
+	DeleteByUserID(userID string) error
+}
+
+type RefreshTokenRepository interface {
+	Store(token *domain.RefreshToken) error
+	Use(tokenHash string) (*domain.RefreshToken, error)
+	DeleteBySessionID(sessionID string) error
+	DeleteByUserID(userID string) error
+}
+
+// ClientInfo describes the device a user signs in from
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// accessTokenTTL is the lifetime of access tokens, configurable through ACCESS_TOKEN_TTL
+func accessTokenTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("ACCESS_TOKEN_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+	return 15 * time.Minute
+}
+
+// randomToken returns an opaque URL-safe token
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken is the form opaque tokens are stored and looked up in
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// AccessTokenAudience is the aud claim of access tokens, configurable through JWT_AUDIENCE
+func AccessTokenAudience() string {
+	if audience := os.Getenv("JWT_AUDIENCE"); audience != "" {
+		return audience
+	}
+	return "subscription-tracker"
+}
+
+// createSession signs the user in on a new device
+func (s *UserService) createSession(user *domain.User, client ClientInfo) (*AuthResponse, error) {
+	if user.PendingDeletion() {
+		return nil, ErrAccountPendingDeletion
+	}
+	now := time.Now()
+	session := &domain.Session{
+		UserID:     user.ID,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(refreshTokenTTL),
+	}
+	if err := s.sessions.Create(session); err != nil {
+		return nil, err
+	}
+	return s.issueTokens(user, session)
+}
+
+// issueTokens returns a new access token and the next refresh token of the session
+func (s *UserService) issueTokens(user *domain.User, session *domain.Session) (*AuthResponse, error) {
+	accessToken, err := s.generateToken(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	err = s.refreshTokens.Store(&domain.RefreshToken{
+		TokenHash: hashToken(refreshToken),
+		SessionID: session.ID,
+		UserID:    user.ID,
+		ExpiresAt: session.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(accessTokenTTL().Seconds()),
+		User:         user,
+	}, nil
+}
+
+// Refresh rotates a refresh token. Presenting a token that was already rotated means it
+// leaked, so the whole session is revoked.
+func (s *UserService) Refresh(refreshToken string, client ClientInfo) (*AuthResponse, error) {
+	token, err := s.refreshTokens.Use(hashToken(refreshToken))
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if token.UsedAt != nil {
+		log.Printf("Refresh token reuse detected, revoking session %s of user %s", token.SessionID, token.UserID)
+		if err := s.revokeSession(token.SessionID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	session, err := s.sessions.FindByID(token.SessionID)
+	if err != nil || !session.Active(time.Now()) {
+		return nil, ErrInvalidRefreshToken
+	}
+	user, err := s.repo.FindByID(token.UserID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err := s.sessions.Touch(session.ID, client.UserAgent, client.IP); err != nil {
+		return nil, err
+	}
+	return s.issueTokens(user, session)
+}
+
+// ListSessions returns the active sessions of the user, marking the current one
+func (s *UserService) ListSessions(userID string, currentSessionID string) ([]domain.Session, error) {
+	sessions, err := s.sessions.FindActiveByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs the user out of one of their sessions
+func (s *UserService) RevokeSession(userID string, sessionID string) error {
+	session, err := s.sessions.FindByID(sessionID)
+	if err != nil || session.UserID != userID {
+		return ErrSessionNotFound
+	}
+	return s.revokeSession(sessionID)
+}
+
+// RevokeAllSessions signs the user out everywhere
+func (s *UserService) RevokeAllSessions(userID string) error {
+	if err := s.sessions.RevokeByUserID(userID); err != nil {
+		return err
+	}
+	return s.refreshTokens.DeleteByUserID(userID)
+}
+
+func (s *UserService) revokeSession(sessionID string) error {
+	if err := s.sessions.Revoke(sessionID); err != nil {
+		return err
+	}
+	return s.refreshTokens.DeleteBySessionID(sessionID)
+}
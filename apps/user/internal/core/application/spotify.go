@@ -0,0 +1,182 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/subscription-tracker/user/internal/core/domain"
+	"golang.org/x/oauth2"
+)
+
+var (
+	ErrSpotifyNotLinked  = errors.New("spotify account not linked")
+	ErrSpotifyLinkBroken = errors.New("spotify authorization was revoked")
+)
+
+// spotifyHTTPClient bounds the token exchanges and refreshes made against Spotify
+var spotifyHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// spotifyContext returns the context Spotify OAuth calls run in; without a client in the
+// context oauth2 falls back to http.DefaultClient, which never times out
+func spotifyContext() context.Context {
+	return context.WithValue(context.Background(), oauth2.HTTPClient, spotifyHTTPClient)
+}
+
+// setSpotifyToken stores token as the user's Spotify credentials
+func setSpotifyToken(user *domain.User, token *oauth2.Token) {
+	credentials := &user.SpotifyCredentials
+	credentials.AccessToken = token.AccessToken
+	if token.RefreshToken != "" {
+		credentials.RefreshToken = token.RefreshToken
+	}
+	credentials.TokenType = token.TokenType
+	credentials.Expiry = token.Expiry
+	if token.Expiry.IsZero() && token.ExpiresIn > 0 {
+		credentials.Expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+}
+
+// spotifyToken returns a valid Spotify access token for the user. An expired token is
+// refreshed through the oauth2 token source and the rotated tokens are persisted. If
+// Spotify rejects the refresh token the link is marked broken.
+func (s *UserService) spotifyToken(ctx context.Context, user *domain.User) (*oauth2.Token, error) {
+	credentials := user.SpotifyCredentials
+	if !credentials.IsLinked() {
+		if credentials.Status == domain.SpotifyLinkBroken {
+			return nil, ErrSpotifyLinkBroken
+		}
+		return nil, ErrSpotifyNotLinked
+	}
+
+	current := &oauth2.Token{
+		AccessToken:  credentials.AccessToken,
+		RefreshToken: credentials.RefreshToken,
+		TokenType:    credentials.TokenType,
+		Expiry:       credentials.Expiry,
+	}
+	token, err := s.spotifyConfig.TokenSource(ctx, current).Token()
+	if err != nil {
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) && retrieveErr.ErrorCode == "invalid_grant" {
+			user.SpotifyCredentials = domain.SpotifyCredentials{
+				SpotifyID: credentials.SpotifyID,
+				Status:    domain.SpotifyLinkBroken,
+				LinkedAt:  credentials.LinkedAt,
+			}
+			if err := s.repo.Update(user); err != nil {
+				return nil, err
+			}
+			return nil, ErrSpotifyLinkBroken
+		}
+		return nil, fmt.Errorf("%w: %v", ErrSpotifyAuth, err)
+	}
+
+	if token.AccessToken != current.AccessToken || token.RefreshToken != current.RefreshToken {
+		setSpotifyToken(user, token)
+		if err := s.repo.Update(user); err != nil {
+			return nil, err
+		}
+	}
+	return token, nil
+}
+
+// SyncSpotifySubscription reads the user's Spotify profile with their stored credentials
+// and updates their Spotify subscription in the subscription service. userToken is the
+// caller's own access token, forwarded to the subscription service.
+func (s *UserService) SyncSpotifySubscription(userID string, userToken string) error {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	ctx := spotifyContext()
+	token, err := s.spotifyToken(ctx, user)
+	if err != nil {
+		return err
+	}
+	spotifyUser, err := s.spotifyClient.GetCurrentUser(ctx, token)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSpotifyAuth, err)
+	}
+	return s.subscriptionClient.SyncSpotifySubscription(ctx, userToken, spotifyUser.Product)
+}
+
+// exchangeSpotifyCode redeems an authorization code and reads the Spotify profile
+func (s *UserService) exchangeSpotifyCode(ctx context.Context, code string) (*oauth2.Token, *SpotifyUser, error) {
+	token, err := s.spotifyConfig.Exchange(ctx, code)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrSpotifyAuth, err)
+	}
+	spotifyUser, err := s.spotifyClient.GetCurrentUser(ctx, token)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrSpotifyAuth, err)
+	}
+	return token, spotifyUser, nil
+}
+
+// storeSpotifyLink saves the Spotify credentials of a freshly authorized account
+func (s *UserService) storeSpotifyLink(user *domain.User, token *oauth2.Token, spotifyUser *SpotifyUser) error {
+	user.SpotifyCredentials = domain.SpotifyCredentials{
+		SpotifyID: spotifyUser.ID,
+		Status:    domain.SpotifyLinkActive,
+		LinkedAt:  time.Now(),
+	}
+	setSpotifyToken(user, token)
+	return s.repo.Update(user)
+}
+
+// syncSpotifySubscription detects the Spotify subscription of a user. It is best effort
+// and must not block logins or links.
+func (s *UserService) syncSpotifySubscription(ctx context.Context, user *domain.User, userToken string, spotifyUser *SpotifyUser) {
+	if err := s.subscriptionClient.SyncSpotifySubscription(ctx, userToken, spotifyUser.Product); err != nil {
+		log.Printf("Failed to sync Spotify subscription for user %s: %v", user.ID, err)
+	}
+}
+
+// HandleSpotifyLink links the Spotify account to the user named in state, if nonce is the
+// one GetLinkURL returned to the browser that asked for the link
+func (s *UserService) HandleSpotifyLink(code string, state string, nonce string) error {
+	userID, err := s.parseLinkState(state, SpotifyProvider, nonce)
+	if err != nil {
+		return err
+	}
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	ctx := spotifyContext()
+	token, spotifyUser, err := s.exchangeSpotifyCode(ctx, code)
+	if err != nil {
+		return err
+	}
+	if err := s.link(userID, SpotifyProvider, &ExternalProfile{Subject: spotifyUser.ID, Email: spotifyUser.Email, Name: spotifyUser.Name}); err != nil {
+		return err
+	}
+	if err := s.storeSpotifyLink(user, token, spotifyUser); err != nil {
+		return err
+	}
+
+	userToken, err := s.generateToken(user, "")
+	if err != nil {
+		return err
+	}
+	s.syncSpotifySubscription(ctx, user, userToken, spotifyUser)
+	return nil
+}
+
+// forgetSpotifyCredentials clears the Spotify credentials of an unlinked account
+func (s *UserService) forgetSpotifyCredentials(userID string) error {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	user.SpotifyCredentials = domain.SpotifyCredentials{}
+	if err := s.repo.Update(user); err != nil {
+		return err
+	}
+	log.Printf("Unlinked Spotify account of user %s", userID)
+	return nil
+}
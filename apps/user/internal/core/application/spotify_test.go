@@ -0,0 +1,190 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/subscription-tracker/user/internal/core/domain"
+	"golang.org/x/oauth2"
+)
+
+type fakeSpotifyClient struct {
+	product string
+	tokens  []string
+}
+
+func (c *fakeSpotifyClient) GetCurrentUser(ctx context.Context, token *oauth2.Token) (*SpotifyUser, error) {
+	c.tokens = append(c.tokens, token.AccessToken)
+	return &SpotifyUser{ID: "spotify-user", Product: c.product}, nil
+}
+
+type fakeSubscriptionClient struct {
+	SubscriptionClient
+	products []string
+}
+
+func (c *fakeSubscriptionClient) SyncSpotifySubscription(ctx context.Context, userToken string, product string) error {
+	c.products = append(c.products, product)
+	return nil
+}
+
+func TestSyncSpotifySubscription(t *testing.T) {
+	tests := []struct {
+		name string
+		// expiry of the stored access token relative to now
+		expiry        time.Duration
+		status        string
+		tokenResponse func(w http.ResponseWriter)
+		wantErr       error
+		wantRefreshes int
+		wantAccess    string
+		wantRefresh   string
+		wantStatus    string
+		wantSynced    bool
+	}{
+		{
+			name:        "valid token",
+			expiry:      time.Hour,
+			status:      domain.SpotifyLinkActive,
+			wantAccess:  "old-access",
+			wantRefresh: "old-refresh",
+			wantStatus:  domain.SpotifyLinkActive,
+			wantSynced:  true,
+		},
+		{
+			name:   "expired token is refreshed and rotated tokens persisted",
+			expiry: -time.Minute,
+			status: domain.SpotifyLinkActive,
+			tokenResponse: func(w http.ResponseWriter) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"access_token":"new-access","refresh_token":"new-refresh","token_type":"Bearer","expires_in":3600}`))
+			},
+			wantRefreshes: 1,
+			wantAccess:    "new-access",
+			wantRefresh:   "new-refresh",
+			wantStatus:    domain.SpotifyLinkActive,
+			wantSynced:    true,
+		},
+		{
+			name:   "refresh without a rotated refresh token keeps the old one",
+			expiry: -time.Minute,
+			status: domain.SpotifyLinkActive,
+			tokenResponse: func(w http.ResponseWriter) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"access_token":"new-access","token_type":"Bearer","expires_in":3600}`))
+			},
+			wantRefreshes: 1,
+			wantAccess:    "new-access",
+			wantRefresh:   "old-refresh",
+			wantStatus:    domain.SpotifyLinkActive,
+			wantSynced:    true,
+		},
+		{
+			name:   "revoked grant breaks the link",
+			expiry: -time.Minute,
+			status: domain.SpotifyLinkActive,
+			tokenResponse: func(w http.ResponseWriter) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid_grant","error_description":"Refresh token revoked"}`))
+			},
+			wantErr:       ErrSpotifyLinkBroken,
+			wantRefreshes: 1,
+			wantStatus:    domain.SpotifyLinkBroken,
+		},
+		{
+			name:   "failing token endpoint keeps the link",
+			expiry: -time.Minute,
+			status: domain.SpotifyLinkActive,
+			tokenResponse: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			wantErr:       ErrSpotifyAuth,
+			wantRefreshes: 1,
+			wantAccess:    "old-access",
+			wantRefresh:   "old-refresh",
+			wantStatus:    domain.SpotifyLinkActive,
+		},
+		{
+			name:        "broken link",
+			expiry:      time.Hour,
+			status:      domain.SpotifyLinkBroken,
+			wantErr:     ErrSpotifyLinkBroken,
+			wantAccess:  "old-access",
+			wantRefresh: "old-refresh",
+			wantStatus:  domain.SpotifyLinkBroken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refreshes := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				refreshes++
+				if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "refresh_token" || r.Form.Get("refresh_token") != "old-refresh" {
+					t.Errorf("unexpected token request %v", r.Form)
+				}
+				tt.tokenResponse(w)
+			}))
+			defer server.Close()
+
+			repo := &fakeUserRepository{users: map[string]domain.User{
+				"user-1": {
+					ID: "user-1",
+					SpotifyCredentials: domain.SpotifyCredentials{
+						SpotifyID:    "spotify-user",
+						AccessToken:  "old-access",
+						RefreshToken: "old-refresh",
+						TokenType:    "Bearer",
+						Expiry:       time.Now().Add(tt.expiry),
+						Status:       tt.status,
+					},
+				},
+			}}
+			spotifyClient := &fakeSpotifyClient{product: "premium"}
+			subscriptionClient := &fakeSubscriptionClient{}
+			service := &UserService{
+				repo:               repo,
+				spotifyClient:      spotifyClient,
+				subscriptionClient: subscriptionClient,
+				spotifyConfig: oauth2.Config{
+					ClientID:     "client",
+					ClientSecret: "secret",
+					Endpoint:     oauth2.Endpoint{TokenURL: server.URL + "/api/token", AuthStyle: oauth2.AuthStyleInParams},
+				},
+			}
+
+			err := service.SyncSpotifySubscription("user-1", "user-token")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if refreshes != tt.wantRefreshes {
+				t.Errorf("refreshed %d times, want %d", refreshes, tt.wantRefreshes)
+			}
+			credentials := repo.users["user-1"].SpotifyCredentials
+			if credentials.AccessToken != tt.wantAccess || credentials.RefreshToken != tt.wantRefresh {
+				t.Errorf("stored tokens %q/%q, want %q/%q", credentials.AccessToken, credentials.RefreshToken, tt.wantAccess, tt.wantRefresh)
+			}
+			if credentials.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", credentials.Status, tt.wantStatus)
+			}
+			if tt.wantRefreshes > 0 && tt.wantErr == nil && !credentials.Expiry.After(time.Now().Add(50*time.Minute)) {
+				t.Errorf("refreshed expiry %v was not stored", credentials.Expiry)
+			}
+			if synced := len(subscriptionClient.products) > 0; synced != tt.wantSynced {
+				t.Errorf("synced = %v, want %v", synced, tt.wantSynced)
+			} else if synced {
+				if subscriptionClient.products[0] != "premium" {
+					t.Errorf("synced product %q", subscriptionClient.products[0])
+				}
+				if spotifyClient.tokens[0] != tt.wantAccess {
+					t.Errorf("profile read with token %q, want %q", spotifyClient.tokens[0], tt.wantAccess)
+				}
+			}
+		})
+	}
+}
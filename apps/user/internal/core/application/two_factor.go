@@ -0,0 +1,205 @@
+package application
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+const (
+	// totpIssuer names the account in authenticator apps
+	totpIssuer = "Subscription Tracker"
+	// challengeTTL is how long the second step of a login may take
+	challengeTTL      = 5 * time.Minute
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication already enabled")
+	ErrTwoFactorNotEnabled  = errors.New("two-factor authentication not enabled")
+	ErrTwoFactorNotEnrolled = errors.New("no two-factor enrolment to confirm")
+	ErrInvalidCode          = errors.New("invalid two-factor code")
+)
+
+// TOTPEnrolment is what an authenticator app needs to add the account
+type TOTPEnrolment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// startSession signs the user in on a new device, or returns a challenge token when the
+// user has a second factor. Every login method but passkeys goes through here.
+func (s *UserService) startSession(user *domain.User, client ClientInfo) (*AuthResponse, error) {
+	if user.PendingDeletion() {
+		return nil, ErrAccountPendingDeletion
+	}
+	if !user.HasSecondFactor() {
+		return s.createSession(user, client)
+	}
+	challenge, err := s.signer.Sign(jwt.MapClaims{
+		"user_id": user.ID,
+		"aud":     s.signer.Issuer(),
+		"exp":     time.Now().Add(challengeTTL).Unix(),
+		"type":    "2fa_challenge",
+	})
+	if err != nil {
+		return nil, err
+	}
+	var methods []string
+	if user.TwoFactor.Enabled {
+		methods = append(methods, "totp")
+	}
+	if len(user.Passkeys) > 0 {
+		methods = append(methods, "passkey")
+	}
+	return &AuthResponse{TwoFactorRequired: true, ChallengeToken: challenge, TwoFactorMethods: methods}, nil
+}
+
+// parseLoginChallenge returns the user a login challenge was issued for
+func (s *UserService) parseLoginChallenge(challenge string) (*domain.User, error) {
+	claims, err := s.signer.Verify(challenge, s.signer.Issuer())
+	if err != nil || claims["type"] != "2fa_challenge" {
+		return nil, ErrInvalidToken
+	}
+	userID, ok := claims["user_id"].(string)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// CompleteTwoFactorLogin exchanges a login challenge and a TOTP or recovery code for a session
+func (s *UserService) CompleteTwoFactorLogin(challenge string, code string, client ClientInfo) (*AuthResponse, error) {
+	user, err := s.parseLoginChallenge(challenge)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactor.Enabled {
+		return nil, ErrInvalidCode
+	}
+	// Codes are short, so guessing them is throttled like passwords
+	if err := s.checkLoginThrottle(user.Email, client.IP); err != nil {
+		return nil, err
+	}
+	if err := s.verifySecondFactor(user, code); err != nil {
+		if errors.Is(err, ErrInvalidCode) {
+			if err := s.recordLoginFailure(user, user.Email, client.IP); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+	return s.createSession(user, client)
+}
+
+// EnrolTOTP creates a pending TOTP secret for the user
+func (s *UserService) EnrolTOTP(userID string) (*TOTPEnrolment, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if user.TwoFactor.Enabled {
+		return nil, ErrTwoFactorEnabled
+	}
+	secret, err := domain.NewTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	user.TwoFactor.PendingSecret = secret
+	if err := s.repo.Update(user); err != nil {
+		return nil, err
+	}
+	return &TOTPEnrolment{Secret: secret, URI: domain.TOTPURI(totpIssuer, user.Email, secret)}, nil
+}
+
+// ConfirmTOTP enables the pending secret once the user proves their app generates its
+// codes, and returns the recovery codes. The codes are only stored as hashes.
+func (s *UserService) ConfirmTOTP(userID string, code string) ([]string, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if user.TwoFactor.Enabled {
+		return nil, ErrTwoFactorEnabled
+	}
+	if user.TwoFactor.PendingSecret == "" {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	step, ok := domain.ValidateTOTP(user.TwoFactor.PendingSecret, code, time.Now(), 0)
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		token, err := randomToken()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = strings.ToLower(token[:10])
+		hashes[i] = hashToken(codes[i])
+	}
+	user.TwoFactor = domain.TwoFactor{
+		Enabled:       true,
+		Secret:        user.TwoFactor.PendingSecret,
+		RecoveryCodes: hashes,
+		LastUsedStep:  step,
+		EnabledAt:     time.Now(),
+	}
+	if err := s.repo.Update(user); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTOTP removes the second factor after checking a current code. Guesses are throttled
+// like logins, so a stolen session cannot brute force its way past the second factor.
+func (s *UserService) DisableTOTP(userID string, code string, client ClientInfo) error {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if !user.TwoFactor.Enabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if err := s.checkLoginThrottle(user.Email, client.IP); err != nil {
+		return err
+	}
+	if err := s.verifySecondFactor(user, code); err != nil {
+		if errors.Is(err, ErrInvalidCode) {
+			if err := s.recordLoginFailure(user, user.Email, client.IP); err != nil {
+				return err
+			}
+		}
+		return err
+	}
+	user.TwoFactor = domain.TwoFactor{}
+	return s.repo.Update(user)
+}
+
+// verifySecondFactor accepts a TOTP code, which cannot be replayed, or consumes a recovery code
+func (s *UserService) verifySecondFactor(user *domain.User, code string) error {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if step, ok := domain.ValidateTOTP(user.TwoFactor.Secret, code, time.Now(), user.TwoFactor.LastUsedStep); ok {
+		user.TwoFactor.LastUsedStep = step
+		return s.repo.Update(user)
+	}
+
+	hash := hashToken(strings.ToLower(code))
+	for i, recoveryCode := range user.TwoFactor.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(recoveryCode), []byte(hash)) == 1 {
+			user.TwoFactor.RecoveryCodes = append(user.TwoFactor.RecoveryCodes[:i], user.TwoFactor.RecoveryCodes[i+1:]...)
+			return s.repo.Update(user)
+		}
+	}
+	return ErrInvalidCode
+}
@@ -0,0 +1,92 @@
+package application
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+const testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func newTwoFactorTestService() (*UserService, *fakeUserRepository) {
+	repo := &fakeUserRepository{users: map[string]domain.User{
+		"user-1": {ID: "user-1", Email: "user@example.com", TwoFactor: domain.TwoFactor{
+			Enabled:       true,
+			Secret:        testTOTPSecret,
+			RecoveryCodes: []string{hashToken("recovery-1"), hashToken("recovery-2")},
+		}},
+	}}
+	return &UserService{
+		repo:          repo,
+		signer:        fakeSigner{},
+		emailService:  &fakeEmailService{},
+		sessions:      &fakeSessionRepository{},
+		refreshTokens: &fakeRefreshTokenRepository{},
+		loginAttempts: &fakeLoginAttemptRepository{},
+	}, repo
+}
+
+func TestCompleteTwoFactorLoginCodesWorkOnce(t *testing.T) {
+	service, repo := newTwoFactorTestService()
+	user := repo.users["user-1"]
+	login, err := service.startSession(&user, ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !login.TwoFactorRequired {
+		t.Fatalf("login = %+v, want a two-factor challenge", login)
+	}
+	totp, err := domain.TOTPCode(testTOTPSecret, time.Now().Unix()/int64(domain.TOTPPeriod.Seconds()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		code    string
+		wantErr error
+	}{
+		{name: "totp code", code: totp},
+		{name: "replayed totp code", code: totp, wantErr: ErrInvalidCode},
+		// Recovery codes are compared without case and spaces
+		{name: "recovery code", code: " RECOVERY-1 "},
+		{name: "reused recovery code", code: "recovery-1", wantErr: ErrInvalidCode},
+		{name: "other recovery code", code: "recovery-2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := service.CompleteTwoFactorLogin(login.ChallengeToken, tt.code, ClientInfo{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && response.Token == "" {
+				t.Errorf("response = %+v, want a session", response)
+			}
+		})
+	}
+
+	if codes := repo.users["user-1"].TwoFactor.RecoveryCodes; len(codes) != 0 {
+		t.Errorf("%d recovery codes left, want 0", len(codes))
+	}
+}
+
+func TestDisableTOTPIsThrottled(t *testing.T) {
+	service, repo := newTwoFactorTestService()
+	client := ClientInfo{IP: "192.0.2.1"}
+
+	for i := 0; i < loginDelayAfter; i++ {
+		if err := service.DisableTOTP("user-1", "000000", client); !errors.Is(err, ErrInvalidCode) {
+			t.Fatalf("attempt %d: error = %v, want ErrInvalidCode", i+1, err)
+		}
+	}
+	// A correct recovery code has to wait for the delay too
+	var throttled *LoginThrottledError
+	if err := service.DisableTOTP("user-1", "recovery-1", client); !errors.As(err, &throttled) {
+		t.Fatalf("error = %v, want LoginThrottledError", err)
+	}
+	if user := repo.users["user-1"]; !user.TwoFactor.Enabled || len(user.TwoFactor.RecoveryCodes) != 2 {
+		t.Errorf("two factor = %+v, want it untouched", user.TwoFactor)
+	}
+}
@@ -4,11 +4,12 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -22,14 +23,53 @@ var (
 	ErrUserNotFound       = errors.New("user not found")
 	ErrInvalidToken       = errors.New("invalid token")
 	ErrSpotifyAuth        = errors.New("spotify authentication failed")
+	ErrRateLimited        = errors.New("too many requests")
 )
 
+// loginTokenTTL is how long an emailed login link stays valid
+const loginTokenTTL = 15 * time.Minute
+
 type UserRepository interface {
 	Create(user *domain.User) error
 	FindByEmail(email string) (*domain.User, error)
 	FindByID(id string) (*domain.User, error)
 	Update(user *domain.User) error
 	Delete(id string) error
+	FindByPasskeyID(id string) (*domain.User, error)
+	List(filter UserFilter, offset int, limit int) ([]domain.User, int64, error)
+	FindDueForPurge(before time.Time, limit int) ([]domain.User, error)
+}
+
+// LoginTokenRepository stores pending login links by the hashes of their token and browser binding
+type LoginTokenRepository interface {
+	Store(token string, binding string, userID string, expiry time.Time) error
+	Verify(token string, binding string) (string, error)
+	DeleteByUserID(userID string) error
+}
+
+// RateLimiter allows a number of events per key
+type RateLimiter interface {
+	Allow(key string) bool
+}
+
+// RateLimits bounds how often emails can be triggered
+type RateLimits struct {
+	// LoginLinkPerEmail and LoginLinkPerIP limit login link requests
+	LoginLinkPerEmail RateLimiter
+	LoginLinkPerIP    RateLimiter
+	// VerificationPerUser limits resent verification emails
+	VerificationPerUser RateLimiter
+	// PasswordResetPerEmail limits password reset requests
+	PasswordResetPerEmail RateLimiter
+	// DataExportPerUser limits personal data exports
+	DataExportPerUser RateLimiter
+}
+
+// TokenSigner signs the JWTs the service issues and verifies them by issuer and audience
+type TokenSigner interface {
+	Issuer() string
+	Sign(claims jwt.MapClaims) (string, error)
+	Verify(tokenString string, audience string) (jwt.MapClaims, error)
 }
 
 type EmailService interface {
@@ -37,15 +77,49 @@ type EmailService interface {
 }
 
 type SpotifyUser struct {
-	ID    string `json:"id"`
-	Email string `json:"email"`
-	Name  string `json:"display_name"`
+	ID      string `json:"id"`
+	Email   string `json:"email"`
+	Name    string `json:"display_name"`
+	Product string `json:"product"`
+}
+
+// SpotifyClient reads profiles from the Spotify Web API
+type SpotifyClient interface {
+	GetCurrentUser(ctx context.Context, token *oauth2.Token) (*SpotifyUser, error)
+}
+
+// SubscriptionClient calls the subscription service on behalf of a user
+type SubscriptionClient interface {
+	SyncSpotifySubscription(ctx context.Context, userToken string, product string) error
+	// PublishUserEvent delivers an event, authenticated with a service token. A nil error
+	// confirms the service processed it.
+	PublishUserEvent(ctx context.Context, serviceToken string, event UserEvent) error
+	// ExportUserData returns the JSON export of the user named in the service token
+	ExportUserData(ctx context.Context, serviceToken string) ([]byte, error)
+	// DownloadAttachment returns the contents of an attachment of that user; the caller must close it
+	DownloadAttachment(ctx context.Context, serviceToken string, attachmentUUID string) (io.ReadCloser, error)
 }
 
 type UserService struct {
-	repo          UserRepository
-	emailService  EmailService
-	spotifyConfig oauth2.Config
+	repo               UserRepository
+	emailService       EmailService
+	spotifyConfig      oauth2.Config
+	spotifyClient      SpotifyClient
+	subscriptionClient SubscriptionClient
+	providers          *ProviderRegistry
+	identities         IdentityRepository
+	sessions           SessionRepository
+	refreshTokens      RefreshTokenRepository
+	loginTokens        LoginTokenRepository
+	limits             RateLimits
+	signer             TokenSigner
+	passwordResets     PasswordResetRepository
+	passkeys           PasskeyVerifier
+	loginAttempts      LoginAttemptRepository
+	dataExports        DataExportRepository
+	exportFiles        FileStore
+	loginCodes         LoginCodeRepository
+	passkeyChallenges  PasskeyChallengeRepository
 }
 
 func (s *UserService) GenerateOAuthState() string {
@@ -54,10 +128,26 @@ func (s *UserService) GenerateOAuthState() string {
 	return base64.URLEncoding.EncodeToString(b)
 }
 
-func NewUserService(repo UserRepository, emailService EmailService) *UserService {
+func NewUserService(repo UserRepository, emailService EmailService, spotifyClient SpotifyClient, subscriptionClient SubscriptionClient, providers *ProviderRegistry, identities IdentityRepository, sessions SessionRepository, refreshTokens RefreshTokenRepository, loginTokens LoginTokenRepository, limits RateLimits, signer TokenSigner, passwordResets PasswordResetRepository, passkeys PasskeyVerifier, loginAttempts LoginAttemptRepository, dataExports DataExportRepository, exportFiles FileStore, loginCodes LoginCodeRepository, passkeyChallenges PasskeyChallengeRepository) *UserService {
 	return &UserService{
-		repo:         repo,
-		emailService: emailService,
+		repo:               repo,
+		emailService:       emailService,
+		spotifyClient:      spotifyClient,
+		subscriptionClient: subscriptionClient,
+		providers:          providers,
+		identities:         identities,
+		sessions:           sessions,
+		refreshTokens:      refreshTokens,
+		loginTokens:        loginTokens,
+		limits:             limits,
+		signer:             signer,
+		passwordResets:     passwordResets,
+		passkeys:           passkeys,
+		loginAttempts:      loginAttempts,
+		dataExports:        dataExports,
+		exportFiles:        exportFiles,
+		loginCodes:         loginCodes,
+		passkeyChallenges:  passkeyChallenges,
 		spotifyConfig: oauth2.Config{
 			Endpoint: oauth2.Endpoint{
 				AuthURL:  "https://accounts.spotify.com/authorize",
@@ -71,12 +161,19 @@ func NewUserService(repo UserRepository, emailService EmailService) *UserService
 	}
 }
 
+// AuthResponse is the result of a login. Users with a second factor get a challenge
+// token instead, to be exchanged for the session tokens with one of the listed methods.
 type AuthResponse struct {
-	Token string      `json:"token"`
-	User  domain.User `json:"user"`
+	Token             string       `json:"token,omitempty"`
+	RefreshToken      string       `json:"refresh_token,omitempty"`
+	ExpiresIn         int          `json:"expires_in,omitempty"`
+	User              *domain.User `json:"user,omitempty"`
+	TwoFactorRequired bool         `json:"two_factor_required,omitempty"`
+	ChallengeToken    string       `json:"challenge_token,omitempty"`
+	TwoFactorMethods  []string     `json:"two_factor_methods,omitempty"`
 }
 
-func (s *UserService) Register(email, name, password string) (*AuthResponse, error) {
+func (s *UserService) Register(email, name, password string, client ClientInfo) (*AuthResponse, error) {
 	// Check if user already exists
 	if _, err := s.repo.FindByEmail(email); err == nil {
 		return nil, ErrUserAlreadyExists
@@ -91,50 +188,81 @@ func (s *UserService) Register(email, name, password string) (*AuthResponse, err
 	if err := s.repo.Create(user); err != nil {
 		return nil, err
 	}
-
-	// Generate JWT token
-	token, err := s.generateToken(user)
-	if err != nil {
-		return nil, err
+	if err := s.sendVerificationEmail(user); err != nil {
+		log.Printf("Failed to send verification email to user %s: %v", user.ID, err)
 	}
 
-	return &AuthResponse{Token: token, User: *user}, nil
+	return s.startSession(user, client)
 }
 
-func (s *UserService) Login(email, password string) (*AuthResponse, error) {
+// Login checks the password of the account. Failed attempts are counted per account and
+// IP address, and delay or lock out further attempts with a LoginThrottledError.
+func (s *UserService) Login(email, password string, client ClientInfo) (*AuthResponse, error) {
+	if err := s.checkLoginThrottle(email, client.IP); err != nil {
+		return nil, err
+	}
 	user, err := s.repo.FindByEmail(email)
 	if err != nil {
+		// Unknown emails count too, so they cannot be told apart by their throttling
+		if err := s.recordLoginFailure(nil, email, client.IP); err != nil {
+			return nil, err
+		}
 		return nil, ErrInvalidCredentials
 	}
 
 	if err := user.ComparePassword(password); err != nil {
+		if err := s.recordLoginFailure(user, email, client.IP); err != nil {
+			return nil, err
+		}
 		return nil, ErrInvalidCredentials
 	}
-
-	// Generate JWT token
-	token, err := s.generateToken(user)
-	if err != nil {
+	if err := s.loginAttempts.Clear(accountAttemptKey(email)); err != nil {
 		return nil, err
 	}
 
-	return &AuthResponse{Token: token, User: *user}, nil
+	return s.startSession(user, client)
 }
 
 func (s *UserService) GetUserByID(id string) (*domain.User, error) {
 	return s.repo.FindByID(id)
 }
 
-func (s *UserService) generateToken(user *domain.User) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+// generateToken issues an access token; sessionID is empty for tokens that only call
+// other services on the user's behalf
+func (s *UserService) generateToken(user *domain.User, sessionID string) (string, error) {
+	claims := jwt.MapClaims{
 		"user_id": user.ID,
 		"email":   user.Email,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
-	})
+		// email_verified lets services restrict sensitive actions to proven addresses
+		"email_verified": user.EmailVerified,
+		// prefs lets services present the user's data without looking the user up
+		"prefs": preferenceClaims(user),
+		"aud":   AccessTokenAudience(),
+		"exp":   time.Now().Add(accessTokenTTL()).Unix(),
+	}
+	if sessionID != "" {
+		claims["sid"] = sessionID
+	}
+	return s.signer.Sign(claims)
+}
 
-	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+// GetUser returns a user the actor may view
+func (s *UserService) GetUser(actorID string, id string) (*domain.User, error) {
+	if err := s.authorize(actorID, ActionViewUser, id); err != nil {
+		return nil, err
+	}
+	user, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
 }
 
-func (s *UserService) UpdateUser(id string, name string, password string) error {
+// UpdateUser changes the name and optionally the password of a user the actor may update
+func (s *UserService) UpdateUser(actorID string, id string, name string, password string) error {
+	if err := s.authorize(actorID, ActionUpdateUser, id); err != nil {
+		return err
+	}
 	user, err := s.repo.FindByID(id)
 	if err != nil {
 		return ErrUserNotFound
@@ -147,133 +275,133 @@ func (s *UserService) UpdateUser(id string, name string, password string) error
 		}
 	}
 
-	return s.repo.Update(user)
-}
-
-func (s *UserService) DeleteUser(id string) error {
-	_, err := s.repo.FindByID(id)
-	if err != nil {
-		return ErrUserNotFound
+	if err := s.repo.Update(user); err != nil {
+		return err
 	}
-
-	return s.repo.Delete(id)
+	if password != "" {
+		s.notifyPasswordChanged(user.Email)
+	}
+	return nil
 }
 
-func (s *UserService) InitiatePasswordlessLogin(email string) error {
+// InitiatePasswordlessLogin emails a single-use login link to the user. The returned
+// binding has to be presented together with the link's token, so the link only works in
+// the browser that requested it.
+func (s *UserService) InitiatePasswordlessLogin(email string, ip string) (string, error) {
+	if !s.limits.LoginLinkPerIP.Allow(ip) || !s.limits.LoginLinkPerEmail.Allow(strings.ToLower(email)) {
+		return "", ErrRateLimited
+	}
 	user, err := s.repo.FindByEmail(email)
 	if err != nil {
-		return ErrUserNotFound
+		return "", ErrUserNotFound
 	}
 
 	// Generate login token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID,
-		"email":   user.Email,
-		"exp":     time.Now().Add(time.Minute * 15).Unix(),
-		"type":    "passwordless",
-	})
-
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	token, err := randomToken()
 	if err != nil {
-		return err
+		return "", err
+	}
+	binding, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.loginTokens.Store(hashToken(token), hashToken(binding), user.ID, time.Now().Add(loginTokenTTL)); err != nil {
+		return "", err
 	}
 
-	loginLink := fmt.Sprintf("http://localhost:3000/login/callback/passwordless?token=%s", tokenString)
+	loginLink := fmt.Sprintf("%s/login/callback/passwordless?token=%s", FrontendURL(), token)
 
 	// Send login email
 	err = s.emailService.Send(email, "Passwordless Login", fmt.Sprintf("Click the link to login: %s", loginLink))
 	if err != nil {
-		return fmt.Errorf("failed to send login email: %w", err)
+		return "", fmt.Errorf("failed to send login email: %w", err)
 	}
 
-	return nil
+	return binding, nil
 }
 
 func (s *UserService) GetSpotifyAuthURL(state string) string {
 	return s.spotifyConfig.AuthCodeURL(state)
 }
 
-func (s *UserService) HandleSpotifyCallback(code string) (*AuthResponse, error) {
-	token, err := s.spotifyConfig.Exchange(context.Background(), code)
+// HandleSpotifyCallback signs in the user of a Spotify account and returns the login code
+// the app exchanges for the session
+func (s *UserService) HandleSpotifyCallback(code string) (string, error) {
+	ctx := spotifyContext()
+	token, spotifyUser, err := s.exchangeSpotifyCode(ctx, code)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrSpotifyAuth, err)
+		return "", err
 	}
 
-	client := s.spotifyConfig.Client(context.Background(), token)
-	resp, err := client.Get("https://api.spotify.com/v1/me")
+	// Spotify does not tell whether the email is verified
+	user, err := s.signIn(SpotifyProvider, &ExternalProfile{
+		Subject: spotifyUser.ID,
+		Email:   spotifyUser.Email,
+		Name:    spotifyUser.Name,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrSpotifyAuth, err)
+		return "", err
+	}
+	if err := s.storeSpotifyLink(user, token, spotifyUser); err != nil {
+		return "", err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	userToken, err := s.generateToken(user, "")
 	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrSpotifyAuth, err)
+		return "", err
 	}
+	s.syncSpotifySubscription(ctx, user, userToken, spotifyUser)
 
-	var spotifyUser SpotifyUser
-	if err := json.Unmarshal(body, &spotifyUser); err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrSpotifyAuth, err)
+	return s.issueLoginCode(user)
+}
+
+// VerifyLoginToken signs in with a login link token; each token works once
+func (s *UserService) VerifyLoginToken(token string, binding string, client ClientInfo) (*AuthResponse, error) {
+	userID, err := s.loginTokens.Verify(hashToken(token), hashToken(binding))
+	if err != nil {
+		return nil, ErrInvalidToken
 	}
 
-	// Check if user exists
-	user, err := s.repo.FindByEmail(spotifyUser.Email)
+	user, err := s.repo.FindByID(userID)
 	if err != nil {
-		// Create new user if not exists
-		user, err = domain.NewUser(spotifyUser.Email, spotifyUser.Name, "")
-		if err != nil {
+		return nil, ErrUserNotFound
+	}
+	// Opening the emailed link proves the user owns the address
+	if !user.EmailVerified {
+		if err := s.claimUnverifiedAccount(user); err != nil {
 			return nil, err
 		}
-		if err := s.repo.Create(user); err != nil {
+		if err := s.repo.Update(user); err != nil {
+			return nil, err
+		}
+		if err := s.revokeSignIns(user.ID); err != nil {
 			return nil, err
 		}
-	}
-	// Update user's Spotify ID
-	user.SpotifyCredentials = domain.SpotifyCredentials{
-		AccessToken:  token.AccessToken,
-		RefreshToken: token.RefreshToken,
-		ExpiresIn:    int(token.ExpiresIn),
-	}
-	s.repo.Update(user)
-
-	// Generate JWT token
-	jwtToken, err := s.generateToken(user)
-	if err != nil {
-		return nil, err
 	}
 
-	return &AuthResponse{Token: jwtToken, User: *user}, nil
+	return s.startSession(user, client)
 }
 
-func (s *UserService) VerifyLoginToken(tokenString string) (*AuthResponse, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
-
-	if err != nil || !token.Valid {
-		return nil, ErrInvalidToken
-	}
-
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return nil, ErrInvalidToken
-	}
-
-	userID, ok := claims["user_id"].(string)
-	if !ok {
-		return nil, ErrInvalidToken
-	}
+// claimUnverifiedAccount marks the address as verified and drops every way to sign in that
+// was set up before. Anyone could have registered the address without owning it, so the
+// password, second factor, passkeys and linked logins may belong to someone else. The
+// caller saves the user and revokes its sessions.
+func (s *UserService) claimUnverifiedAccount(user *domain.User) error {
+	user.EmailVerified = true
+	user.Password = ""
+	user.TwoFactor = domain.TwoFactor{}
+	user.Passkeys = nil
+	user.SpotifyCredentials = domain.SpotifyCredentials{}
+	return s.identities.DeleteByUserID(user.ID)
+}
 
-	user, err := s.repo.FindByID(userID)
-	if err != nil {
-		return nil, ErrUserNotFound
+// revokeSignIns ends every session of the user and invalidates its pending login and reset links
+func (s *UserService) revokeSignIns(userID string) error {
+	if err := s.RevokeAllSessions(userID); err != nil {
+		return err
 	}
-
-	// Generate a new regular session token
-	newToken, err := s.generateToken(user)
-	if err != nil {
-		return nil, err
+	if err := s.loginTokens.DeleteByUserID(userID); err != nil {
+		return err
 	}
-
-	return &AuthResponse{Token: newToken, User: *user}, nil
+	return s.passwordResets.DeleteByUserID(userID)
 }
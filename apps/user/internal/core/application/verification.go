@@ -0,0 +1,86 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+// verificationTokenTTL is how long an emailed verification link stays valid
+const verificationTokenTTL = 48 * time.Hour
+
+var ErrEmailAlreadyVerified = errors.New("email already verified")
+
+// FrontendURL is the base URL of the web app used in emailed links, configurable
+// through FRONTEND_URL
+func FrontendURL() string {
+	if url := os.Getenv("FRONTEND_URL"); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+	return "http://localhost:3000"
+}
+
+// sendVerificationEmail emails a link proving the user owns their address. The token
+// names the address, so it stops working when the email changes.
+func (s *UserService) sendVerificationEmail(user *domain.User) error {
+	token, err := s.signer.Sign(jwt.MapClaims{
+		"user_id": user.ID,
+		"email":   user.Email,
+		"aud":     s.signer.Issuer(),
+		"exp":     time.Now().Add(verificationTokenTTL).Unix(),
+		"type":    "verify_email",
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", FrontendURL(), token)
+	return s.emailService.Send(user.Email, "Verify your email", fmt.Sprintf("Click the link to verify your email address: %s", link))
+}
+
+// VerifyEmail marks the address named in a verification token as verified
+func (s *UserService) VerifyEmail(token string) error {
+	claims, err := s.signer.Verify(token, s.signer.Issuer())
+	if err != nil || claims["type"] != "verify_email" {
+		return ErrInvalidToken
+	}
+	userID, ok := claims["user_id"].(string)
+	if !ok {
+		return ErrInvalidToken
+	}
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.Email != claims["email"] {
+		return ErrInvalidToken
+	}
+	if user.EmailVerified {
+		return nil
+	}
+	user.EmailVerified = true
+	return s.repo.Update(user)
+}
+
+// ResendVerificationEmail sends another verification link, a few times per hour at most
+func (s *UserService) ResendVerificationEmail(userID string) error {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.EmailVerified {
+		return ErrEmailAlreadyVerified
+	}
+	if !s.limits.VerificationPerUser.Allow(userID) {
+		return ErrRateLimited
+	}
+	if err := s.sendVerificationEmail(user); err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+const (
+	DataExportPending = "pending"
+	DataExportRunning = "running"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+)
+
+// DataExport is a requested archive of everything stored about a user
+type DataExport struct {
+	ID        string    `bson:"_id,omitempty" json:"id"`
+	UserID    string    `bson:"user_id" json:"-"`
+	Status    string    `bson:"status" json:"status"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	// StartedAt is when a worker picked up the export
+	StartedAt   *time.Time `bson:"started_at,omitempty" json:"-"`
+	CompletedAt *time.Time `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	// ExpiresAt is when the archive and its download link expire
+	ExpiresAt  *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	StorageKey string     `bson:"storage_key,omitempty" json:"-"`
+	Error      string     `bson:"error,omitempty" json:"-"`
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// AccountDeletion is a requested deletion of an account. Until PurgeAfter the account is
+// locked but can be restored; after it the account's data is purged in every service.
+type AccountDeletion struct {
+	RequestedAt time.Time `bson:"requested_at" json:"requested_at"`
+	RequestedBy string    `bson:"requested_by" json:"-"`
+	PurgeAfter  time.Time `bson:"purge_after" json:"purge_after"`
+	// PurgeAttempts counts the purges that failed, e.g. because a service was down
+	PurgeAttempts int `bson:"purge_attempts" json:"-"`
+}
+
+// PendingDeletion reports whether the user asked for their account to be deleted
+func (u *User) PendingDeletion() bool {
+	return u.Deletion != nil
+}
@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// Identity links an external login, identified by its provider and the provider's
+// subject, to a user. A user has at most one identity per provider.
+type Identity struct {
+	ID        string    `bson:"_id,omitempty" json:"id"`
+	UserID    string    `bson:"user_id" json:"user_id"`
+	Provider  string    `bson:"provider" json:"provider"`
+	Subject   string    `bson:"subject" json:"subject"`
+	Email     string    `bson:"email" json:"email"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// Passkey is a WebAuthn credential registered by a user
+type Passkey struct {
+	// ID is the base64url encoded credential ID
+	ID   string `bson:"id" json:"id"`
+	Name string `bson:"name" json:"name"`
+	// PublicKey is the COSE encoded credential public key
+	PublicKey  []byte     `bson:"public_key" json:"-"`
+	SignCount  uint32     `bson:"sign_count" json:"-"`
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+	LastUsedAt *time.Time `bson:"last_used_at,omitempty" json:"last_used_at"`
+}
+
+// FindPasskey returns the user's passkey with the given credential ID
+func (u *User) FindPasskey(id string) *Passkey {
+	for i := range u.Passkeys {
+		if u.Passkeys[i].ID == id {
+			return &u.Passkeys[i]
+		}
+	}
+	return nil
+}
+
+// HasSecondFactor reports whether logins have to be confirmed with a TOTP code or a passkey
+func (u *User) HasSecondFactor() bool {
+	return u.TwoFactor.Enabled || len(u.Passkeys) > 0
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// DigestFrequency is how often a user wants the summary of their subscriptions. Nothing
+// sends the digest on a schedule yet, so it is stored but not carried in access tokens.
+type DigestFrequency string
+
+const (
+	DigestNever   DigestFrequency = "never"
+	DigestDaily   DigestFrequency = "daily"
+	DigestWeekly  DigestFrequency = "weekly"
+	DigestMonthly DigestFrequency = "monthly"
+)
+
+// MaxReminderLeadDays bounds how early a renewal reminder can be sent
+const MaxReminderLeadDays = 30
+
+// NotificationChannels are the channels a user agreed to be notified on
+type NotificationChannels struct {
+	Email bool `bson:"email" json:"email"`
+	Push  bool `bson:"push" json:"push"`
+}
+
+// Preferences are the settings other services apply when presenting a user's data
+type Preferences struct {
+	// Currency is the ISO 4217 code totals are reported in
+	Currency string `bson:"currency" json:"currency"`
+	// TimeZone is an IANA time zone name such as Europe/Berlin
+	TimeZone string `bson:"time_zone" json:"time_zone"`
+	// Locale is a BCP 47 language tag such as en-GB
+	Locale string `bson:"locale" json:"locale"`
+	// WeekStart is the lower case English name of the first day of the week
+	WeekStart        string               `bson:"week_start" json:"week_start"`
+	ReminderLeadDays int                  `bson:"reminder_lead_days" json:"reminder_lead_days"`
+	DigestFrequency  DigestFrequency      `bson:"digest_frequency" json:"digest_frequency"`
+	Channels         NotificationChannels `bson:"channels" json:"channels"`
+}
+
+// DefaultPreferences returns the preferences of a user who has not changed any
+func DefaultPreferences() Preferences {
+	return Preferences{
+		Currency:         "USD",
+		TimeZone:         "UTC",
+		Locale:           "en-US",
+		WeekStart:        "monday",
+		ReminderLeadDays: 3,
+		DigestFrequency:  DigestWeekly,
+		Channels:         NotificationChannels{Email: true},
+	}
+}
+
+// Valid reports whether f is a known digest frequency
+func (f DigestFrequency) Valid() bool {
+	return f == DigestNever || f == DigestDaily || f == DigestWeekly || f == DigestMonthly
+}
+
+// ParseWeekday returns the weekday of a lower case English day name
+func ParseWeekday(name string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.ToLower(day.String()) == name {
+			return day, true
+		}
+	}
+	return time.Sunday, false
+}
+
+// EffectivePreferences returns the user's preferences; users created before preferences
+// existed have none and get the defaults
+func (u *User) EffectivePreferences() Preferences {
+	if u.Preferences == nil {
+		return DefaultPreferences()
+	}
+	return *u.Preferences
+}
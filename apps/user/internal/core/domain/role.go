@@ -0,0 +1,26 @@
+package domain
+
+// Role grants a user access beyond their own account
+type Role string
+
+const (
+	RoleUser Role = "user"
+	// RoleSupport can look up other users but not change them
+	RoleSupport Role = "support"
+	// RoleAdmin can manage every user
+	RoleAdmin Role = "admin"
+)
+
+// Valid reports whether r is a known role
+func (r Role) Valid() bool {
+	return r == RoleUser || r == RoleSupport || r == RoleAdmin
+}
+
+// EffectiveRole returns the user's role; users created before roles existed have none
+// and are regular users
+func (u *User) EffectiveRole() Role {
+	if u.Role == "" {
+		return RoleUser
+	}
+	return u.Role
+}
@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+// Session is a signed in device. Its refresh tokens form one family: every refresh
+// rotates the token, and presenting a rotated token again revokes the whole session.
+type Session struct {
+	ID         string     `bson:"_id,omitempty" json:"id"`
+	UserID     string     `bson:"user_id" json:"-"`
+	UserAgent  string     `bson:"user_agent" json:"user_agent"`
+	IP         string     `bson:"ip" json:"ip"`
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+	LastUsedAt time.Time  `bson:"last_used_at" json:"last_used_at"`
+	ExpiresAt  time.Time  `bson:"expires_at" json:"expires_at"`
+	RevokedAt  *time.Time `bson:"revoked_at,omitempty" json:"-"`
+	// Current marks the session of the request
+	Current bool `bson:"-" json:"current"`
+}
+
+// Active reports whether the session can still be refreshed
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// RefreshToken is a single-use refresh token of a session. Only its hash is stored.
+type RefreshToken struct {
+	TokenHash string     `bson:"token_hash"`
+	SessionID string     `bson:"session_id"`
+	UserID    string     `bson:"user_id"`
+	ExpiresAt time.Time  `bson:"expires_at"`
+	UsedAt    *time.Time `bson:"used_at"`
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	// TOTPPeriod and TOTPDigits are the RFC 6238 defaults authenticator apps expect
+	TOTPPeriod = 30 * time.Second
+	TOTPDigits = 6
+	// totpSkew is the number of periods a code may be early or late
+	totpSkew = 1
+)
+
+// TwoFactor is the TOTP second factor of a user. A secret is pending until the user
+// confirms it with a first code.
+type TwoFactor struct {
+	Enabled       bool      `bson:"enabled" json:"enabled"`
+	Secret        string    `bson:"secret,omitempty" json:"-"`
+	PendingSecret string    `bson:"pending_secret,omitempty" json:"-"`
+	RecoveryCodes []string  `bson:"recovery_codes,omitempty" json:"-"`
+	LastUsedStep  int64     `bson:"last_used_step,omitempty" json:"-"`
+	EnabledAt     time.Time `bson:"enabled_at,omitempty" json:"enabled_at,omitempty"`
+}
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewTOTPSecret returns a random base32 encoded 160-bit secret
+func NewTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPURI returns the otpauth URI authenticator apps enrol from
+func TOTPURI(issuer string, account string, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(TOTPDigits))
+	query.Set("period", fmt.Sprint(int(TOTPPeriod.Seconds())))
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// TOTPCode returns the code of secret for the given time step
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		return "", err
+	}
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", TOTPDigits, value%1000000), nil
+}
+
+// ValidateTOTP checks code against the steps around now and returns the matching step.
+// Steps up to after are rejected so a code cannot be replayed.
+func ValidateTOTP(secret string, code string, now time.Time, after int64) (int64, bool) {
+	current := now.Unix() / int64(TOTPPeriod.Seconds())
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= after {
+			continue
+		}
+		expected, err := TOTPCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors
+var rfc6238Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode(t *testing.T) {
+	// The RFC lists eight digit codes; six digit codes are their last six digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+
+	for _, tt := range tests {
+		step := tt.unix / int64(TOTPPeriod.Seconds())
+		got, err := TOTPCode(rfc6238Secret, step)
+		if err != nil {
+			t.Fatalf("TOTPCode: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTPCode at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Error("TOTPCode accepted an invalid secret")
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := now.Unix() / int64(TOTPPeriod.Seconds())
+	code := func(step int64) string {
+		code, err := TOTPCode(rfc6238Secret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		after    int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "current code", code: code(current), wantStep: current, wantOK: true},
+		{name: "one step early", code: code(current + 1), wantStep: current + 1, wantOK: true},
+		{name: "one step late", code: code(current - 1), wantStep: current - 1, wantOK: true},
+		{name: "two steps early", code: code(current + 2)},
+		{name: "two steps late", code: code(current - 2)},
+		{name: "wrong code", code: "000000"},
+		{name: "replayed code", code: code(current), after: current},
+		{name: "code older than the last used", code: code(current - 1), after: current},
+		{name: "code newer than the last used", code: code(current + 1), after: current, wantStep: current + 1, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(rfc6238Secret, tt.code, now, tt.after)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("ValidateTOTP = %d, %v, want %d, %v", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
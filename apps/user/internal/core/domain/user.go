@@ -6,18 +6,41 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	SpotifyLinkActive = "active"
+	// SpotifyLinkBroken means Spotify revoked the grant and the user has to link again
+	SpotifyLinkBroken = "broken"
+)
+
+// SpotifyCredentials holds the OAuth tokens of a linked Spotify account. The tokens are
+// never serialised to clients.
 type SpotifyCredentials struct {
-	AccessToken  string `bson:"access_token" json:"access_token"`
-	RefreshToken string `bson:"refresh_token" json:"refresh_token"`
-	ExpiresIn    int    `bson:"expires_in" json:"expires_in"`
+	SpotifyID    string    `bson:"spotify_id,omitempty" json:"spotify_id,omitempty"`
+	AccessToken  string    `bson:"access_token" json:"-"`
+	RefreshToken string    `bson:"refresh_token" json:"-"`
+	TokenType    string    `bson:"token_type" json:"-"`
+	Expiry       time.Time `bson:"expiry" json:"expiry"`
+	Status       string    `bson:"status" json:"status"`
+	LinkedAt     time.Time `bson:"linked_at" json:"linked_at"`
+}
+
+// IsLinked reports whether the credentials can be used to call Spotify
+func (c *SpotifyCredentials) IsLinked() bool {
+	return c.Status == SpotifyLinkActive && c.RefreshToken != ""
 }
 
 type User struct {
 	ID                 string             `bson:"_id,omitempty" json:"id"`
 	Name               string             `bson:"name" json:"name"`
 	Email              string             `bson:"email" json:"email"`
-	Password           string             `bson:"password,omitempty" json:"password,omitempty"`
+	EmailVerified      bool               `bson:"email_verified" json:"email_verified"`
+	Password           string             `bson:"password,omitempty" json:"-"`
+	Role               Role               `bson:"role,omitempty" json:"role"`
 	SpotifyCredentials SpotifyCredentials `bson:"spotify_credentials" json:"spotify_credentials"`
+	TwoFactor          TwoFactor          `bson:"two_factor" json:"two_factor"`
+	Passkeys           []Passkey          `bson:"passkeys" json:"passkeys"`
+	Deletion           *AccountDeletion   `bson:"deletion" json:"deletion,omitempty"`
+	Preferences        *Preferences       `bson:"preferences,omitempty" json:"-"`
 	CreatedAt          time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt          time.Time          `bson:"updated_at" json:"updated_at"`
 }
@@ -35,6 +58,7 @@ func NewUser(email, name, password string) (*User, error) {
 	user := &User{
 		Email: email,
 		Name:  name,
+		Role:  RoleUser,
 	}
 	if password != "" {
 		if err := user.HashedPassword(password); err != nil {
@@ -0,0 +1,237 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrNoSigningKey is returned by Load when no signing key is configured and generating
+// an ephemeral one was not allowed
+var ErrNoSigningKey = errors.New("no JWT signing key configured")
+
+// JWK is a public key as published in the JWKS
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type key struct {
+	id     string
+	method jwt.SigningMethod
+	public crypto.PublicKey
+	jwk    JWK
+}
+
+// KeySet signs tokens with the current key and verifies tokens signed by the current or
+// a retired key. To rotate, load the new key as the signing key and keep the old one as
+// a verification key until the tokens it signed have expired.
+type KeySet struct {
+	issuer  string
+	signing crypto.Signer
+	current *key
+	keys    map[string]*key
+}
+
+// NewKeySet creates a key set from a signing key and the public halves of retired keys
+func NewKeySet(issuer string, signing crypto.Signer, retired ...crypto.PublicKey) (*KeySet, error) {
+	current, err := newKey(signing.Public())
+	if err != nil {
+		return nil, err
+	}
+	set := &KeySet{issuer: issuer, signing: signing, current: current, keys: map[string]*key{current.id: current}}
+	for _, public := range retired {
+		k, err := newKey(public)
+		if err != nil {
+			return nil, err
+		}
+		set.keys[k.id] = k
+	}
+	return set, nil
+}
+
+// Load reads the PEM encoded signing key at signingPath and the retired keys at
+// retiredPaths. Without a signing key it fails with ErrNoSigningKey, unless allowEphemeral
+// is set for development: then an Ed25519 key is generated, which invalidates every token
+// when the service restarts and differs between instances.
+func Load(issuer string, signingPath string, retiredPaths []string, allowEphemeral bool) (*KeySet, error) {
+	var signing crypto.Signer
+	if signingPath == "" {
+		if !allowEphemeral {
+			return nil, ErrNoSigningKey
+		}
+		log.Printf("No JWT signing key configured, generating an ephemeral Ed25519 key")
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		signing = private
+	} else {
+		k, err := readPEM(signingPath)
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		if signing, ok = k.(crypto.Signer); !ok {
+			return nil, fmt.Errorf("%s does not hold a private key", signingPath)
+		}
+	}
+
+	var retired []crypto.PublicKey
+	for _, path := range retiredPaths {
+		k, err := readPEM(path)
+		if err != nil {
+			return nil, err
+		}
+		if signer, ok := k.(crypto.Signer); ok {
+			k = signer.Public()
+		}
+		retired = append(retired, k)
+	}
+	return NewKeySet(issuer, signing, retired...)
+}
+
+// readPEM parses a PKCS#8 or PKCS#1 private key or a PKIX public key
+func readPEM(path string) (interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not PEM encoded", path)
+	}
+	if k, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return k, nil
+	}
+	if k, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return k, nil
+	}
+	if k, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return k, nil
+	}
+	return nil, fmt.Errorf("%s holds no supported key", path)
+}
+
+// newKey derives the signing method and the JWK of a public key. The key ID is the
+// RFC 7638 thumbprint, so it stays the same across restarts and instances.
+func newKey(public crypto.PublicKey) (*key, error) {
+	k := &key{public: public}
+	var thumbprintInput interface{}
+	switch p := public.(type) {
+	case *rsa.PublicKey:
+		k.method = jwt.SigningMethodRS256
+		k.jwk = JWK{
+			Kty: "RSA",
+			Alg: k.method.Alg(),
+			N:   base64.RawURLEncoding.EncodeToString(p.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.E)).Bytes()),
+		}
+		thumbprintInput = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{k.jwk.E, k.jwk.Kty, k.jwk.N}
+	case ed25519.PublicKey:
+		k.method = jwt.SigningMethodEdDSA
+		k.jwk = JWK{
+			Kty: "OKP",
+			Alg: k.method.Alg(),
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(p),
+		}
+		thumbprintInput = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{k.jwk.Crv, k.jwk.Kty, k.jwk.X}
+	default:
+		return nil, errors.New("unsupported key type, use RSA or Ed25519")
+	}
+
+	canonical, err := json.Marshal(thumbprintInput)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(canonical)
+	k.id = base64.RawURLEncoding.EncodeToString(sum[:])
+	k.jwk.Kid = k.id
+	k.jwk.Use = "sig"
+	return k, nil
+}
+
+// Issuer is the iss claim of the tokens the set signs
+func (s *KeySet) Issuer() string {
+	return s.issuer
+}
+
+// Sign signs claims with the current key, setting the issuer and the key ID
+func (s *KeySet) Sign(claims jwt.MapClaims) (string, error) {
+	claims["iss"] = s.issuer
+	token := jwt.NewWithClaims(s.current.method, claims)
+	token.Header["kid"] = s.current.id
+	return token.SignedString(s.signing)
+}
+
+// Verify parses a token signed by one of the keys and checks its expiry, issuer and audience
+func (s *KeySet) Verify(tokenString string, audience string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		k, ok := s.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		if token.Method.Alg() != k.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+		return k.public, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(s.issuer),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+	return claims, nil
+}
+
+// JWKS returns the public keys, current key first
+func (s *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{s.current.jwk}}
+	for id, k := range s.keys {
+		if id != s.current.id {
+			jwks.Keys = append(jwks.Keys, k.jwk)
+		}
+	}
+	return jwks
+}
@@ -0,0 +1,265 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testAudience = "subscription-tracker"
+
+func newEd25519(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return private
+}
+
+func newRSA(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return private
+}
+
+func newKeySet(t *testing.T, issuer string, signing crypto.Signer, retired ...crypto.PublicKey) *KeySet {
+	t.Helper()
+	set, err := NewKeySet(issuer, signing, retired...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return set
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{"sub": "user-1", "aud": testAudience, "exp": time.Now().Add(time.Hour).Unix()}
+}
+
+func sign(t *testing.T, set *KeySet, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := set.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestVerify(t *testing.T) {
+	current := newEd25519(t)
+	retired := newRSA(t)
+	set := newKeySet(t, "user-service", current, retired.Public())
+	currentKid := set.current.id
+	retiredSet := newKeySet(t, "user-service", retired)
+
+	tests := []struct {
+		name    string
+		token   func(t *testing.T) string
+		wantErr bool
+	}{
+		{name: "current key", token: func(t *testing.T) string { return sign(t, set, validClaims()) }},
+		{name: "retired key", token: func(t *testing.T) string { return sign(t, retiredSet, validClaims()) }},
+		{name: "audience in a list", token: func(t *testing.T) string {
+			claims := validClaims()
+			claims["aud"] = []string{"other", testAudience}
+			return sign(t, set, claims)
+		}},
+		{name: "wrong audience", wantErr: true, token: func(t *testing.T) string {
+			claims := validClaims()
+			claims["aud"] = "subscription-tracker-internal"
+			return sign(t, set, claims)
+		}},
+		{name: "no audience", wantErr: true, token: func(t *testing.T) string {
+			claims := validClaims()
+			delete(claims, "aud")
+			return sign(t, set, claims)
+		}},
+		{name: "wrong issuer", wantErr: true, token: func(t *testing.T) string {
+			return sign(t, newKeySet(t, "someone-else", current), validClaims())
+		}},
+		{name: "expired", wantErr: true, token: func(t *testing.T) string {
+			claims := validClaims()
+			claims["exp"] = time.Now().Add(-time.Minute).Unix()
+			return sign(t, set, claims)
+		}},
+		{name: "no expiry", wantErr: true, token: func(t *testing.T) string {
+			claims := validClaims()
+			delete(claims, "exp")
+			return sign(t, set, claims)
+		}},
+		{name: "unknown key", wantErr: true, token: func(t *testing.T) string {
+			return sign(t, newKeySet(t, "user-service", newEd25519(t)), validClaims())
+		}},
+		{name: "no key id", wantErr: true, token: func(t *testing.T) string {
+			claims := validClaims()
+			claims["iss"] = "user-service"
+			token, err := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims).SignedString(current)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return token
+		}},
+		// A token naming a trusted key ID but signed by another key
+		{name: "forged with a trusted key id", wantErr: true, token: func(t *testing.T) string {
+			claims := validClaims()
+			claims["iss"] = "user-service"
+			token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+			token.Header["kid"] = currentKid
+			signed, err := token.SignedString(newEd25519(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return signed
+		}},
+		// The Ed25519 key ID with an RSA signature must not be looked up as the RSA key
+		{name: "algorithm of another key", wantErr: true, token: func(t *testing.T) string {
+			claims := validClaims()
+			claims["iss"] = "user-service"
+			token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+			token.Header["kid"] = currentKid
+			signed, err := token.SignedString(retired)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return signed
+		}},
+		// HMAC keyed with the public key, the classic algorithm confusion attack
+		{name: "hmac with the public key", wantErr: true, token: func(t *testing.T) string {
+			claims := validClaims()
+			claims["iss"] = "user-service"
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+			token.Header["kid"] = currentKid
+			signed, err := token.SignedString([]byte(current.Public().(ed25519.PublicKey)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return signed
+		}},
+		{name: "unsigned", wantErr: true, token: func(t *testing.T) string {
+			claims := validClaims()
+			claims["iss"] = "user-service"
+			token := jwt.NewWithClaims(jwt.SigningMethodNone, claims)
+			token.Header["kid"] = currentKid
+			signed, err := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return signed
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := set.Verify(tt.token(t), testAudience)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Verify accepted the token: %v", claims)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if claims["sub"] != "user-1" {
+				t.Errorf("sub = %v, want user-1", claims["sub"])
+			}
+		})
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	retired := newRSA(t)
+	set := newKeySet(t, "user-service", newEd25519(t), retired.Public())
+	again := newKeySet(t, "user-service", set.signing, retired.Public())
+
+	jwks := set.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("JWKS has %d keys, want 2", len(jwks.Keys))
+	}
+	if current := jwks.Keys[0]; current.Kid != set.current.id || current.Alg != "EdDSA" || current.Crv != "Ed25519" {
+		t.Errorf("first key = %+v, want the current Ed25519 key", current)
+	}
+	if old := jwks.Keys[1]; old.Alg != "RS256" || old.N == "" || old.E != "AQAB" {
+		t.Errorf("second key = %+v, want the retired RSA key", old)
+	}
+	// Key IDs are thumbprints, so they survive restarts
+	if again.current.id != set.current.id {
+		t.Errorf("key id changed from %s to %s for the same key", set.current.id, again.current.id)
+	}
+}
+
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	signing := newEd25519(t)
+	signingDER, err := x509.MarshalPKCS8PrivateKey(signing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingPath := writePEM(t, "PRIVATE KEY", signingDER)
+	retired := newRSA(t)
+	retiredPath := writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(retired))
+	publicDER, err := x509.MarshalPKIXPublicKey(signing.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPath := writePEM(t, "PUBLIC KEY", publicDER)
+
+	t.Run("signing and retired keys", func(t *testing.T) {
+		set, err := Load("user-service", signingPath, []string{retiredPath}, false)
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if len(set.keys) != 2 {
+			t.Errorf("loaded %d keys, want 2", len(set.keys))
+		}
+		token := sign(t, newKeySet(t, "user-service", retired), validClaims())
+		if _, err := set.Verify(token, testAudience); err != nil {
+			t.Errorf("token of the retired key: %v", err)
+		}
+	})
+	t.Run("no signing key", func(t *testing.T) {
+		if _, err := Load("user-service", "", nil, false); !errors.Is(err, ErrNoSigningKey) {
+			t.Errorf("error = %v, want ErrNoSigningKey", err)
+		}
+	})
+	t.Run("ephemeral key allowed", func(t *testing.T) {
+		set, err := Load("user-service", "", nil, true)
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if _, err := set.Verify(sign(t, set, validClaims()), testAudience); err != nil {
+			t.Errorf("Verify: %v", err)
+		}
+	})
+	t.Run("public key as signing key", func(t *testing.T) {
+		if _, err := Load("user-service", publicPath, nil, false); err == nil {
+			t.Error("Load accepted a public key as the signing key")
+		}
+	})
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := Load("user-service", filepath.Join(t.TempDir(), "missing.pem"), nil, false); err == nil {
+			t.Error("Load accepted a missing key file")
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/subscription-tracker/user/internal/core/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DataExportRepository struct {
+	collection *mongo.Collection
+}
+
+func NewDataExportRepository(db *mongo.Database) (*DataExportRepository, error) {
+	collection := db.Collection("data_exports")
+	_, err := collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &DataExportRepository{collection: collection}, nil
+}
+
+func (r *DataExportRepository) Create(export *domain.DataExport) error {
+	result, err := r.collection.InsertOne(context.Background(), export)
+	if err != nil {
+		return err
+	}
+	if objectID, ok := result.InsertedID.(primitive.ObjectID); ok {
+		export.ID = objectID.Hex()
+	}
+	return nil
+}
+
+func (r *DataExportRepository) FindByID(id string) (*domain.DataExport, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var export domain.DataExport
+	if err := r.collection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&export); err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// FindByUserID returns the user's exports, newest first
+func (r *DataExportRepository) FindByUserID(userID string) ([]domain.DataExport, error) {
+	cursor, err := r.collection.Find(context.Background(), bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	exports := []domain.DataExport{}
+	if err := cursor.All(context.Background(), &exports); err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+// ClaimNext marks the oldest pending export as running and returns it. Exports left
+// running since before staleBefore, by a worker that died, are claimed again.
+func (r *DataExportRepository) ClaimNext(staleBefore time.Time) (*domain.DataExport, error) {
+	now := time.Now()
+	var export domain.DataExport
+	err := r.collection.FindOneAndUpdate(context.Background(),
+		bson.M{"$or": bson.A{
+			bson.M{"status": domain.DataExportPending},
+			bson.M{"status": domain.DataExportRunning, "started_at": bson.M{"$lt": staleBefore}},
+		}},
+		bson.M{"$set": bson.M{"status": domain.DataExportRunning, "started_at": now}},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "created_at", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&export)
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *DataExportRepository) Update(export *domain.DataExport) error {
+	objectID, err := primitive.ObjectIDFromHex(export.ID)
+	if err != nil {
+		return err
+	}
+	replacement := *export
+	replacement.ID = ""
+	_, err = r.collection.ReplaceOne(context.Background(), bson.M{"_id": objectID}, replacement)
+	return err
+}
+
+// FindExpired returns the exports whose archive expired before the given time
+func (r *DataExportRepository) FindExpired(before time.Time) ([]domain.DataExport, error) {
+	cursor, err := r.collection.Find(context.Background(), bson.M{"expires_at": bson.M{"$lt": before}})
+	if err != nil {
+		return nil, err
+	}
+	var exports []domain.DataExport
+	if err := cursor.All(context.Background(), &exports); err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+func (r *DataExportRepository) Delete(id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	_, err = r.collection.DeleteOne(context.Background(), bson.M{"_id": objectID})
+	return err
+}
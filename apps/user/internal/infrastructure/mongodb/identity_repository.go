@@ -0,0 +1,80 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/subscription-tracker/user/internal/core/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IdentityRepository struct {
+	collection *mongo.Collection
+}
+
+// NewIdentityRepository creates the repository and the unique indexes that keep a
+// provider subject on one user and a user on one identity per provider
+func NewIdentityRepository(db *mongo.Database) (*IdentityRepository, error) {
+	collection := db.Collection("identities")
+	_, err := collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "provider", Value: 1}, {Key: "subject", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "provider", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &IdentityRepository{collection: collection}, nil
+}
+
+func (r *IdentityRepository) Create(identity *domain.Identity) error {
+	identity.CreatedAt = time.Now()
+	result, err := r.collection.InsertOne(context.Background(), identity)
+	if err != nil {
+		return err
+	}
+	if objectID, ok := result.InsertedID.(primitive.ObjectID); ok {
+		identity.ID = objectID.Hex()
+	}
+	return nil
+}
+
+func (r *IdentityRepository) FindByProviderSubject(provider string, subject string) (*domain.Identity, error) {
+	var identity domain.Identity
+	err := r.collection.FindOne(context.Background(), bson.M{"provider": provider, "subject": subject}).Decode(&identity)
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *IdentityRepository) FindByUserID(userID string) ([]domain.Identity, error) {
+	cursor, err := r.collection.Find(context.Background(), bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	identities := make([]domain.Identity, 0)
+	if err := cursor.All(context.Background(), &identities); err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+func (r *IdentityRepository) Delete(userID string, provider string) error {
+	_, err := r.collection.DeleteOne(context.Background(), bson.M{"user_id": userID, "provider": provider})
+	return err
+}
+
+func (r *IdentityRepository) DeleteByUserID(userID string) error {
+	_, err := r.collection.DeleteMany(context.Background(), bson.M{"user_id": userID})
+	return err
+}
@@ -0,0 +1,124 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LoginAttemptDocument is one failed login for an account or IP key
+type LoginAttemptDocument struct {
+	Key       string    `bson:"key"`
+	At        time.Time `bson:"at"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// LoginLockoutDocument blocks logins for a key until it expires
+type LoginLockoutDocument struct {
+	Key   string    `bson:"key"`
+	Until time.Time `bson:"until"`
+}
+
+// LoginAttemptRepository keeps failed logins as a log per key, so attempts can be counted
+// over a sliding window, and the lockouts they caused
+type LoginAttemptRepository struct {
+	attempts *mongo.Collection
+	lockouts *mongo.Collection
+}
+
+// NewLoginAttemptRepository creates the repository; old attempts and lockouts are removed by TTL indexes
+func NewLoginAttemptRepository(db *mongo.Database) (*LoginAttemptRepository, error) {
+	attempts := db.Collection("login_attempts")
+	_, err := attempts.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "key", Value: 1}, {Key: "at", Value: -1}}},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	lockouts := db.Collection("login_lockouts")
+	_, err = lockouts.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "until", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &LoginAttemptRepository{attempts: attempts, lockouts: lockouts}, nil
+}
+
+func (r *LoginAttemptRepository) Record(key string, at time.Time, expiry time.Time) error {
+	_, err := r.attempts.InsertOne(context.Background(), LoginAttemptDocument{
+		Key:       key,
+		At:        at,
+		ExpiresAt: expiry,
+	})
+	return err
+}
+
+// Failures counts the attempts of key since the given time and returns the latest one
+func (r *LoginAttemptRepository) Failures(key string, since time.Time) (int, time.Time, error) {
+	filter := bson.M{"key": key, "at": bson.M{"$gt": since}}
+	count, err := r.attempts.CountDocuments(context.Background(), filter)
+	if err != nil || count == 0 {
+		return 0, time.Time{}, err
+	}
+
+	var latest LoginAttemptDocument
+	err = r.attempts.FindOne(context.Background(), filter,
+		options.FindOne().SetSort(bson.D{{Key: "at", Value: -1}})).Decode(&latest)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return int(count), latest.At, nil
+}
+
+func (r *LoginAttemptRepository) Clear(key string) error {
+	_, err := r.attempts.DeleteMany(context.Background(), bson.M{"key": key})
+	return err
+}
+
+// Lock blocks key until the given time and reports whether it was not locked already
+func (r *LoginAttemptRepository) Lock(key string, until time.Time) (bool, error) {
+	var previous LoginLockoutDocument
+	err := r.lockouts.FindOneAndUpdate(context.Background(),
+		bson.M{"key": key},
+		bson.M{"$set": bson.M{"until": until}},
+		options.FindOneAndUpdate().SetUpsert(true),
+	).Decode(&previous)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	// The TTL monitor removes expired lockouts only periodically
+	return !previous.Until.After(time.Now()), nil
+}
+
+// LockedUntil returns the end of the lockout of key, or the zero time when it is not locked
+func (r *LoginAttemptRepository) LockedUntil(key string) (time.Time, error) {
+	var lockout LoginLockoutDocument
+	err := r.lockouts.FindOne(context.Background(), bson.M{
+		"key":   key,
+		"until": bson.M{"$gt": time.Now()},
+	}).Decode(&lockout)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, nil
+	} else if err != nil {
+		return time.Time{}, err
+	}
+	return lockout.Until, nil
+}
@@ -0,0 +1,62 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LoginCodeDocument is the pending code of a provider login; the code is stored as a hash only
+type LoginCodeDocument struct {
+	Code      string    `bson:"code"`
+	UserID    string    `bson:"user_id"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+type LoginCodeRepository struct {
+	collection *mongo.Collection
+}
+
+// NewLoginCodeRepository creates the repository; expired codes are removed by a TTL index
+func NewLoginCodeRepository(db *mongo.Database) (*LoginCodeRepository, error) {
+	collection := db.Collection("login_codes")
+	_, err := collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "code", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &LoginCodeRepository{collection: collection}, nil
+}
+
+func (r *LoginCodeRepository) Store(code string, userID string, expiry time.Time) error {
+	_, err := r.collection.InsertOne(context.Background(), LoginCodeDocument{
+		Code:      code,
+		UserID:    userID,
+		ExpiresAt: expiry,
+	})
+	return err
+}
+
+// Consume deletes an unexpired code and returns its user
+func (r *LoginCodeRepository) Consume(code string) (string, error) {
+	var doc LoginCodeDocument
+	err := r.collection.FindOneAndDelete(context.Background(), bson.M{
+		"code":       code,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}).Decode(&doc)
+	if err != nil {
+		return "", err
+	}
+	return doc.UserID, nil
+}
@@ -0,0 +1,63 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PasskeyChallengeDocument is an open passkey ceremony session
+type PasskeyChallengeDocument struct {
+	SessionID string    `bson:"session_id"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+type PasskeyChallengeRepository struct {
+	collection *mongo.Collection
+}
+
+// NewPasskeyChallengeRepository creates the repository; expired sessions are removed by a TTL index
+func NewPasskeyChallengeRepository(db *mongo.Database) (*PasskeyChallengeRepository, error) {
+	collection := db.Collection("passkey_challenges")
+	_, err := collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "session_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &PasskeyChallengeRepository{collection: collection}, nil
+}
+
+func (r *PasskeyChallengeRepository) Store(id string, expiry time.Time) error {
+	_, err := r.collection.InsertOne(context.Background(), PasskeyChallengeDocument{
+		SessionID: id,
+		ExpiresAt: expiry,
+	})
+	return err
+}
+
+// Consume deletes an unexpired session; of concurrent calls with the same ID only one succeeds
+func (r *PasskeyChallengeRepository) Consume(id string) error {
+	result, err := r.collection.DeleteOne(context.Background(), bson.M{
+		"session_id": id,
+		"expires_at": bson.M{"$gt": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return errors.New("passkey session not found")
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PasswordResetDocument is a pending password reset; the token is stored as a hash only
+type PasswordResetDocument struct {
+	Token     string    `bson:"token"`
+	UserID    string    `bson:"user_id"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+type PasswordResetRepository struct {
+	collection *mongo.Collection
+}
+
+// NewPasswordResetRepository creates the repository; expired tokens are removed by a TTL index
+func NewPasswordResetRepository(db *mongo.Database) (*PasswordResetRepository, error) {
+	collection := db.Collection("password_reset_tokens")
+	_, err := collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &PasswordResetRepository{collection: collection}, nil
+}
+
+func (r *PasswordResetRepository) Store(token string, userID string, expiry time.Time) error {
+	_, err := r.collection.InsertOne(context.Background(), PasswordResetDocument{
+		Token:     token,
+		UserID:    userID,
+		ExpiresAt: expiry,
+	})
+	return err
+}
+
+// Consume deletes an unexpired token and returns its user
+func (r *PasswordResetRepository) Consume(token string) (string, error) {
+	var doc PasswordResetDocument
+	err := r.collection.FindOneAndDelete(context.Background(), bson.M{
+		"token":      token,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}).Decode(&doc)
+	if err != nil {
+		return "", err
+	}
+	return doc.UserID, nil
+}
+
+func (r *PasswordResetRepository) DeleteByUserID(userID string) error {
+	_, err := r.collection.DeleteMany(context.Background(), bson.M{"user_id": userID})
+	return err
+}
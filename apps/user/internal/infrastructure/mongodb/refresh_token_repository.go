@@ -0,0 +1,69 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/subscription-tracker/user/internal/core/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RefreshTokenRepository struct {
+	collection *mongo.Collection
+}
+
+// NewRefreshTokenRepository creates the repository; expired tokens are removed by a TTL index
+func NewRefreshTokenRepository(db *mongo.Database) (*RefreshTokenRepository, error) {
+	collection := db.Collection("refresh_tokens")
+	_, err := collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "session_id", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &RefreshTokenRepository{collection: collection}, nil
+}
+
+func (r *RefreshTokenRepository) Store(token *domain.RefreshToken) error {
+	_, err := r.collection.InsertOne(context.Background(), token)
+	return err
+}
+
+// Use marks the token as used and returns it as it was before. A token that was
+// already used is returned with its UsedAt set.
+func (r *RefreshTokenRepository) Use(tokenHash string) (*domain.RefreshToken, error) {
+	var token domain.RefreshToken
+	err := r.collection.FindOneAndUpdate(context.Background(),
+		bson.M{"token_hash": tokenHash, "used_at": nil},
+		bson.M{"$set": bson.M{"used_at": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&token)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		err = r.collection.FindOne(context.Background(), bson.M{"token_hash": tokenHash}).Decode(&token)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *RefreshTokenRepository) DeleteBySessionID(sessionID string) error {
+	_, err := r.collection.DeleteMany(context.Background(), bson.M{"session_id": sessionID})
+	return err
+}
+
+func (r *RefreshTokenRepository) DeleteByUserID(userID string) error {
+	_, err := r.collection.DeleteMany(context.Background(), bson.M{"user_id": userID})
+	return err
+}
@@ -0,0 +1,122 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/subscription-tracker/user/internal/core/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SessionRepository struct {
+	collection *mongo.Collection
+}
+
+// NewSessionRepository creates the repository; expired sessions are removed by a TTL index
+func NewSessionRepository(db *mongo.Database) (*SessionRepository, error) {
+	collection := db.Collection("sessions")
+	_, err := collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &SessionRepository{collection: collection}, nil
+}
+
+func (r *SessionRepository) Create(session *domain.Session) error {
+	result, err := r.collection.InsertOne(context.Background(), session)
+	if err != nil {
+		return err
+	}
+	if objectID, ok := result.InsertedID.(primitive.ObjectID); ok {
+		session.ID = objectID.Hex()
+	}
+	return nil
+}
+
+func (r *SessionRepository) FindByID(id string) (*domain.Session, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var session domain.Session
+	if err := r.collection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// FindActiveByUserID returns the sessions of the user that are neither revoked nor
+// expired, most recently used first
+func (r *SessionRepository) FindActiveByUserID(userID string) ([]domain.Session, error) {
+	cursor, err := r.collection.Find(context.Background(), bson.M{
+		"user_id":    userID,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": time.Now()},
+	}, options.Find().SetSort(bson.D{{Key: "last_used_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]domain.Session, 0)
+	if err := cursor.All(context.Background(), &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// FindByUserID returns every stored session of the user, including revoked ones, newest first
+func (r *SessionRepository) FindByUserID(userID string) ([]domain.Session, error) {
+	cursor, err := r.collection.Find(context.Background(), bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]domain.Session, 0)
+	if err := cursor.All(context.Background(), &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// Touch records a refresh of the session from the given device
+func (r *SessionRepository) Touch(id string, userAgent string, ip string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	_, err = r.collection.UpdateOne(context.Background(), bson.M{"_id": objectID}, bson.M{
+		"$set": bson.M{"last_used_at": time.Now(), "user_agent": userAgent, "ip": ip},
+	})
+	return err
+}
+
+func (r *SessionRepository) Revoke(id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	_, err = r.collection.UpdateOne(context.Background(),
+		bson.M{"_id": objectID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}})
+	return err
+}
+
+func (r *SessionRepository) RevokeByUserID(userID string) error {
+	_, err := r.collection.UpdateMany(context.Background(),
+		bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}})
+	return err
+}
+
+func (r *SessionRepository) DeleteByUserID(userID string) error {
+	_, err := r.collection.DeleteMany(context.Background(), bson.M{"user_id": userID})
+	return err
+}
@@ -6,10 +6,14 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// TokenDocument is a pending magic link. The token and the browser binding are stored
+// as hashes only.
 type TokenDocument struct {
 	Token     string    `bson:"token"`
+	Binding   string    `bson:"binding"`
 	UserID    string    `bson:"user_id"`
 	ExpiresAt time.Time `bson:"expires_at"`
 }
@@ -18,25 +22,42 @@ type MongoTokenRepository struct {
 	collection *mongo.Collection
 }
 
-func NewMongoTokenRepository(db *mongo.Database) *MongoTokenRepository {
-	return &MongoTokenRepository{
-		collection: db.Collection("login_tokens"),
+// NewMongoTokenRepository creates the repository; expired tokens are removed by a TTL index
+func NewMongoTokenRepository(db *mongo.Database) (*MongoTokenRepository, error) {
+	collection := db.Collection("login_tokens")
+	_, err := collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return nil, err
 	}
+	return &MongoTokenRepository{collection: collection}, nil
 }
 
-func (r *MongoTokenRepository) Store(token string, userID string, expiry time.Time) error {
+func (r *MongoTokenRepository) Store(token string, binding string, userID string, expiry time.Time) error {
 	_, err := r.collection.InsertOne(context.Background(), TokenDocument{
 		Token:     token,
+		Binding:   binding,
 		UserID:    userID,
 		ExpiresAt: expiry,
 	})
 	return err
 }
 
-func (r *MongoTokenRepository) Verify(token string) (string, error) {
+// Verify consumes an unexpired token issued to the browser with the given binding and
+// returns its user. A token presented from another browser is left untouched.
+func (r *MongoTokenRepository) Verify(token string, binding string) (string, error) {
 	var doc TokenDocument
-	err := r.collection.FindOne(context.Background(), bson.M{
+	err := r.collection.FindOneAndDelete(context.Background(), bson.M{
 		"token":      token,
+		"binding":    binding,
 		"expires_at": bson.M{"$gt": time.Now()},
 	}).Decode(&doc)
 
@@ -51,3 +72,9 @@ func (r *MongoTokenRepository) Delete(token string) error {
 	_, err := r.collection.DeleteOne(context.Background(), bson.M{"token": token})
 	return err
 }
+
+// DeleteByUserID removes the pending magic links of a user
+func (r *MongoTokenRepository) DeleteByUserID(userID string) error {
+	_, err := r.collection.DeleteMany(context.Background(), bson.M{"user_id": userID})
+	return err
+}
@@ -2,12 +2,15 @@ package mongodb
 
 import (
 	"context"
+	"regexp"
 	"time"
 
+	"github.com/subscription-tracker/user/internal/core/application"
 	"github.com/subscription-tracker/user/internal/core/domain"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type UserRepository struct {
@@ -24,8 +27,14 @@ func (r *UserRepository) Create(user *domain.User) error {
 	now := time.Now()
 	user.CreatedAt = now
 	user.UpdatedAt = now
-	_, err := r.collection.InsertOne(context.Background(), user)
-	return err
+	result, err := r.collection.InsertOne(context.Background(), user)
+	if err != nil {
+		return err
+	}
+	if objectID, ok := result.InsertedID.(primitive.ObjectID); ok {
+		user.ID = objectID.Hex()
+	}
+	return nil
 }
 
 func (r *UserRepository) FindByID(id string) (*domain.User, error) {
@@ -53,6 +62,17 @@ func (r *UserRepository) FindByEmail(email string) (*domain.User, error) {
 	return &user, nil
 }
 
+// FindByPasskeyID returns the user who registered the passkey with the given credential ID
+func (r *UserRepository) FindByPasskeyID(id string) (*domain.User, error) {
+	var user domain.User
+	err := r.collection.FindOne(context.Background(), bson.M{"passkeys.id": id}).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
 func (r *UserRepository) Update(user *domain.User) error {
 	objectID, err := primitive.ObjectIDFromHex(user.ID)
 	if err != nil {
@@ -60,11 +80,17 @@ func (r *UserRepository) Update(user *domain.User) error {
 	}
 	userData := &domain.User{
 		Email:              user.Email,
+		EmailVerified:      user.EmailVerified,
 		Name:               user.Name,
 		Password:           user.Password,
+		Role:               user.Role,
 		CreatedAt:          user.CreatedAt,
 		UpdatedAt:          time.Now(),
 		SpotifyCredentials: user.SpotifyCredentials,
+		TwoFactor:          user.TwoFactor,
+		Passkeys:           user.Passkeys,
+		Deletion:           user.Deletion,
+		Preferences:        user.Preferences,
 	}
 
 	_, err = r.collection.UpdateOne(
@@ -75,6 +101,52 @@ func (r *UserRepository) Update(user *domain.User) error {
 	return err
 }
 
+// List returns a page of the users matching filter, ordered by creation, and the number of matches
+func (r *UserRepository) List(filter application.UserFilter, offset int, limit int) ([]domain.User, int64, error) {
+	query := bson.M{}
+	if filter.Query != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(filter.Query), Options: "i"}
+		query["$or"] = bson.A{bson.M{"email": pattern}, bson.M{"name": pattern}}
+	}
+	if filter.Role == domain.RoleUser {
+		query["role"] = bson.M{"$in": bson.A{domain.RoleUser, nil}}
+	} else if filter.Role != "" {
+		query["role"] = filter.Role
+	}
+
+	total, err := r.collection.CountDocuments(context.Background(), query)
+	if err != nil {
+		return nil, 0, err
+	}
+	cursor, err := r.collection.Find(context.Background(), query, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit)))
+	if err != nil {
+		return nil, 0, err
+	}
+	users := []domain.User{}
+	if err := cursor.All(context.Background(), &users); err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// FindDueForPurge returns up to limit users whose deletion grace period ended before the given time
+func (r *UserRepository) FindDueForPurge(before time.Time, limit int) ([]domain.User, error) {
+	cursor, err := r.collection.Find(context.Background(),
+		bson.M{"deletion.purge_after": bson.M{"$lte": before}},
+		options.Find().SetSort(bson.D{{Key: "deletion.purge_after", Value: 1}}).SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	var users []domain.User
+	if err := cursor.All(context.Background(), &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 func (r *UserRepository) Delete(id string) error {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -0,0 +1,101 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/subscription-tracker/user/internal/core/application"
+	"golang.org/x/oauth2"
+)
+
+// DefaultGitHubURL and DefaultGitHubAPIURL are the GitHub OAuth and REST API base URLs
+const (
+	DefaultGitHubURL    = "https://github.com"
+	DefaultGitHubAPIURL = "https://api.github.com"
+)
+
+// GitHubProvider signs users in with GitHub. GitHub is plain OAuth 2.0, so the profile
+// and the verified primary email are read from the REST API.
+type GitHubProvider struct {
+	oauthConfig oauth2.Config
+	apiURL      string
+	httpClient  *http.Client
+}
+
+// NewGitHubProvider creates a GitHub provider; the base URLs can point at a GitHub
+// Enterprise server or a stand-in server in tests
+func NewGitHubProvider(config Config, baseURL string, apiURL string, httpClient *http.Client) *GitHubProvider {
+	if baseURL == "" {
+		baseURL = DefaultGitHubURL
+	}
+	if apiURL == "" {
+		apiURL = DefaultGitHubAPIURL
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: requestTimeout}
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"read:user", "user:email"}
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return &GitHubProvider{
+		oauthConfig: oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			RedirectURL:  config.RedirectURL,
+			Scopes:       config.Scopes,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  baseURL + "/login/oauth/authorize",
+				TokenURL: baseURL + "/login/oauth/access_token",
+			},
+		},
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+func (p *GitHubProvider) Name() string {
+	return "github"
+}
+
+func (p *GitHubProvider) AuthCodeURL(ctx context.Context, state string) (string, error) {
+	return p.oauthConfig.AuthCodeURL(state), nil
+}
+
+func (p *GitHubProvider) Exchange(ctx context.Context, code string, state string) (*application.ExternalProfile, error) {
+	token, err := p.oauthConfig.Exchange(context.WithValue(ctx, oauth2.HTTPClient, p.httpClient), code)
+	if err != nil {
+		return nil, err
+	}
+
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := getJSON(ctx, p.httpClient, p.apiURL+"/user", token.AccessToken, &user); err != nil {
+		return nil, err
+	}
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, p.httpClient, p.apiURL+"/user/emails", token.AccessToken, &emails); err != nil {
+		return nil, err
+	}
+
+	profile := &application.ExternalProfile{Subject: strconv.FormatInt(user.ID, 10), Name: user.Name}
+	if profile.Name == "" {
+		profile.Name = user.Login
+	}
+	for _, email := range emails {
+		if email.Primary {
+			profile.Email = email.Email
+			profile.EmailVerified = email.Verified
+		}
+	}
+	return profile, nil
+}
@@ -0,0 +1,298 @@
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/subscription-tracker/user/internal/core/application"
+	"golang.org/x/oauth2"
+)
+
+// GoogleIssuer is the OIDC issuer of Google accounts
+const GoogleIssuer = "https://accounts.google.com"
+
+// requestTimeout bounds a request to a provider when no HTTP client is given
+const requestTimeout = 10 * time.Second
+
+// Config is the client registration of a login provider
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// discovery is the subset of the OIDC discovery document the provider needs
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCProvider signs users in with any OpenID Connect issuer. The discovery document is
+// fetched on first use and ID tokens are verified against the issuer's JWKS.
+type OIDCProvider struct {
+	name       string
+	issuer     string
+	config     Config
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	discovery *discovery
+	keys      map[string]interface{}
+}
+
+// NewOIDCProvider creates a provider for issuer; the openid, email and profile scopes are
+// requested when config has none
+func NewOIDCProvider(name string, issuer string, config Config, httpClient *http.Client) *OIDCProvider {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: requestTimeout}
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &OIDCProvider{
+		name:       name,
+		issuer:     strings.TrimSuffix(issuer, "/"),
+		config:     config,
+		httpClient: httpClient,
+	}
+}
+
+// NewGoogleProvider creates an OIDC provider for Google accounts
+func NewGoogleProvider(config Config, httpClient *http.Client) *OIDCProvider {
+	return NewOIDCProvider("google", GoogleIssuer, config, httpClient)
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.name
+}
+
+// AuthCodeURL returns the consent page URL; the state doubles as the ID token nonce
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state string) (string, error) {
+	oauthConfig, err := p.oauthConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+	return oauthConfig.AuthCodeURL(state, oauth2.SetAuthURLParam("nonce", state)), nil
+}
+
+// Exchange redeems code and returns the profile from the verified ID token
+func (p *OIDCProvider) Exchange(ctx context.Context, code string, state string) (*application.ExternalProfile, error) {
+	oauthConfig, err := p.oauthConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, p.httpClient)
+	token, err := oauthConfig.Exchange(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+	return p.verify(ctx, rawIDToken, state)
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce         string      `json:"nonce"`
+	Email         string      `json:"email"`
+	EmailVerified interface{} `json:"email_verified"`
+	Name          string      `json:"name"`
+}
+
+// verify checks the signature, issuer, audience, expiry and nonce of an ID token
+func (p *OIDCProvider) verify(ctx context.Context, rawIDToken string, nonce string) (*application.ExternalProfile, error) {
+	var claims idTokenClaims
+	_, err := jwt.ParseWithClaims(rawIDToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(p.discovery.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id_token: %w", err)
+	}
+	if claims.Nonce != nonce {
+		return nil, errors.New("invalid id_token: nonce mismatch")
+	}
+
+	// Some issuers send email_verified as a string
+	verified := false
+	switch v := claims.EmailVerified.(type) {
+	case bool:
+		verified = v
+	case string:
+		verified = v == "true"
+	}
+	return &application.ExternalProfile{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: verified,
+		Name:          claims.Name,
+	}, nil
+}
+
+func (p *OIDCProvider) oauthConfig(ctx context.Context) (*oauth2.Config, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		RedirectURL:  p.config.RedirectURL,
+		Scopes:       p.config.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  doc.AuthorizationEndpoint,
+			TokenURL: doc.TokenEndpoint,
+		},
+	}, nil
+}
+
+// discover fetches and caches the issuer's discovery document
+func (p *OIDCProvider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var doc discovery
+	if err := p.getJSON(ctx, p.issuer+"/.well-known/openid-configuration", &doc); err != nil {
+		return nil, fmt.Errorf("oidc discovery failed: %w", err)
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != p.issuer {
+		return nil, fmt.Errorf("oidc discovery returned issuer %q, expected %q", doc.Issuer, p.issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, errors.New("oidc discovery document is incomplete")
+	}
+	p.discovery = &doc
+	return p.discovery, nil
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// key returns the signing key with the given id, refetching the JWKS when the issuer
+// has rotated its keys
+func (p *OIDCProvider) key(ctx context.Context, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := p.getJSON(ctx, p.discovery.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("fetching jwks failed: %w", err)
+	}
+	keys := make(map[string]interface{})
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := parseJWK(k)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	p.keys = keys
+
+	key, ok := p.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+func parseJWK(k jwk) (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, url string, v interface{}) error {
+	return getJSON(ctx, p.httpClient, url, "", v)
+}
+
+func getJSON(ctx context.Context, httpClient *http.Client, url string, accessToken string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s failed with status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,266 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/subscription-tracker/user/internal/core/application"
+)
+
+const (
+	testClientID = "client-id"
+	testKeyID    = "test-key"
+	testNonce    = "login-state"
+)
+
+// mockIssuer is a local OIDC issuer serving discovery, JWKS and a token endpoint that
+// answers every code with idToken. The discovery document names advertisedIssuer as the
+// issuer when it is set.
+type mockIssuer struct {
+	server           *httptest.Server
+	key              *rsa.PrivateKey
+	idToken          string
+	advertisedIssuer string
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := &mockIssuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		advertised := issuer.server.URL
+		if issuer.advertisedIssuer != "" {
+			advertised = issuer.advertisedIssuer
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 advertised,
+			"authorization_endpoint": issuer.server.URL + "/authorize",
+			"token_endpoint":         issuer.server.URL + "/token",
+			"jwks_uri":               issuer.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": testKeyID,
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("code") != "auth-code" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     issuer.idToken,
+		})
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+// claims returns the claims of a valid ID token for the test client
+func (i *mockIssuer) claims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            i.server.URL,
+		"sub":            "subject-1",
+		"aud":            testClientID,
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          testNonce,
+		"email":          "user@example.com",
+		"email_verified": true,
+		"name":           "Test User",
+	}
+}
+
+func (i *mockIssuer) sign(t *testing.T, claims jwt.MapClaims, key *rsa.PrivateKey) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = testKeyID
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestOIDCProviderExchange(t *testing.T) {
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(claims jwt.MapClaims)
+		// signWith signs with another key than the published one when set
+		signWith *rsa.PrivateKey
+		code     string
+		// wantErr is part of the expected error message
+		wantErr string
+		want    application.ExternalProfile
+	}{
+		{
+			name: "valid id token",
+			want: application.ExternalProfile{Subject: "subject-1", Email: "user@example.com", EmailVerified: true, Name: "Test User"},
+		},
+		{
+			name:     "bad signature",
+			signWith: otherKey,
+			wantErr:  "signature is invalid",
+		},
+		{
+			name:    "wrong issuer",
+			modify:  func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" },
+			wantErr: "invalid issuer",
+		},
+		{
+			name:    "wrong audience",
+			modify:  func(claims jwt.MapClaims) { claims["aud"] = "another-client" },
+			wantErr: "invalid audience",
+		},
+		{
+			name:    "expired",
+			modify:  func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Minute).Unix() },
+			wantErr: "token is expired",
+		},
+		{
+			name:    "missing expiry",
+			modify:  func(claims jwt.MapClaims) { delete(claims, "exp") },
+			wantErr: "missing required claim",
+		},
+		{
+			name:    "nonce mismatch",
+			modify:  func(claims jwt.MapClaims) { claims["nonce"] = "another-state" },
+			wantErr: "nonce mismatch",
+		},
+		{
+			name:   "unverified email",
+			modify: func(claims jwt.MapClaims) { claims["email_verified"] = false },
+			want:   application.ExternalProfile{Subject: "subject-1", Email: "user@example.com", EmailVerified: false, Name: "Test User"},
+		},
+		{
+			name:   "email verified as string",
+			modify: func(claims jwt.MapClaims) { claims["email_verified"] = "true" },
+			want:   application.ExternalProfile{Subject: "subject-1", Email: "user@example.com", EmailVerified: true, Name: "Test User"},
+		},
+		{
+			name:    "rejected code",
+			code:    "wrong-code",
+			wantErr: "invalid_grant",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := newMockIssuer(t)
+			claims := issuer.claims()
+			if tt.modify != nil {
+				tt.modify(claims)
+			}
+			key := issuer.key
+			if tt.signWith != nil {
+				key = tt.signWith
+			}
+			issuer.idToken = issuer.sign(t, claims, key)
+			code := tt.code
+			if code == "" {
+				code = "auth-code"
+			}
+
+			provider := NewOIDCProvider("test", issuer.server.URL, Config{ClientID: testClientID, ClientSecret: "secret"}, nil)
+			profile, err := provider.Exchange(context.Background(), code, testNonce)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exchange: %v", err)
+			}
+			if *profile != tt.want {
+				t.Errorf("profile = %+v, want %+v", *profile, tt.want)
+			}
+		})
+	}
+}
+
+func TestOIDCProviderAuthCodeURL(t *testing.T) {
+	issuer := newMockIssuer(t)
+	provider := NewOIDCProvider("test", issuer.server.URL, Config{ClientID: testClientID}, nil)
+
+	url, err := provider.AuthCodeURL(context.Background(), testNonce)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	if !strings.HasPrefix(url, issuer.server.URL+"/authorize?") {
+		t.Errorf("url %q does not use the discovered authorization endpoint", url)
+	}
+	for _, param := range []string{"state=" + testNonce, "nonce=" + testNonce, "client_id=" + testClientID, "scope=openid+email+profile"} {
+		if !strings.Contains(url, param) {
+			t.Errorf("url %q has no %s", url, param)
+		}
+	}
+}
+
+func TestOIDCProviderDiscoveryIssuerMismatch(t *testing.T) {
+	issuer := newMockIssuer(t)
+	issuer.advertisedIssuer = "https://evil.example.com"
+	provider := NewOIDCProvider("test", issuer.server.URL, Config{ClientID: testClientID}, nil)
+	_, err := provider.AuthCodeURL(context.Background(), testNonce)
+	if err == nil || !strings.Contains(err.Error(), "returned issuer") {
+		t.Fatalf("error = %v, want an issuer mismatch", err)
+	}
+}
+
+func TestProviderRegistry(t *testing.T) {
+	registry := application.NewProviderRegistry()
+	google := NewGoogleProvider(Config{ClientID: testClientID}, nil)
+	github := NewGitHubProvider(Config{ClientID: testClientID}, "", "", nil)
+	okta := NewOIDCProvider("Okta", "https://okta.example.com", Config{ClientID: testClientID}, nil)
+	for _, provider := range []application.LoginProvider{google, github, okta} {
+		if err := registry.Register(provider); err != nil {
+			t.Fatalf("Register %s: %v", provider.Name(), err)
+		}
+	}
+
+	if err := registry.Register(NewOIDCProvider("GOOGLE", "https://accounts.example.com", Config{}, nil)); !errors.Is(err, application.ErrProviderRegistered) {
+		t.Errorf("registering a duplicate name returned %v", err)
+	}
+	if got, err := registry.Get("OKTA"); err != nil || got != okta {
+		t.Errorf("Get(OKTA) = %v, %v", got, err)
+	}
+	if _, err := registry.Get("facebook"); !errors.Is(err, application.ErrUnknownProvider) {
+		t.Errorf("Get(facebook) returned %v", err)
+	}
+	if names := strings.Join(registry.Names(), ","); names != "github,google,okta" {
+		t.Errorf("Names() = %s", names)
+	}
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter allows a number of events per key within a fixed window. Counts are kept in
+// memory, so each instance of the service limits on its own.
+type Limiter struct {
+	limit  int
+	window time.Duration
+
+	mu        sync.Mutex
+	windows   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	start time.Time
+	count int
+}
+
+func NewLimiter(limit int, window time.Duration) *Limiter {
+	return &Limiter{limit: limit, window: window, windows: make(map[string]*bucket), lastSweep: time.Now()}
+}
+
+// Allow records an event for key and reports whether it is within the limit
+func (l *Limiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.window {
+		if now.Sub(l.lastSweep) >= l.window {
+			l.sweep(now)
+		}
+		w = &bucket{start: now}
+		l.windows[key] = w
+	}
+	if w.count >= l.limit {
+		return false
+	}
+	w.count++
+	return true
+}
+
+// sweep drops the expired windows so idle keys do not accumulate
+func (l *Limiter) sweep(now time.Time) {
+	for key, w := range l.windows {
+		if now.Sub(w.start) >= l.window {
+			delete(l.windows, key)
+		}
+	}
+	l.lastSweep = now
+}
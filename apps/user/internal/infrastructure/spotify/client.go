@@ -0,0 +1,104 @@
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/subscription-tracker/user/internal/core/application"
+	"golang.org/x/oauth2"
+)
+
+// DefaultBaseURL is the base URL of the Spotify Web API
+const DefaultBaseURL = "https://api.spotify.com/v1"
+
+const (
+	// requestTimeout bounds a Spotify request when no HTTP client is given
+	requestTimeout = 10 * time.Second
+	// maxRetryWait is the longest Retry-After a rate limited request waits for before retrying once
+	maxRetryWait = 5 * time.Second
+)
+
+// RateLimitError is returned when Spotify keeps rejecting requests with 429 Too Many Requests
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("spotify rate limit exceeded, retry after %s", e.RetryAfter)
+}
+
+// Client reads profiles from the Spotify Web API
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a Spotify client; baseURL can point at a stand-in server in tests
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: requestTimeout}
+	}
+	return &Client{baseURL: strings.TrimSuffix(baseURL, "/"), httpClient: httpClient}
+}
+
+// GetCurrentUser returns the profile of the user the token was issued to
+func (c *Client) GetCurrentUser(ctx context.Context, token *oauth2.Token) (*application.SpotifyUser, error) {
+	var spotifyUser application.SpotifyUser
+	if err := c.get(ctx, token, "/me", &spotifyUser); err != nil {
+		return nil, err
+	}
+	return &spotifyUser, nil
+}
+
+// get decodes the JSON response of a GET request into v. A rate limited request is retried
+// once if Spotify asks to wait no longer than maxRetryWait.
+func (c *Client) get(ctx context.Context, token *oauth2.Token, path string, v interface{}) error {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+		if err != nil {
+			return err
+		}
+		token.SetAuthHeader(req)
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			resp.Body.Close()
+			wait := retryAfter(resp.Header.Get("Retry-After"))
+			if attempt > 0 || wait > maxRetryWait {
+				return &RateLimitError{RetryAfter: wait}
+			}
+			select {
+			case <-time.After(wait):
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("spotify request %s failed with status %d", path, resp.StatusCode)
+		}
+		return json.NewDecoder(resp.Body).Decode(v)
+	}
+}
+
+// retryAfter parses the Retry-After header Spotify sends in seconds, defaulting to one second
+func retryAfter(header string) time.Duration {
+	seconds, err := strconv.Atoi(header)
+	if err != nil || seconds < 0 {
+		return time.Second
+	}
+	return time.Duration(seconds) * time.Second
+}
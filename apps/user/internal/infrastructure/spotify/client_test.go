@@ -0,0 +1,130 @@
+package spotify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestGetCurrentUser(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(calls int, w http.ResponseWriter, r *http.Request)
+		product string
+		calls   int
+		wantErr func(err error) bool
+	}{
+		{
+			name: "profile",
+			handler: func(calls int, w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"id":"spotify-user","email":"user@example.com","display_name":"User","product":"premium"}`))
+			},
+			product: "premium",
+			calls:   1,
+		},
+		{
+			name: "error status",
+			handler: func(calls int, w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			},
+			calls:   1,
+			wantErr: func(err error) bool { return err != nil },
+		},
+		{
+			name: "malformed body",
+			handler: func(calls int, w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"id":`))
+			},
+			calls:   1,
+			wantErr: func(err error) bool { return err != nil },
+		},
+		{
+			name: "rate limited once",
+			handler: func(calls int, w http.ResponseWriter, r *http.Request) {
+				if calls == 1 {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+				w.Write([]byte(`{"id":"spotify-user","product":"free"}`))
+			},
+			product: "free",
+			calls:   2,
+		},
+		{
+			name: "rate limited twice",
+			handler: func(calls int, w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			calls: 2,
+			wantErr: func(err error) bool {
+				var rateLimitErr *RateLimitError
+				return errors.As(err, &rateLimitErr)
+			},
+		},
+		{
+			name: "rate limited for too long",
+			handler: func(calls int, w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "60")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			calls: 1,
+			wantErr: func(err error) bool {
+				var rateLimitErr *RateLimitError
+				return errors.As(err, &rateLimitErr) && rateLimitErr.RetryAfter == time.Minute
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if r.URL.Path != "/v1/me" {
+					t.Errorf("unexpected path %s", r.URL.Path)
+				}
+				if got := r.Header.Get("Authorization"); got != "Bearer access-token" {
+					t.Errorf("unexpected Authorization header %q", got)
+				}
+				tt.handler(calls, w, r)
+			}))
+			defer server.Close()
+
+			client := NewClient(server.URL+"/v1/", nil)
+			user, err := client.GetCurrentUser(context.Background(), &oauth2.Token{AccessToken: "access-token", TokenType: "Bearer"})
+			if tt.wantErr != nil {
+				if !tt.wantErr(err) {
+					t.Fatalf("unexpected error %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("GetCurrentUser: %v", err)
+			} else if user.Product != tt.product {
+				t.Errorf("product = %q, want %q", user.Product, tt.product)
+			}
+			if calls != tt.calls {
+				t.Errorf("made %d requests, want %d", calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestGetCurrentUserTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewClient(server.URL, &http.Client{Timeout: 50 * time.Millisecond})
+	if _, err := client.GetCurrentUser(context.Background(), &oauth2.Token{AccessToken: "access-token"}); err == nil {
+		t.Fatal("expected a timeout error")
+	}
+}
@@ -0,0 +1,120 @@
+package subscription
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/subscription-tracker/user/internal/core/application"
+)
+
+// requestTimeout bounds a request when no HTTP client is given. It includes reading the
+// response body, so it leaves room for attachment downloads.
+const requestTimeout = time.Minute
+
+// Client calls the subscription service on behalf of a user
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: requestTimeout}
+	}
+	return &Client{baseURL: strings.TrimSuffix(baseURL, "/"), httpClient: httpClient}
+}
+
+// SyncSpotifySubscription asks the subscription service to create or update the user's
+// Spotify subscription from the product of their Spotify profile
+func (c *Client) SyncSpotifySubscription(ctx context.Context, userToken string, product string) error {
+	body, err := json.Marshal(map[string]string{"product": product})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/integrations/spotify/sync", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+userToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("subscription service responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// PublishUserEvent posts an event to the subscription service. The service answers once
+// it has processed the event.
+func (c *Client) PublishUserEvent(ctx context.Context, serviceToken string, event application.UserEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/internal/events", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+serviceToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("subscription service responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ExportUserData fetches the subscription service's export of the user named in the service token
+func (c *Client) ExportUserData(ctx context.Context, serviceToken string) ([]byte, error) {
+	resp, err := c.get(ctx, serviceToken, "/api/internal/export")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// DownloadAttachment streams an attachment of the user named in the service token
+func (c *Client) DownloadAttachment(ctx context.Context, serviceToken string, attachmentUUID string) (io.ReadCloser, error) {
+	resp, err := c.get(ctx, serviceToken, "/api/internal/export/attachments/"+url.PathEscape(attachmentUUID))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// get calls an internal API and returns the successful response; the caller must close its body
+func (c *Client) get(ctx context.Context, serviceToken string, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+serviceToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("subscription service responded with status %d", resp.StatusCode)
+	}
+	return resp, nil
+}
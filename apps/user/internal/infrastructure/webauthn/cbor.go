@@ -0,0 +1,144 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+var errTruncated = errors.New("cbor: truncated input")
+
+// decodeCBOR decodes the first CBOR data item of data and returns it with the bytes that
+// follow it. Maps decode to map[interface{}]interface{}, integers to int64, byte strings
+// to []byte and text strings to string. Indefinite lengths are rejected, as CTAP2 never
+// produces them.
+func decodeCBOR(data []byte) (interface{}, []byte, error) {
+	return decodeItem(data, 0)
+}
+
+func decodeItem(data []byte, depth int) (interface{}, []byte, error) {
+	if depth > 16 {
+		return nil, nil, errors.New("cbor: nesting too deep")
+	}
+	if len(data) == 0 {
+		return nil, nil, errTruncated
+	}
+	major := data[0] >> 5
+	info := data[0] & 0x1f
+	data = data[1:]
+
+	if major == 7 {
+		return decodeSimple(info, data)
+	}
+	value, data, err := decodeArgument(info, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case 0:
+		if value > 1<<63-1 {
+			return nil, nil, errors.New("cbor: integer overflow")
+		}
+		return int64(value), data, nil
+	case 1:
+		if value > 1<<63-1 {
+			return nil, nil, errors.New("cbor: integer overflow")
+		}
+		return -1 - int64(value), data, nil
+	case 2, 3:
+		if uint64(len(data)) < value {
+			return nil, nil, errTruncated
+		}
+		if major == 2 {
+			return append([]byte(nil), data[:value]...), data[value:], nil
+		}
+		return string(data[:value]), data[value:], nil
+	case 4:
+		if value > uint64(len(data)) {
+			return nil, nil, errTruncated
+		}
+		items := make([]interface{}, 0, value)
+		for i := uint64(0); i < value; i++ {
+			var item interface{}
+			if item, data, err = decodeItem(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, data, nil
+	case 5:
+		if value > uint64(len(data)) {
+			return nil, nil, errTruncated
+		}
+		items := make(map[interface{}]interface{}, value)
+		for i := uint64(0); i < value; i++ {
+			var key, item interface{}
+			if key, data, err = decodeItem(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			if item, data, err = decodeItem(data, depth+1); err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+				items[key] = item
+			default:
+				return nil, nil, errors.New("cbor: unsupported map key")
+			}
+		}
+		return items, data, nil
+	case 6:
+		// Tags carry no meaning for WebAuthn structures, decode the tagged item
+		return decodeItem(data, depth+1)
+	}
+	return nil, nil, fmt.Errorf("cbor: unsupported major type %d", major)
+}
+
+// decodeArgument reads the length or value that follows the initial byte
+func decodeArgument(info byte, data []byte) (uint64, []byte, error) {
+	switch {
+	case info < 24:
+		return uint64(info), data, nil
+	case info == 24:
+		if len(data) < 1 {
+			return 0, nil, errTruncated
+		}
+		return uint64(data[0]), data[1:], nil
+	case info == 25:
+		if len(data) < 2 {
+			return 0, nil, errTruncated
+		}
+		return uint64(binary.BigEndian.Uint16(data)), data[2:], nil
+	case info == 26:
+		if len(data) < 4 {
+			return 0, nil, errTruncated
+		}
+		return uint64(binary.BigEndian.Uint32(data)), data[4:], nil
+	case info == 27:
+		if len(data) < 8 {
+			return 0, nil, errTruncated
+		}
+		return binary.BigEndian.Uint64(data), data[8:], nil
+	}
+	return 0, nil, errors.New("cbor: indefinite or reserved length")
+}
+
+// decodeSimple reads booleans, null and undefined, and skips floats
+func decodeSimple(info byte, data []byte) (interface{}, []byte, error) {
+	switch info {
+	case 20:
+		return false, data, nil
+	case 21:
+		return true, data, nil
+	case 22, 23:
+		return nil, data, nil
+	case 25, 26, 27:
+		size := 1 << (info - 24)
+		if len(data) < size {
+			return nil, nil, errTruncated
+		}
+		return nil, data[size:], nil
+	}
+	return nil, nil, fmt.Errorf("cbor: unsupported simple value %d", info)
+}
@@ -0,0 +1,97 @@
+package webauthn
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// nestedArrays returns depth arrays nested in each other around an integer
+func nestedArrays(depth int) []byte {
+	return append(bytes.Repeat([]byte{0x81}, depth), 0x01)
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		want  interface{}
+		// wantRest is what follows the first data item
+		wantRest []byte
+		// wantErr is part of the expected error message
+		wantErr string
+	}{
+		{name: "small integer", input: []byte{0x17}, want: int64(23)},
+		{name: "one byte integer", input: []byte{0x18, 0xff}, want: int64(255)},
+		{name: "eight byte integer", input: []byte{0x1b, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, want: int64(1<<63 - 1)},
+		{name: "negative integer", input: []byte{0x38, 0x63}, want: int64(-100)},
+		{name: "byte string", input: []byte{0x43, 1, 2, 3}, want: []byte{1, 2, 3}},
+		{name: "text string", input: []byte{0x63, 'a', 'b', 'c'}, want: "abc"},
+		{name: "array", input: []byte{0x82, 0x01, 0xf5}, want: []interface{}{int64(1), true}},
+		{name: "map", input: []byte{0xa2, 0x01, 0x02, 0x61, 'k', 0xf6}, want: map[interface{}]interface{}{int64(1): int64(2), "k": nil}},
+		{name: "tag", input: []byte{0xc2, 0x41, 0x01}, want: []byte{1}},
+		{name: "float is skipped", input: []byte{0xf9, 0x3c, 0x00}, want: nil},
+		{name: "trailing data", input: []byte{0x01, 0x02, 0x03}, want: int64(1), wantRest: []byte{0x02, 0x03}},
+		{name: "deepest nesting", input: nestedArrays(16), want: func() interface{} {
+			var item interface{} = int64(1)
+			for i := 0; i < 16; i++ {
+				item = []interface{}{item}
+			}
+			return item
+		}()},
+
+		{name: "empty", input: nil, wantErr: "truncated"},
+		{name: "truncated argument", input: []byte{0x19, 0x01}, wantErr: "truncated"},
+		{name: "truncated byte string", input: []byte{0x45, 1, 2}, wantErr: "truncated"},
+		{name: "truncated text string", input: []byte{0x7a, 0x00, 0x00, 0x01, 0x00, 'a'}, wantErr: "truncated"},
+		{name: "truncated array", input: []byte{0x83, 0x01, 0x02}, wantErr: "truncated"},
+		{name: "truncated map", input: []byte{0xa1, 0x01}, wantErr: "truncated"},
+		{name: "truncated float", input: []byte{0xfb, 0x00, 0x00}, wantErr: "truncated"},
+		{name: "oversized byte string", input: []byte{0x5b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00}, wantErr: "truncated"},
+		{name: "oversized array", input: []byte{0x9b, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x01}, wantErr: "truncated"},
+		{name: "oversized map", input: []byte{0xba, 0xff, 0xff, 0xff, 0xff, 0x01, 0x02}, wantErr: "truncated"},
+		{name: "integer overflow", input: []byte{0x1b, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, wantErr: "integer overflow"},
+		{name: "negative integer overflow", input: []byte{0x3b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, wantErr: "integer overflow"},
+		{name: "nesting too deep", input: nestedArrays(17), wantErr: "nesting too deep"},
+		{name: "nested maps too deep", input: append(bytes.Repeat([]byte{0xa1, 0x01}, 17), 0x01), wantErr: "nesting too deep"},
+		{name: "nested tags too deep", input: append(bytes.Repeat([]byte{0xc1}, 17), 0x01), wantErr: "nesting too deep"},
+		{name: "indefinite length", input: []byte{0x9f, 0x01, 0xff}, wantErr: "indefinite"},
+		{name: "reserved length", input: []byte{0x1c}, wantErr: "reserved"},
+		{name: "byte string map key", input: []byte{0xa1, 0x41, 0x01, 0x01}, wantErr: "unsupported map key"},
+		{name: "unsupported simple value", input: []byte{0xe0}, wantErr: "unsupported simple value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rest, err := decodeCBOR(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeCBOR: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decoded %#v, want %#v", got, tt.want)
+			}
+			if !bytes.Equal(rest, tt.wantRest) {
+				t.Errorf("rest = %x, want %x", rest, tt.wantRest)
+			}
+		})
+	}
+}
+
+func TestDecodeCBORCopiesByteStrings(t *testing.T) {
+	input := []byte{0x42, 1, 2}
+	got, _, err := decodeCBOR(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input[1] = 9
+	if !bytes.Equal(got.([]byte), []byte{1, 2}) {
+		t.Errorf("decoded byte string %x shares the input", got)
+	}
+}
@@ -0,0 +1,318 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/subscription-tracker/user/internal/core/application"
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+// COSE algorithm identifiers of the supported credential keys
+const (
+	algES256 = -7
+	algEdDSA = -8
+	algRS256 = -257
+)
+
+// authenticator data flags
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttested     = 0x40
+)
+
+// ceremonyTimeout is the time the browser gives the user to complete a ceremony
+const ceremonyTimeout = 5 * time.Minute
+
+// RelyingParty verifies WebAuthn ceremonies for one relying party ID. Passkeys are
+// registered with attestation "none", so attestation statements are not verified.
+type RelyingParty struct {
+	id      string
+	name    string
+	origins map[string]bool
+}
+
+// NewRelyingParty creates a relying party for id, e.g. "example.com", accepting
+// ceremonies from the given origins
+func NewRelyingParty(id string, name string, origins []string) *RelyingParty {
+	rp := &RelyingParty{id: id, name: name, origins: make(map[string]bool)}
+	for _, origin := range origins {
+		rp.origins[origin] = true
+	}
+	return rp
+}
+
+type credentialDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// CreationOptions are the PublicKeyCredentialCreationOptions of a registration
+type CreationOptions struct {
+	Challenge string `json:"challenge"`
+	RP        struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"rp"`
+	User struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName"`
+	} `json:"user"`
+	PubKeyCredParams []struct {
+		Type string `json:"type"`
+		Alg  int    `json:"alg"`
+	} `json:"pubKeyCredParams"`
+	AuthenticatorSelection struct {
+		ResidentKey      string `json:"residentKey"`
+		UserVerification string `json:"userVerification"`
+	} `json:"authenticatorSelection"`
+	ExcludeCredentials []credentialDescriptor `json:"excludeCredentials"`
+	Attestation        string                 `json:"attestation"`
+	Timeout            int64                  `json:"timeout"`
+}
+
+// RequestOptions are the PublicKeyCredentialRequestOptions of an authentication
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []credentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+	Timeout          int64                  `json:"timeout"`
+}
+
+func (rp *RelyingParty) RegistrationOptions(user *domain.User, challenge string) interface{} {
+	options := CreationOptions{
+		Challenge:   challenge,
+		Attestation: "none",
+		Timeout:     ceremonyTimeout.Milliseconds(),
+	}
+	options.RP.ID = rp.id
+	options.RP.Name = rp.name
+	options.User.ID = base64.RawURLEncoding.EncodeToString([]byte(user.ID))
+	options.User.Name = user.Email
+	options.User.DisplayName = user.Name
+	for _, alg := range []int{algES256, algEdDSA, algRS256} {
+		options.PubKeyCredParams = append(options.PubKeyCredParams, struct {
+			Type string `json:"type"`
+			Alg  int    `json:"alg"`
+		}{"public-key", alg})
+	}
+	// Discoverable credentials allow logins without typing an email
+	options.AuthenticatorSelection.ResidentKey = "required"
+	options.AuthenticatorSelection.UserVerification = "required"
+	options.ExcludeCredentials = descriptors(user.Passkeys)
+	return options
+}
+
+func (rp *RelyingParty) AssertionOptions(challenge string, allowed []domain.Passkey) interface{} {
+	return RequestOptions{
+		Challenge:        challenge,
+		RPID:             rp.id,
+		AllowCredentials: descriptors(allowed),
+		UserVerification: "required",
+		Timeout:          ceremonyTimeout.Milliseconds(),
+	}
+}
+
+func descriptors(passkeys []domain.Passkey) []credentialDescriptor {
+	result := make([]credentialDescriptor, 0, len(passkeys))
+	for _, passkey := range passkeys {
+		result = append(result, credentialDescriptor{Type: "public-key", ID: passkey.ID})
+	}
+	return result
+}
+
+// VerifyRegistration checks an attestation response and returns the new credential
+func (rp *RelyingParty) VerifyRegistration(challenge string, response application.PasskeyAttestation) (*domain.Passkey, error) {
+	if err := rp.verifyClientData(response.ClientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	decoded, _, err := decodeCBOR(response.AttestationObject)
+	if err != nil {
+		return nil, err
+	}
+	attestation, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("attestation object is not a map")
+	}
+	authData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, errors.New("attestation object has no authenticator data")
+	}
+
+	flags, signCount, rest, err := rp.parseAuthenticatorData(authData)
+	if err != nil {
+		return nil, err
+	}
+	if flags&flagAttested == 0 {
+		return nil, errors.New("authenticator data has no attested credential")
+	}
+	// aaguid (16 bytes) and the credential ID length precede the credential ID
+	if len(rest) < 18 {
+		return nil, errTruncated
+	}
+	idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if len(rest) < idLength {
+		return nil, errTruncated
+	}
+	credentialID := rest[:idLength]
+	_, extensions, err := decodeCBOR(rest[idLength:])
+	if err != nil {
+		return nil, err
+	}
+	publicKey := rest[idLength : len(rest)-len(extensions)]
+	if _, err := parseCOSEKey(publicKey); err != nil {
+		return nil, err
+	}
+
+	return &domain.Passkey{
+		ID:        base64.RawURLEncoding.EncodeToString(credentialID),
+		PublicKey: append([]byte(nil), publicKey...),
+		SignCount: signCount,
+	}, nil
+}
+
+// VerifyAssertion checks an assertion made with passkey and returns the new signature counter
+func (rp *RelyingParty) VerifyAssertion(challenge string, passkey *domain.Passkey, response application.PasskeyAssertion) (uint32, error) {
+	if err := rp.verifyClientData(response.ClientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+	_, signCount, _, err := rp.parseAuthenticatorData(response.AuthenticatorData)
+	if err != nil {
+		return 0, err
+	}
+	// A counter that does not increase hints at a cloned authenticator; authenticators
+	// without a counter always report zero
+	if (signCount != 0 || passkey.SignCount != 0) && signCount <= passkey.SignCount {
+		return 0, errors.New("signature counter did not increase")
+	}
+
+	key, err := parseCOSEKey(passkey.PublicKey)
+	if err != nil {
+		return 0, err
+	}
+	clientDataHash := sha256.Sum256(response.ClientDataJSON)
+	signed := append(append([]byte(nil), response.AuthenticatorData...), clientDataHash[:]...)
+	if err := verifySignature(key, signed, response.Signature); err != nil {
+		return 0, err
+	}
+	return signCount, nil
+}
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+func (rp *RelyingParty) verifyClientData(raw []byte, ceremony string, challenge string) error {
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return err
+	}
+	if data.Type != ceremony {
+		return fmt.Errorf("unexpected ceremony %q", data.Type)
+	}
+	if data.Challenge != challenge {
+		return errors.New("challenge mismatch")
+	}
+	if !rp.origins[data.Origin] {
+		return fmt.Errorf("unexpected origin %q", data.Origin)
+	}
+	return nil
+}
+
+// parseAuthenticatorData checks the relying party and the user presence and verification
+// flags, and returns the flags, the signature counter and the remaining data
+func (rp *RelyingParty) parseAuthenticatorData(data []byte) (byte, uint32, []byte, error) {
+	if len(data) < 37 {
+		return 0, 0, nil, errTruncated
+	}
+	rpIDHash := sha256.Sum256([]byte(rp.id))
+	if !bytes.Equal(data[:32], rpIDHash[:]) {
+		return 0, 0, nil, errors.New("relying party ID mismatch")
+	}
+	flags := data[32]
+	if flags&flagUserPresent == 0 || flags&flagUserVerified == 0 {
+		return 0, 0, nil, errors.New("user was not verified")
+	}
+	return flags, binary.BigEndian.Uint32(data[33:37]), data[37:], nil
+}
+
+// parseCOSEKey decodes an ES256, EdDSA or RS256 COSE key
+func parseCOSEKey(raw []byte) (crypto.PublicKey, error) {
+	decoded, _, err := decodeCBOR(raw)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("credential public key is not a map")
+	}
+	alg, _ := key[int64(3)].(int64)
+	switch alg {
+	case algES256:
+		x, _ := key[int64(-2)].([]byte)
+		y, _ := key[int64(-3)].([]byte)
+		if key[int64(-1)] != int64(1) || len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("invalid ES256 key")
+		}
+		public := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !public.Curve.IsOnCurve(public.X, public.Y) {
+			return nil, errors.New("invalid ES256 key")
+		}
+		return public, nil
+	case algEdDSA:
+		x, _ := key[int64(-2)].([]byte)
+		if key[int64(-1)] != int64(6) || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid EdDSA key")
+		}
+		return ed25519.PublicKey(x), nil
+	case algRS256:
+		n, _ := key[int64(-1)].([]byte)
+		e, _ := key[int64(-2)].([]byte)
+		if len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid RS256 key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	}
+	return nil, fmt.Errorf("unsupported credential algorithm %d", alg)
+}
+
+func verifySignature(key crypto.PublicKey, signed []byte, signature []byte) error {
+	switch public := key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(signed)
+		if !ecdsa.VerifyASN1(public, digest[:], signature) {
+			return errors.New("invalid signature")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(public, signed, signature) {
+			return errors.New("invalid signature")
+		}
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(signed)
+		if err := rsa.VerifyPKCS1v15(public, crypto.SHA256, digest[:], signature); err != nil {
+			return errors.New("invalid signature")
+		}
+	default:
+		return errors.New("unsupported key")
+	}
+	return nil
+}
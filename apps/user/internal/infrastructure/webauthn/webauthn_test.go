@@ -0,0 +1,365 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/subscription-tracker/user/internal/core/application"
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+const (
+	testRPID      = "example.com"
+	testOrigin    = "https://example.com"
+	testChallenge = "ceremony-challenge"
+)
+
+// cborMap is a CBOR map given as alternating keys and values, so it encodes the same way
+// every time
+type cborMap []interface{}
+
+// encodeCBOR encodes the values decodeCBOR produces: ints, byte and text strings, arrays
+// and maps
+func encodeCBOR(v interface{}) []byte {
+	switch v := v.(type) {
+	case int:
+		if v < 0 {
+			return cborHead(1, uint64(-1-v))
+		}
+		return cborHead(0, uint64(v))
+	case []byte:
+		return append(cborHead(2, uint64(len(v))), v...)
+	case string:
+		return append(cborHead(3, uint64(len(v))), v...)
+	case []interface{}:
+		out := cborHead(4, uint64(len(v)))
+		for _, item := range v {
+			out = append(out, encodeCBOR(item)...)
+		}
+		return out
+	case cborMap:
+		out := cborHead(5, uint64(len(v)/2))
+		for _, item := range v {
+			out = append(out, encodeCBOR(item)...)
+		}
+		return out
+	}
+	panic("encodeCBOR: unsupported value")
+}
+
+func cborHead(major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n <= 0xff:
+		return []byte{major<<5 | 24, byte(n)}
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+	case n <= 0xffffffff:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(n))
+	}
+	return binary.BigEndian.AppendUint64([]byte{major<<5 | 27}, n)
+}
+
+// softAuthenticator is a software authenticator holding one ES256 or Ed25519 credential
+type softAuthenticator struct {
+	credentialID []byte
+	ecKey        *ecdsa.PrivateKey
+	edKey        ed25519.PrivateKey
+}
+
+func newSoftAuthenticator(t *testing.T, alg int) *softAuthenticator {
+	t.Helper()
+	a := &softAuthenticator{credentialID: []byte("credential-1")}
+	var err error
+	switch alg {
+	case algES256:
+		a.ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case algEdDSA:
+		_, a.edKey, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// coseKey is the credential public key in COSE form
+func (a *softAuthenticator) coseKey() []byte {
+	if a.edKey != nil {
+		return encodeCBOR(cborMap{
+			1, 1, 3, algEdDSA, -1, 6, -2, []byte(a.edKey.Public().(ed25519.PublicKey)),
+		})
+	}
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	a.ecKey.X.FillBytes(x)
+	a.ecKey.Y.FillBytes(y)
+	return encodeCBOR(cborMap{
+		1, 2, 3, algES256, -1, 1, -2, x, -3, y,
+	})
+}
+
+func (a *softAuthenticator) sign(t *testing.T, data []byte) []byte {
+	t.Helper()
+	if a.edKey != nil {
+		return ed25519.Sign(a.edKey, data)
+	}
+	digest := sha256.Sum256(data)
+	signature, err := ecdsa.SignASN1(rand.Reader, a.ecKey, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signature
+}
+
+func (a *softAuthenticator) passkey(signCount uint32) *domain.Passkey {
+	return &domain.Passkey{
+		ID:        base64.RawURLEncoding.EncodeToString(a.credentialID),
+		PublicKey: a.coseKey(),
+		SignCount: signCount,
+	}
+}
+
+// ceremony is what the authenticator and the browser put into a response
+type ceremony struct {
+	clientType string
+	challenge  string
+	origin     string
+	rpID       string
+	flags      byte
+	signCount  uint32
+}
+
+func (c ceremony) clientDataJSON() []byte {
+	data, _ := json.Marshal(clientData{Type: c.clientType, Challenge: c.challenge, Origin: c.origin})
+	return data
+}
+
+func (c ceremony) authenticatorData() []byte {
+	rpIDHash := sha256.Sum256([]byte(c.rpID))
+	data := append(rpIDHash[:], c.flags)
+	return binary.BigEndian.AppendUint32(data, c.signCount)
+}
+
+func (a *softAuthenticator) register(c ceremony) application.PasskeyAttestation {
+	authData := c.authenticatorData()
+	authData = append(authData, make([]byte, 16)...) // aaguid
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.credentialID)))
+	authData = append(authData, a.credentialID...)
+	authData = append(authData, a.coseKey()...)
+	return application.PasskeyAttestation{
+		ClientDataJSON: c.clientDataJSON(),
+		AttestationObject: encodeCBOR(cborMap{
+			"fmt", "none",
+			"attStmt", cborMap{},
+			"authData", authData,
+		}),
+	}
+}
+
+func (a *softAuthenticator) assert(t *testing.T, c ceremony) application.PasskeyAssertion {
+	clientDataJSON := c.clientDataJSON()
+	authData := c.authenticatorData()
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	return application.PasskeyAssertion{
+		CredentialID:      base64.RawURLEncoding.EncodeToString(a.credentialID),
+		ClientDataJSON:    clientDataJSON,
+		AuthenticatorData: authData,
+		Signature:         a.sign(t, append(append([]byte(nil), authData...), clientDataHash[:]...)),
+	}
+}
+
+func TestVerifyRegistration(t *testing.T) {
+	tests := []struct {
+		name   string
+		alg    int
+		modify func(c *ceremony)
+		// corrupt changes the response after the authenticator made it
+		corrupt func(response *application.PasskeyAttestation)
+		// wantErr is part of the expected error message
+		wantErr string
+	}{
+		{name: "ES256", alg: algES256},
+		{name: "Ed25519", alg: algEdDSA},
+		{name: "counter is kept", alg: algES256, modify: func(c *ceremony) { c.signCount = 7 }},
+		{name: "bad origin", alg: algES256, modify: func(c *ceremony) { c.origin = "https://evil.example.com" }, wantErr: "unexpected origin"},
+		{name: "bad challenge", alg: algES256, modify: func(c *ceremony) { c.challenge = "another-challenge" }, wantErr: "challenge mismatch"},
+		{name: "assertion client data", alg: algES256, modify: func(c *ceremony) { c.clientType = "webauthn.get" }, wantErr: "unexpected ceremony"},
+		{name: "bad rpIdHash", alg: algES256, modify: func(c *ceremony) { c.rpID = "evil.example.com" }, wantErr: "relying party ID mismatch"},
+		{name: "user not verified", alg: algES256, modify: func(c *ceremony) { c.flags &^= flagUserVerified }, wantErr: "user was not verified"},
+		{name: "user not present", alg: algEdDSA, modify: func(c *ceremony) { c.flags &^= flagUserPresent }, wantErr: "user was not verified"},
+		{name: "no attested credential", alg: algES256, modify: func(c *ceremony) { c.flags &^= flagAttested }, wantErr: "no attested credential"},
+		{
+			name: "truncated attestation object",
+			alg:  algES256,
+			corrupt: func(response *application.PasskeyAttestation) {
+				response.AttestationObject = response.AttestationObject[:40]
+			},
+			wantErr: "truncated",
+		},
+		{
+			name: "unsupported key",
+			alg:  algES256,
+			corrupt: func(response *application.PasskeyAttestation) {
+				authData := make([]byte, 37, 128)
+				rpIDHash := sha256.Sum256([]byte(testRPID))
+				copy(authData, rpIDHash[:])
+				authData[32] = flagUserPresent | flagUserVerified | flagAttested
+				authData = append(authData, make([]byte, 16)...)
+				authData = append(authData, 0, 1, 'x')
+				authData = append(authData, encodeCBOR(cborMap{1, 2, 3, -35})...)
+				response.AttestationObject = encodeCBOR(cborMap{"fmt", "none", "authData", authData})
+			},
+			wantErr: "unsupported credential algorithm -35",
+		},
+	}
+
+	rp := NewRelyingParty(testRPID, "Test", []string{testOrigin})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := newSoftAuthenticator(t, tt.alg)
+			c := ceremony{
+				clientType: "webauthn.create",
+				challenge:  testChallenge,
+				origin:     testOrigin,
+				rpID:       testRPID,
+				flags:      flagUserPresent | flagUserVerified | flagAttested,
+			}
+			if tt.modify != nil {
+				tt.modify(&c)
+			}
+			response := authenticator.register(c)
+			if tt.corrupt != nil {
+				tt.corrupt(&response)
+			}
+
+			passkey, err := rp.VerifyRegistration(testChallenge, response)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyRegistration: %v", err)
+			}
+			want := authenticator.passkey(c.signCount)
+			if passkey.ID != want.ID || string(passkey.PublicKey) != string(want.PublicKey) || passkey.SignCount != want.SignCount {
+				t.Errorf("passkey = %+v, want %+v", passkey, want)
+			}
+		})
+	}
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	tests := []struct {
+		name string
+		alg  int
+		// stored is the signature counter recorded for the passkey
+		stored  uint32
+		modify  func(c *ceremony)
+		corrupt func(response *application.PasskeyAssertion)
+		wantErr string
+		want    uint32
+	}{
+		{name: "ES256", alg: algES256, stored: 4, modify: func(c *ceremony) { c.signCount = 5 }, want: 5},
+		{name: "Ed25519", alg: algEdDSA, stored: 4, modify: func(c *ceremony) { c.signCount = 5 }, want: 5},
+		{name: "authenticator without counter", alg: algES256},
+		{name: "bad origin", alg: algES256, modify: func(c *ceremony) { c.origin = "https://evil.example.com" }, wantErr: "unexpected origin"},
+		{name: "bad challenge", alg: algES256, modify: func(c *ceremony) { c.challenge = "another-challenge" }, wantErr: "challenge mismatch"},
+		{name: "registration client data", alg: algES256, modify: func(c *ceremony) { c.clientType = "webauthn.create" }, wantErr: "unexpected ceremony"},
+		{name: "bad rpIdHash", alg: algEdDSA, modify: func(c *ceremony) { c.rpID = "evil.example.com" }, wantErr: "relying party ID mismatch"},
+		{name: "user not verified", alg: algES256, modify: func(c *ceremony) { c.flags = flagUserPresent }, wantErr: "user was not verified"},
+		{
+			name:    "bad ES256 signature",
+			alg:     algES256,
+			corrupt: func(response *application.PasskeyAssertion) { response.Signature[len(response.Signature)-1] ^= 0xff },
+			wantErr: "invalid signature",
+		},
+		{
+			name:    "bad Ed25519 signature",
+			alg:     algEdDSA,
+			corrupt: func(response *application.PasskeyAssertion) { response.Signature[0] ^= 0xff },
+			wantErr: "invalid signature",
+		},
+		{
+			name: "signature over other client data",
+			alg:  algES256,
+			corrupt: func(response *application.PasskeyAssertion) {
+				response.ClientDataJSON = []byte(`{"type":"webauthn.get","challenge":"` + testChallenge + `","origin":"` + testOrigin + `","crossOrigin":true}`)
+			},
+			wantErr: "invalid signature",
+		},
+		{name: "counter not increased", alg: algES256, stored: 5, modify: func(c *ceremony) { c.signCount = 5 }, wantErr: "counter did not increase"},
+		{name: "counter went back", alg: algES256, stored: 5, modify: func(c *ceremony) { c.signCount = 3 }, wantErr: "counter did not increase"},
+		{name: "counter reset to zero", alg: algEdDSA, stored: 5, wantErr: "counter did not increase"},
+		{
+			name: "truncated authenticator data",
+			alg:  algES256,
+			corrupt: func(response *application.PasskeyAssertion) {
+				response.AuthenticatorData = response.AuthenticatorData[:36]
+			},
+			wantErr: "truncated",
+		},
+	}
+
+	rp := NewRelyingParty(testRPID, "Test", []string{testOrigin})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := newSoftAuthenticator(t, tt.alg)
+			c := ceremony{
+				clientType: "webauthn.get",
+				challenge:  testChallenge,
+				origin:     testOrigin,
+				rpID:       testRPID,
+				flags:      flagUserPresent | flagUserVerified,
+			}
+			if tt.modify != nil {
+				tt.modify(&c)
+			}
+			response := authenticator.assert(t, c)
+			if tt.corrupt != nil {
+				tt.corrupt(&response)
+			}
+
+			signCount, err := rp.VerifyAssertion(testChallenge, authenticator.passkey(tt.stored), response)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyAssertion: %v", err)
+			}
+			if signCount != tt.want {
+				t.Errorf("signature counter = %d, want %d", signCount, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyAssertionWithAnotherCredential(t *testing.T) {
+	rp := NewRelyingParty(testRPID, "Test", []string{testOrigin})
+	signer := newSoftAuthenticator(t, algES256)
+	registered := newSoftAuthenticator(t, algES256)
+	response := signer.assert(t, ceremony{
+		clientType: "webauthn.get",
+		challenge:  testChallenge,
+		origin:     testOrigin,
+		rpID:       testRPID,
+		flags:      flagUserPresent | flagUserVerified,
+	})
+	if _, err := rp.VerifyAssertion(testChallenge, registered.passkey(0), response); err == nil || !strings.Contains(err.Error(), "invalid signature") {
+		t.Fatalf("error = %v, want an invalid signature", err)
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/subscription-tracker/user/internal/core/application"
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+type SetRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// ListUsers searches users by the q, role, page and page_size query parameters
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.Query("page"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size"))
+	filter := application.UserFilter{Query: c.Query("q"), Role: domain.Role(c.Query("role"))}
+
+	users, err := h.userService.ListUsers(c.GetString("user_id"), filter, page, pageSize)
+	if errors.Is(err, application.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"message": "not allowed to list users"})
+		return
+	} else if errors.Is(err, application.ErrInvalidRole) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid role"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to list users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+func (h *UserHandler) SetUserRole(c *gin.Context) {
+	var req SetRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	err := h.userService.SetRole(c.GetString("user_id"), c.Param("id"), domain.Role(req.Role))
+	if errors.Is(err, application.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"message": "not allowed to change this role"})
+		return
+	} else if errors.Is(err, application.ErrInvalidRole) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid role"})
+		return
+	} else if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to change role"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "role updated"})
+}
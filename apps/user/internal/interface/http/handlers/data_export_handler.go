@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/subscription-tracker/user/internal/core/application"
+)
+
+func (h *UserHandler) RequestDataExport(c *gin.Context) {
+	export, err := h.userService.RequestDataExport(c.GetString("user_id"))
+	if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if errors.Is(err, application.ErrDataExportInProgress) {
+		c.JSON(http.StatusConflict, gin.H{"message": "a data export is already in progress"})
+		return
+	} else if errors.Is(err, application.ErrRateLimited) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"message": "too many data exports, try again later"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to request data export"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, export)
+}
+
+func (h *UserHandler) ListDataExports(c *gin.Context) {
+	exports, err := h.userService.ListDataExports(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to list data exports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, exports)
+}
+
+// DownloadDataExport serves the archive of an export to the holder of its emailed link
+func (h *UserHandler) DownloadDataExport(c *gin.Context) {
+	export, content, err := h.userService.OpenDataExport(c.Param("id"), c.Query("token"))
+	if errors.Is(err, application.ErrInvalidToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "invalid or expired download link"})
+		return
+	} else if errors.Is(err, application.ErrDataExportNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "data export not found or expired"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to download data export"})
+		return
+	}
+	defer content.Close()
+
+	c.DataFromReader(http.StatusOK, -1, "application/zip", content, map[string]string{
+		"Content-Disposition": `attachment; filename="data-export-` + export.CompletedAt.Format("2006-01-02") + `.zip"`,
+	})
+}
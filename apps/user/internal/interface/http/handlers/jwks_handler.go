@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/subscription-tracker/user/internal/infrastructure/jwtkeys"
+)
+
+type JWKSHandler struct {
+	keys *jwtkeys.KeySet
+}
+
+func NewJWKSHandler(keys *jwtkeys.KeySet) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// GetJWKS publishes the public keys that verify the service's tokens
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
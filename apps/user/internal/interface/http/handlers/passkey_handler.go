@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/subscription-tracker/user/internal/core/application"
+)
+
+// base64URL is a binary WebAuthn field, encoded as by PublicKeyCredential.toJSON()
+type base64URL []byte
+
+func (b *base64URL) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// PasskeyCredential is a serialised PublicKeyCredential
+type PasskeyCredential struct {
+	ID       string `json:"id" binding:"required"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    base64URL `json:"clientDataJSON"`
+		AttestationObject base64URL `json:"attestationObject"`
+		AuthenticatorData base64URL `json:"authenticatorData"`
+		Signature         base64URL `json:"signature"`
+		UserHandle        base64URL `json:"userHandle"`
+	} `json:"response"`
+}
+
+func (c PasskeyCredential) attestation() application.PasskeyAttestation {
+	return application.PasskeyAttestation{
+		ClientDataJSON:    c.Response.ClientDataJSON,
+		AttestationObject: c.Response.AttestationObject,
+	}
+}
+
+func (c PasskeyCredential) assertion() application.PasskeyAssertion {
+	return application.PasskeyAssertion{
+		CredentialID:      strings.TrimRight(c.ID, "="),
+		ClientDataJSON:    c.Response.ClientDataJSON,
+		AuthenticatorData: c.Response.AuthenticatorData,
+		Signature:         c.Response.Signature,
+		UserHandle:        c.Response.UserHandle,
+	}
+}
+
+type RegisterPasskeyRequest struct {
+	Session    string            `json:"session" binding:"required"`
+	Name       string            `json:"name"`
+	Credential PasskeyCredential `json:"credential" binding:"required"`
+}
+
+type RenamePasskeyRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type PasskeyLoginRequest struct {
+	Session    string            `json:"session" binding:"required"`
+	Credential PasskeyCredential `json:"credential" binding:"required"`
+}
+
+type TwoFactorPasskeyOptionsRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+}
+
+type TwoFactorPasskeyRequest struct {
+	ChallengeToken string            `json:"challenge_token" binding:"required"`
+	Session        string            `json:"session" binding:"required"`
+	Credential     PasskeyCredential `json:"credential" binding:"required"`
+}
+
+func (h *UserHandler) BeginPasskeyRegistration(c *gin.Context) {
+	ceremony, err := h.userService.BeginPasskeyRegistration(c.GetString("user_id"))
+	if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to start passkey registration"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ceremony)
+}
+
+func (h *UserHandler) RegisterPasskey(c *gin.Context) {
+	var req RegisterPasskeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	passkey, err := h.userService.FinishPasskeyRegistration(c.GetString("user_id"), req.Session, req.Name, req.Credential.attestation())
+	if errors.Is(err, application.ErrInvalidToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid or expired passkey session"})
+		return
+	} else if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if errors.Is(err, application.ErrPasskeyVerification) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "passkey could not be verified"})
+		return
+	} else if errors.Is(err, application.ErrPasskeyExists) {
+		c.JSON(http.StatusConflict, gin.H{"message": "passkey already registered"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to register passkey"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, passkey)
+}
+
+func (h *UserHandler) ListPasskeys(c *gin.Context) {
+	passkeys, err := h.userService.ListPasskeys(c.GetString("user_id"))
+	if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to list passkeys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, passkeys)
+}
+
+func (h *UserHandler) RenamePasskey(c *gin.Context) {
+	var req RenamePasskeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	passkey, err := h.userService.RenamePasskey(c.GetString("user_id"), c.Param("id"), req.Name)
+	if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if errors.Is(err, application.ErrPasskeyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "passkey not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to rename passkey"})
+		return
+	}
+
+	c.JSON(http.StatusOK, passkey)
+}
+
+func (h *UserHandler) DeletePasskey(c *gin.Context) {
+	err := h.userService.DeletePasskey(c.GetString("user_id"), c.Param("id"))
+	if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if errors.Is(err, application.ErrPasskeyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "passkey not found"})
+		return
+	} else if errors.Is(err, application.ErrLastLoginMethod) {
+		c.JSON(http.StatusConflict, gin.H{"message": "cannot remove the last login method, set a password first"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to delete passkey"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "passkey deleted"})
+}
+
+func (h *UserHandler) BeginPasskeyLogin(c *gin.Context) {
+	ceremony, err := h.userService.BeginPasskeyLogin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to start passkey login"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ceremony)
+}
+
+func (h *UserHandler) PasskeyLogin(c *gin.Context) {
+	var req PasskeyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	response, err := h.userService.FinishPasskeyLogin(req.Session, req.Credential.assertion(), clientInfo(c))
+	if errors.Is(err, application.ErrAccountPendingDeletion) {
+		accountPendingDeletion(c)
+		return
+	} else if errors.Is(err, application.ErrInvalidToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "invalid or expired passkey session"})
+		return
+	} else if errors.Is(err, application.ErrPasskeyVerification) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "passkey could not be verified"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to login"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *UserHandler) BeginTwoFactorPasskey(c *gin.Context) {
+	var req TwoFactorPasskeyOptionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	ceremony, err := h.userService.BeginTwoFactorPasskey(req.ChallengeToken)
+	if errors.Is(err, application.ErrInvalidToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "invalid or expired challenge"})
+		return
+	} else if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if errors.Is(err, application.ErrPasskeyNotFound) {
+		c.JSON(http.StatusConflict, gin.H{"message": "no passkeys registered"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to start passkey login"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ceremony)
+}
+
+func (h *UserHandler) CompleteTwoFactorPasskey(c *gin.Context) {
+	var req TwoFactorPasskeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	response, err := h.userService.CompleteTwoFactorPasskey(req.ChallengeToken, req.Session, req.Credential.assertion(), clientInfo(c))
+	if errors.Is(err, application.ErrAccountPendingDeletion) {
+		accountPendingDeletion(c)
+		return
+	} else if errors.Is(err, application.ErrInvalidToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "invalid or expired challenge"})
+		return
+	} else if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if errors.Is(err, application.ErrPasskeyVerification) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "passkey could not be verified"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to login"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
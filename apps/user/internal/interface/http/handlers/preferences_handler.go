@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/subscription-tracker/user/internal/core/application"
+	"github.com/subscription-tracker/user/internal/core/domain"
+)
+
+type NotificationChannelsRequest struct {
+	Email *bool `json:"email"`
+	Push  *bool `json:"push"`
+}
+
+// UpdatePreferencesRequest is a partial update; fields that are left out keep their value
+type UpdatePreferencesRequest struct {
+	Currency         *string                      `json:"currency" binding:"omitempty,iso4217"`
+	TimeZone         *string                      `json:"time_zone" binding:"omitempty,timezone"`
+	Locale           *string                      `json:"locale" binding:"omitempty,bcp47_language_tag"`
+	WeekStart        *string                      `json:"week_start" binding:"omitempty,oneof=monday tuesday wednesday thursday friday saturday sunday"`
+	ReminderLeadDays *int                         `json:"reminder_lead_days" binding:"omitempty,min=0,max=30"`
+	DigestFrequency  *domain.DigestFrequency      `json:"digest_frequency" binding:"omitempty,oneof=never daily weekly monthly"`
+	Channels         *NotificationChannelsRequest `json:"channels"`
+}
+
+func (h *UserHandler) GetPreferences(c *gin.Context) {
+	preferences, err := h.userService.GetPreferences(c.GetString("user_id"))
+	if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+func (h *UserHandler) UpdatePreferences(c *gin.Context) {
+	var req UpdatePreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	update := application.PreferencesUpdate{
+		Currency:         req.Currency,
+		TimeZone:         req.TimeZone,
+		Locale:           req.Locale,
+		WeekStart:        req.WeekStart,
+		ReminderLeadDays: req.ReminderLeadDays,
+		DigestFrequency:  req.DigestFrequency,
+	}
+	if req.Channels != nil {
+		update.EmailNotices = req.Channels.Email
+		update.PushNotices = req.Channels.Push
+	}
+	preferences, err := h.userService.UpdatePreferences(c.GetString("user_id"), update)
+	if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if errors.Is(err, application.ErrInvalidPreferences) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	} else if errors.Is(err, application.ErrChannelNeedsVerifiedEmail) {
+		c.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to update preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
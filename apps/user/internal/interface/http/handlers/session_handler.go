@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/subscription-tracker/user/internal/core/application"
+)
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+func (h *UserHandler) RefreshToken(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	response, err := h.userService.Refresh(req.RefreshToken, clientInfo(c))
+	if errors.Is(err, application.ErrInvalidRefreshToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "invalid or expired refresh token"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to refresh token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *UserHandler) ListSessions(c *gin.Context) {
+	sessions, err := h.userService.ListSessions(c.GetString("user_id"), c.GetString("session_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to list sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (h *UserHandler) RevokeSession(c *gin.Context) {
+	err := h.userService.RevokeSession(c.GetString("user_id"), c.Param("id"))
+	if errors.Is(err, application.ErrSessionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "session not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "session revoked"})
+}
+
+func (h *UserHandler) Logout(c *gin.Context) {
+	sessionID := c.GetString("session_id")
+	if sessionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "token has no session"})
+		return
+	}
+
+	err := h.userService.RevokeSession(c.GetString("user_id"), sessionID)
+	if err != nil && !errors.Is(err, application.ErrSessionNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to logout"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+func (h *UserHandler) LogoutEverywhere(c *gin.Context) {
+	if err := h.userService.RevokeAllSessions(c.GetString("user_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to logout"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out of all sessions"})
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/subscription-tracker/user/internal/core/application"
+)
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+func (h *UserHandler) CompleteTwoFactorLogin(c *gin.Context) {
+	var req TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	response, err := h.userService.CompleteTwoFactorLogin(req.ChallengeToken, req.Code, clientInfo(c))
+	var throttled *application.LoginThrottledError
+	if errors.Is(err, application.ErrAccountPendingDeletion) {
+		accountPendingDeletion(c)
+		return
+	} else if errors.As(err, &throttled) {
+		loginThrottled(c, throttled)
+		return
+	} else if errors.Is(err, application.ErrInvalidToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "invalid or expired challenge"})
+		return
+	} else if errors.Is(err, application.ErrInvalidCode) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "invalid two-factor code"})
+		return
+	} else if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to login"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *UserHandler) EnrolTOTP(c *gin.Context) {
+	enrolment, err := h.userService.EnrolTOTP(c.GetString("user_id"))
+	if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if errors.Is(err, application.ErrTwoFactorEnabled) {
+		c.JSON(http.StatusConflict, gin.H{"message": "two-factor authentication already enabled"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to enrol two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, enrolment)
+}
+
+func (h *UserHandler) ConfirmTOTP(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	recoveryCodes, err := h.userService.ConfirmTOTP(c.GetString("user_id"), req.Code)
+	if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if errors.Is(err, application.ErrTwoFactorEnabled) {
+		c.JSON(http.StatusConflict, gin.H{"message": "two-factor authentication already enabled"})
+		return
+	} else if errors.Is(err, application.ErrTwoFactorNotEnrolled) {
+		c.JSON(http.StatusConflict, gin.H{"message": "start the two-factor enrolment first"})
+		return
+	} else if errors.Is(err, application.ErrInvalidCode) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid two-factor code"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to enable two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": recoveryCodes})
+}
+
+func (h *UserHandler) DisableTOTP(c *gin.Context) {
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	err := h.userService.DisableTOTP(c.GetString("user_id"), req.Code, clientInfo(c))
+	var throttled *application.LoginThrottledError
+	if errors.As(err, &throttled) {
+		loginThrottled(c, throttled)
+		return
+	} else if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if errors.Is(err, application.ErrTwoFactorNotEnabled) {
+		c.JSON(http.StatusConflict, gin.H{"message": "two-factor authentication not enabled"})
+		return
+	} else if errors.Is(err, application.ErrInvalidCode) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid two-factor code"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to disable two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
@@ -1,8 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/subscription-tracker/user/internal/core/application"
@@ -10,6 +15,9 @@ import (
 
 type UserHandler struct {
 	userService *application.UserService
+	frontendURL string
+	// secureCookies marks the state and nonce cookies Secure so they are only sent over HTTPS
+	secureCookies bool
 }
 
 type RegisterRequest struct {
@@ -29,6 +37,29 @@ type PasswordlessLoginRequest struct {
 
 type VerifyTokenRequest struct {
 	Token string `json:"token" binding:"required"`
+	// Binding is returned when the login link is requested and ties the link to that browser
+	Binding string `json:"binding" binding:"required"`
+}
+
+type ExchangeLoginCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+type RestoreAccountRequest struct {
+	Token string `json:"token" binding:"required"`
 }
 
 type UpdateUserRequest struct {
@@ -36,8 +67,29 @@ type UpdateUserRequest struct {
 	Password string `json:"password,omitempty" binding:"omitempty,min=6"`
 }
 
-func NewUserHandler(userService *application.UserService) *UserHandler {
-	return &UserHandler{userService: userService}
+func NewUserHandler(userService *application.UserService, frontendURL string, secureCookies bool) *UserHandler {
+	return &UserHandler{userService: userService, frontendURL: strings.TrimSuffix(frontendURL, "/"), secureCookies: secureCookies}
+}
+
+// setCookie sets an HTTP-only cookie for the whole site; a negative maxAge deletes it
+func (h *UserHandler) setCookie(c *gin.Context, name, value string, maxAge int) {
+	c.SetCookie(name, value, maxAge, "/", "", h.secureCookies, true)
+}
+
+// loginThrottled answers 429 with the seconds until the next login attempt is allowed
+func loginThrottled(c *gin.Context, err *application.LoginThrottledError) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(err.RetryAfter.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, gin.H{"message": "too many failed login attempts, try again later"})
+}
+
+// accountPendingDeletion refuses logins to accounts scheduled for deletion
+func accountPendingDeletion(c *gin.Context) {
+	c.JSON(http.StatusForbidden, gin.H{"message": "account is scheduled for deletion, use the link in the deletion email to restore it"})
+}
+
+// clientInfo describes the device of the request for session listings
+func clientInfo(c *gin.Context) application.ClientInfo {
+	return application.ClientInfo{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
 }
 
 func (h *UserHandler) Register(c *gin.Context) {
@@ -47,7 +99,7 @@ func (h *UserHandler) Register(c *gin.Context) {
 		return
 	}
 
-	response, err := h.userService.Register(req.Email, req.Name, req.Password)
+	response, err := h.userService.Register(req.Email, req.Name, req.Password, clientInfo(c))
 	if err == application.ErrUserAlreadyExists {
 		c.JSON(http.StatusConflict, gin.H{"message": "user already exists"})
 		return
@@ -66,8 +118,15 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	response, err := h.userService.Login(req.Email, req.Password)
-	if err == application.ErrInvalidCredentials {
+	response, err := h.userService.Login(req.Email, req.Password, clientInfo(c))
+	var throttled *application.LoginThrottledError
+	if errors.Is(err, application.ErrAccountPendingDeletion) {
+		accountPendingDeletion(c)
+		return
+	} else if errors.As(err, &throttled) {
+		loginThrottled(c, throttled)
+		return
+	} else if err == application.ErrInvalidCredentials {
 		c.JSON(http.StatusUnauthorized, gin.H{"message": "invalid credentials"})
 		return
 	} else if err != nil {
@@ -80,8 +139,11 @@ func (h *UserHandler) Login(c *gin.Context) {
 
 func (h *UserHandler) GetUser(c *gin.Context) {
 	userID := c.Param("id")
-	user, err := h.userService.GetUserByID(userID)
-	if err == application.ErrUserNotFound {
+	user, err := h.userService.GetUser(c.GetString("user_id"), userID)
+	if errors.Is(err, application.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"message": "not allowed to view this user"})
+		return
+	} else if err == application.ErrUserNotFound {
 		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
 		return
 	} else if err != nil {
@@ -100,8 +162,11 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	err := h.userService.UpdateUser(userID, req.Name, req.Password)
-	if err == application.ErrUserNotFound {
+	err := h.userService.UpdateUser(c.GetString("user_id"), userID, req.Name, req.Password)
+	if errors.Is(err, application.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"message": "not allowed to update this user"})
+		return
+	} else if err == application.ErrUserNotFound {
 		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
 		return
 	} else if err != nil {
@@ -123,10 +188,14 @@ func (h *UserHandler) GetCurrentUser(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
+// DeleteUser schedules the deletion of the account; it is purged after a grace period
 func (h *UserHandler) DeleteUser(c *gin.Context) {
 	userID := c.Param("id")
-	err := h.userService.DeleteUser(userID)
-	if err == application.ErrUserNotFound {
+	deletion, err := h.userService.DeleteUser(c.GetString("user_id"), userID)
+	if errors.Is(err, application.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"message": "not allowed to delete this user"})
+		return
+	} else if err == application.ErrUserNotFound {
 		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
 		return
 	} else if err != nil {
@@ -134,7 +203,51 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "user deleted successfully"})
+	c.JSON(http.StatusAccepted, gin.H{"message": "user scheduled for deletion", "deletion": deletion})
+}
+
+func (h *UserHandler) RestoreUser(c *gin.Context) {
+	err := h.userService.RestoreUser(c.GetString("user_id"), c.Param("id"))
+	if errors.Is(err, application.ErrForbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"message": "not allowed to restore this user"})
+		return
+	} else if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if errors.Is(err, application.ErrAccountNotPendingDeletion) {
+		c.JSON(http.StatusConflict, gin.H{"message": "account is not scheduled for deletion"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to restore user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user restored"})
+}
+
+func (h *UserHandler) RestoreAccount(c *gin.Context) {
+	var req RestoreAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	err := h.userService.RestoreAccount(req.Token)
+	if errors.Is(err, application.ErrInvalidToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid or expired restore link"})
+		return
+	} else if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if errors.Is(err, application.ErrAccountNotPendingDeletion) {
+		c.JSON(http.StatusConflict, gin.H{"message": "account is not scheduled for deletion"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to restore account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "account restored"})
 }
 
 func (h *UserHandler) InitiatePasswordlessLogin(c *gin.Context) {
@@ -144,8 +257,11 @@ func (h *UserHandler) InitiatePasswordlessLogin(c *gin.Context) {
 		return
 	}
 
-	err := h.userService.InitiatePasswordlessLogin(req.Email)
-	if err == application.ErrUserNotFound {
+	binding, err := h.userService.InitiatePasswordlessLogin(req.Email, c.ClientIP())
+	if errors.Is(err, application.ErrRateLimited) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"message": "too many login links requested, try again later"})
+		return
+	} else if err == application.ErrUserNotFound {
 		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
 		return
 	} else if err != nil {
@@ -153,7 +269,7 @@ func (h *UserHandler) InitiatePasswordlessLogin(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "login link sent to email"})
+	c.JSON(http.StatusOK, gin.H{"message": "login link sent to email", "binding": binding})
 }
 
 func (h *UserHandler) VerifyLoginToken(c *gin.Context) {
@@ -163,8 +279,11 @@ func (h *UserHandler) VerifyLoginToken(c *gin.Context) {
 		return
 	}
 
-	response, err := h.userService.VerifyLoginToken(req.Token)
-	if err == application.ErrInvalidToken {
+	response, err := h.userService.VerifyLoginToken(req.Token, req.Binding, clientInfo(c))
+	if errors.Is(err, application.ErrAccountPendingDeletion) {
+		accountPendingDeletion(c)
+		return
+	} else if err == application.ErrInvalidToken {
 		c.JSON(http.StatusUnauthorized, gin.H{"message": "invalid or expired token"})
 		return
 	} else if err != nil {
@@ -177,7 +296,7 @@ func (h *UserHandler) VerifyLoginToken(c *gin.Context) {
 
 func (h *UserHandler) InitiateLogin(c *gin.Context) {
 	state := h.userService.GenerateOAuthState()
-	c.SetCookie("oauth_state", state, 3600, "/", "", false, true)
+	h.setCookie(c, "oauth_state", state, 3600)
 
 	spotifyAuthURL := h.userService.GetSpotifyAuthURL(state)
 	fmt.Println(spotifyAuthURL)
@@ -189,16 +308,315 @@ func (h *UserHandler) HandleCallback(c *gin.Context) {
 	state := c.Query("state")
 	savedState, _ := c.Cookie("oauth_state")
 
-	if state == "" || state != savedState {
+	if state == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid state parameter"})
 		return
 	}
+	// Without the login cookie the state has to be a link request of a signed in user
+	if state != savedState {
+		err := h.userService.HandleSpotifyLink(code, state, h.takeLinkNonce(c, application.SpotifyProvider))
+		h.linkResponse(c, application.SpotifyProvider, err)
+		return
+	}
 
-	response, err := h.userService.HandleSpotifyCallback(code)
-	if err != nil {
+	loginCode, err := h.userService.HandleSpotifyCallback(code)
+	if errors.Is(err, application.ErrEmailNotVerified) {
+		c.JSON(http.StatusForbidden, gin.H{"message": "an account with this email exists, sign in to it and link Spotify from there"})
+		return
+	} else if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to handle Spotify callback", "error": err.Error()})
 		return
 	}
 
-	c.Redirect(http.StatusTemporaryRedirect, "http://localhost:3000/login/callback/spotify?token="+response.Token)
+	h.loginRedirect(c, application.SpotifyProvider, loginCode)
+}
+
+func (h *UserHandler) SyncSpotify(c *gin.Context) {
+	userID := c.GetString("user_id")
+	userToken := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+	err := h.userService.SyncSpotifySubscription(userID, userToken)
+	if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if errors.Is(err, application.ErrSpotifyNotLinked) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "spotify account not linked"})
+		return
+	} else if errors.Is(err, application.ErrSpotifyLinkBroken) {
+		c.JSON(http.StatusConflict, gin.H{"message": "spotify authorization was revoked, link the account again"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"message": "failed to sync spotify subscription"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "spotify subscription synced"})
+}
+
+func (h *UserHandler) ListLoginProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": h.userService.LoginProviders()})
+}
+
+func (h *UserHandler) InitiateProviderLogin(c *gin.Context) {
+	provider := c.Param("provider")
+	state := h.userService.GenerateOAuthState()
+
+	authURL, err := h.userService.GetProviderAuthURL(c.Request.Context(), provider, state)
+	if errors.Is(err, application.ErrUnknownProvider) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "unknown login provider"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"message": "login provider unavailable"})
+		return
+	}
+
+	h.setCookie(c, "oauth_state_"+strings.ToLower(provider), state, 3600)
+	c.Redirect(http.StatusTemporaryRedirect, authURL)
+}
+
+func (h *UserHandler) HandleProviderCallback(c *gin.Context) {
+	provider := c.Param("provider")
+	code := c.Query("code")
+	state := c.Query("state")
+	stateCookie := "oauth_state_" + strings.ToLower(provider)
+	savedState, _ := c.Cookie(stateCookie)
+
+	if state == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid state parameter"})
+		return
+	}
+	// Without the login cookie the state has to be a link request of a signed in user
+	if state != savedState {
+		err := h.userService.HandleProviderLink(c.Request.Context(), provider, code, state, h.takeLinkNonce(c, provider))
+		h.linkResponse(c, strings.ToLower(provider), err)
+		return
+	}
+	h.setCookie(c, stateCookie, "", -1)
+
+	loginCode, err := h.userService.HandleProviderCallback(c.Request.Context(), provider, code, state)
+	if errors.Is(err, application.ErrUnknownProvider) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "unknown login provider"})
+		return
+	} else if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if errors.Is(err, application.ErrEmailNotVerified) {
+		c.JSON(http.StatusForbidden, gin.H{"message": "an account with this email exists, sign in to it and link this login from there"})
+		return
+	} else if errors.Is(err, application.ErrProviderAuth) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "login provider authentication failed"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to handle login callback"})
+		return
+	}
+
+	h.loginRedirect(c, strings.ToLower(provider), loginCode)
+}
+
+// loginRedirect hands the code of a provider login to the app, which exchanges it for the
+// session tokens or the second factor challenge
+func (h *UserHandler) loginRedirect(c *gin.Context, provider string, loginCode string) {
+	c.Redirect(http.StatusTemporaryRedirect, h.frontendURL+"/login/callback/"+provider+"?code="+url.QueryEscape(loginCode))
+}
+
+// ExchangeLoginCode returns the session of a provider login for the code its callback
+// redirected to the app with
+func (h *UserHandler) ExchangeLoginCode(c *gin.Context) {
+	var req ExchangeLoginCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	response, err := h.userService.ExchangeLoginCode(req.Code, clientInfo(c))
+	if errors.Is(err, application.ErrInvalidToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "invalid or expired login code"})
+		return
+	} else if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if errors.Is(err, application.ErrAccountPendingDeletion) {
+		accountPendingDeletion(c)
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to login"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// linkResponse sends the browser back to the app after linking a provider
+func (h *UserHandler) linkResponse(c *gin.Context, provider string, err error) {
+	if errors.Is(err, application.ErrInvalidToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "invalid state parameter"})
+		return
+	} else if errors.Is(err, application.ErrUnknownProvider) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "unknown login provider"})
+		return
+	} else if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if errors.Is(err, application.ErrIdentityInUse) {
+		c.JSON(http.StatusConflict, gin.H{"message": "this login is already linked to another account"})
+		return
+	} else if errors.Is(err, application.ErrProviderAlreadyLinked) {
+		c.JSON(http.StatusConflict, gin.H{"message": "another login of this provider is already linked"})
+		return
+	} else if errors.Is(err, application.ErrProviderAuth) || errors.Is(err, application.ErrSpotifyAuth) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "login provider authentication failed"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to link login"})
+		return
+	}
+
+	c.Redirect(http.StatusTemporaryRedirect, h.frontendURL+"/?linked="+provider)
+}
+
+func (h *UserHandler) GetLoginMethods(c *gin.Context) {
+	userID := c.GetString("user_id")
+	methods, err := h.userService.GetLoginMethods(userID)
+	if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get login methods"})
+		return
+	}
+
+	c.JSON(http.StatusOK, methods)
+}
+
+func (h *UserHandler) LinkIdentity(c *gin.Context) {
+	userID := c.GetString("user_id")
+	provider := strings.ToLower(c.Param("provider"))
+	url, nonce, err := h.userService.GetLinkURL(c.Request.Context(), userID, provider)
+	if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if errors.Is(err, application.ErrUnknownProvider) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "unknown login provider"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"message": "login provider unavailable"})
+		return
+	}
+
+	// The callback only completes the link in the browser holding this cookie. The app has
+	// to send this request with credentials so the browser keeps the cookie.
+	c.SetSameSite(http.SameSiteLaxMode)
+	h.setCookie(c, "link_nonce_"+provider, nonce, 600)
+	c.JSON(http.StatusOK, gin.H{"url": url})
+}
+
+// takeLinkNonce returns and clears the link nonce cookie of provider
+func (h *UserHandler) takeLinkNonce(c *gin.Context, provider string) string {
+	name := "link_nonce_" + strings.ToLower(provider)
+	nonce, _ := c.Cookie(name)
+	h.setCookie(c, name, "", -1)
+	return nonce
+}
+
+func (h *UserHandler) UnlinkIdentity(c *gin.Context) {
+	userID := c.GetString("user_id")
+	err := h.userService.UnlinkIdentity(userID, c.Param("provider"))
+	if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if errors.Is(err, application.ErrIdentityNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "login not linked"})
+		return
+	} else if errors.Is(err, application.ErrLastLoginMethod) {
+		c.JSON(http.StatusConflict, gin.H{"message": "cannot remove the last login method, set a password first"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to unlink login"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "login unlinked"})
+}
+
+func (h *UserHandler) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	err := h.userService.VerifyEmail(req.Token)
+	if errors.Is(err, application.ErrInvalidToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "invalid or expired token"})
+		return
+	} else if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to verify email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email verified"})
+}
+
+func (h *UserHandler) ResendVerificationEmail(c *gin.Context) {
+	err := h.userService.ResendVerificationEmail(c.GetString("user_id"))
+	if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if errors.Is(err, application.ErrEmailAlreadyVerified) {
+		c.JSON(http.StatusConflict, gin.H{"message": "email already verified"})
+		return
+	} else if errors.Is(err, application.ErrRateLimited) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"message": "too many verification emails requested, try again later"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "verification email sent"})
+}
+
+func (h *UserHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	err := h.userService.ForgotPassword(req.Email, c.ClientIP())
+	if errors.Is(err, application.ErrRateLimited) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"message": "too many password resets requested, try again later"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to request password reset"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "if an account exists for this email, a reset link has been sent"})
+}
+
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	err := h.userService.ResetPassword(req.Token, req.Password)
+	if errors.Is(err, application.ErrInvalidToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "invalid or expired token"})
+		return
+	} else if errors.Is(err, application.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "user not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to reset password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password reset, sign in with your new password"})
 }
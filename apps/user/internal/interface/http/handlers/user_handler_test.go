@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/subscription-tracker/user/internal/core/application"
+)
+
+func TestLoginThrottledRetryAfter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		retryAfter time.Duration
+		want       string
+	}{
+		{retryAfter: time.Second, want: "1"},
+		// Partial seconds round up so the client does not retry too early
+		{retryAfter: 1500 * time.Millisecond, want: "2"},
+		{retryAfter: time.Millisecond, want: "1"},
+		{retryAfter: 15*time.Minute - 200*time.Millisecond, want: "900"},
+	}
+
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		loginThrottled(c, &application.LoginThrottledError{RetryAfter: tt.retryAfter})
+
+		if recorder.Code != http.StatusTooManyRequests {
+			t.Errorf("%v: status = %d, want 429", tt.retryAfter, recorder.Code)
+		}
+		if got := recorder.Header().Get("Retry-After"); got != tt.want {
+			t.Errorf("%v: Retry-After = %q, want %q", tt.retryAfter, got, tt.want)
+		}
+	}
+}
@@ -8,7 +8,12 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func AuthMiddleware(jwtSecret []byte) gin.HandlerFunc {
+// TokenVerifier checks the signature, expiry, issuer and audience of a token
+type TokenVerifier interface {
+	Verify(tokenString string, audience string) (jwt.MapClaims, error)
+}
+
+func AuthMiddleware(verifier TokenVerifier, audience string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -18,25 +23,33 @@ func AuthMiddleware(jwtSecret []byte) gin.HandlerFunc {
 		}
 
 		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return jwtSecret, nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := verifier.Verify(tokenString, audience)
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			c.Abort()
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
-			c.Abort()
-			return
-		}
-
 		c.Set("user_id", claims["user_id"])
 		c.Set("email", claims["email"])
+		emailVerified, _ := claims["email_verified"].(bool)
+		c.Set("email_verified", emailVerified)
+		if sessionID, ok := claims["sid"].(string); ok {
+			c.Set("session_id", sessionID)
+		}
 		c.Next()
 	}
 }
+
+// RequireVerifiedEmail restricts a route to users who verified their email address. It
+// has to run after AuthMiddleware.
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetBool("email_verified") {
+			c.Next()
+			return
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "verify your email address first"})
+		c.Abort()
+	}
+}